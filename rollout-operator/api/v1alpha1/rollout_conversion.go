@@ -0,0 +1,369 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+var _ conversion.Convertible = &Rollout{}
+
+// v1alpha1ConversionData v1alpha1 独有、存到 v1beta1 注解里的字段
+type v1alpha1ConversionData struct {
+	RollbackOnFailure *bool `json:"rollbackOnFailure,omitempty"`
+	// FailurePolicyDerived 表示 v1beta1 的 failurePolicy 是由 rollbackOnFailure 推导出来的，原对象未设置
+	FailurePolicyDerived bool `json:"failurePolicyDerived,omitempty"`
+}
+
+// v1beta1ConversionData v1beta1 独有、存到 v1alpha1 注解里的字段
+type v1beta1ConversionData struct {
+	Template  *corev1.PodTemplateSpec  `json:"template,omitempty"`
+	Placement *v1beta1.CanaryPlacement `json:"placement,omitempty"`
+	Schedule  *v1beta1.RolloutSchedule `json:"schedule,omitempty"`
+	// steps 已按 progression 展开后同步到 v1alpha1，这里只保留模板本身
+	Progression   *v1beta1.StepProgression    `json:"progression,omitempty"`
+	DependsOn     []v1beta1.RolloutDependency `json:"dependsOn,omitempty"`
+	StickySession *v1beta1.StickySession      `json:"stickySession,omitempty"`
+	Replicas      int32                       `json:"replicas,omitempty"`
+	DryRun        bool                        `json:"dryRun,omitempty"`
+	Hooks         *v1beta1.RolloutHooks       `json:"hooks,omitempty"`
+	SLOs          []v1beta1.SLOCheck          `json:"slos,omitempty"`
+	// status.plan、status.hooks、status.undo 同样只存在于 v1beta1
+	Plan       *v1beta1.RolloutPlan `json:"plan,omitempty"`
+	HookStatus []v1beta1.HookStatus `json:"hookStatus,omitempty"`
+	Undo       *v1beta1.UndoStatus  `json:"undo,omitempty"`
+}
+
+// ConvertTo 将 v1alpha1 转换为 hub 版本 v1beta1
+func (src *Rollout) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.Rollout)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", dstRaw)
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+
+	s := &src.Spec
+	d := &dst.Spec
+	d.TargetRef = v1beta1.TargetRef{Kind: s.TargetRef.Kind, Name: s.TargetRef.Name, Port: s.TargetRef.Port}
+	d.Strategy = v1beta1.RolloutStrategy{Type: v1beta1.StrategyType(s.Strategy.Type)}
+	if s.Strategy.Steps != nil {
+		d.Strategy.Steps = make([]v1beta1.RolloutStep, len(s.Strategy.Steps))
+		for i, st := range s.Strategy.Steps {
+			d.Strategy.Steps[i] = v1beta1.RolloutStep{Weight: st.Weight, HoldSeconds: st.HoldSeconds}
+		}
+	}
+	d.Analysis = v1beta1.AnalysisSpec{
+		IntervalSeconds:  s.Analysis.IntervalSeconds,
+		SuccessThreshold: s.Analysis.SuccessThreshold,
+		FailureThreshold: s.Analysis.FailureThreshold,
+	}
+	if s.Analysis.Metrics != nil {
+		d.Analysis.Metrics = make([]v1beta1.MetricCheck, len(s.Analysis.Metrics))
+		for i, m := range s.Analysis.Metrics {
+			d.Analysis.Metrics[i] = v1beta1.MetricCheck{Name: m.Name, PromQL: m.PromQL, Threshold: m.Threshold, Compare: m.Compare}
+		}
+	}
+	d.Traffic = v1beta1.TrafficSpec{
+		Provider:      s.Traffic.Provider,
+		Host:          s.Traffic.Host,
+		StableService: s.Traffic.StableService,
+		CanaryService: s.Traffic.CanaryService,
+		OnDelete:      v1beta1.TrafficTarget(s.Traffic.OnDelete),
+	}
+	if s.Notifications != nil {
+		d.Notifications = make([]v1beta1.NotificationSpec, len(s.Notifications))
+		for i, n := range s.Notifications {
+			d.Notifications[i] = v1beta1.NotificationSpec{
+				Type:     n.Type,
+				URL:      n.URL,
+				Headers:  copyStringMap(n.Headers),
+				Template: n.Template,
+			}
+			if n.Phases != nil {
+				d.Notifications[i].Phases = make([]v1beta1.RolloutPhase, len(n.Phases))
+				for j, p := range n.Phases {
+					d.Notifications[i].Phases[j] = v1beta1.RolloutPhase(p)
+				}
+			}
+		}
+	}
+
+	// rollbackOnFailure 在 v1beta1 中已移除，折算进 failurePolicy
+	d.FailurePolicy = v1beta1.FailurePolicy(s.FailurePolicy)
+	d.Paused = s.Paused
+	d.RevisionHistoryLimit = copyInt32Ptr(s.RevisionHistoryLimit)
+	alphaData := v1alpha1ConversionData{RollbackOnFailure: s.RollbackOnFailure}
+	if s.FailurePolicy == "" && s.RollbackOnFailure != nil {
+		d.FailurePolicy = v1beta1.FailurePolicy(s.EffectiveFailurePolicy())
+		alphaData.FailurePolicyDerived = true
+	}
+
+	dst.Status = v1beta1.RolloutStatus{
+		Phase:          v1beta1.RolloutPhase(src.Status.Phase),
+		StepIndex:      src.Status.StepIndex,
+		StableRevision: src.Status.StableRevision,
+		CanaryRevision: src.Status.CanaryRevision,
+		Conditions:     copyConditions(src.Status.Conditions),
+		Steps:          convertStepStatusesTo(src.Status.Steps),
+		Revisions:      convertRevisionsTo(src.Status.Revisions),
+		Abort:          src.Status.Abort,
+	}
+
+	// 还原之前从 v1beta1 转换过来时暂存的字段
+	var betaData v1beta1ConversionData
+	if err := popConversionData(&dst.ObjectMeta, &betaData); err != nil {
+		return err
+	}
+	d.Template = betaData.Template
+	d.Placement = betaData.Placement
+	d.Schedule = betaData.Schedule
+	d.Strategy.Progression = betaData.Progression
+	d.DependsOn = betaData.DependsOn
+	d.Traffic.StickySession = betaData.StickySession
+	d.Traffic.Replicas = betaData.Replicas
+	d.DryRun = betaData.DryRun
+	d.Hooks = betaData.Hooks
+	d.Analysis.SLOs = betaData.SLOs
+	dst.Status.Plan = betaData.Plan
+	dst.Status.Hooks = betaData.HookStatus
+	dst.Status.Undo = betaData.Undo
+
+	if alphaData.RollbackOnFailure != nil {
+		return pushConversionData(&dst.ObjectMeta, alphaData)
+	}
+	return nil
+}
+
+// ConvertFrom 将 hub 版本 v1beta1 转换为 v1alpha1
+func (dst *Rollout) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.Rollout)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", srcRaw)
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+
+	s := &src.Spec
+	d := &dst.Spec
+	d.TargetRef = TargetRef{Kind: s.TargetRef.Kind, Name: s.TargetRef.Name, Port: s.TargetRef.Port}
+	d.Strategy = RolloutStrategy{Type: StrategyType(s.Strategy.Type)}
+	if s.Strategy.Steps != nil {
+		d.Strategy.Steps = make([]RolloutStep, len(s.Strategy.Steps))
+		for i, st := range s.Strategy.Steps {
+			d.Strategy.Steps[i] = RolloutStep{Weight: st.Weight, HoldSeconds: st.HoldSeconds}
+		}
+	}
+	d.Analysis = AnalysisSpec{
+		IntervalSeconds:  s.Analysis.IntervalSeconds,
+		SuccessThreshold: s.Analysis.SuccessThreshold,
+		FailureThreshold: s.Analysis.FailureThreshold,
+	}
+	if s.Analysis.Metrics != nil {
+		d.Analysis.Metrics = make([]MetricCheck, len(s.Analysis.Metrics))
+		for i, m := range s.Analysis.Metrics {
+			d.Analysis.Metrics[i] = MetricCheck{Name: m.Name, PromQL: m.PromQL, Threshold: m.Threshold, Compare: m.Compare}
+		}
+	}
+	d.Traffic = TrafficSpec{
+		Provider:      s.Traffic.Provider,
+		Host:          s.Traffic.Host,
+		StableService: s.Traffic.StableService,
+		CanaryService: s.Traffic.CanaryService,
+		OnDelete:      TrafficTarget(s.Traffic.OnDelete),
+	}
+	if s.Notifications != nil {
+		d.Notifications = make([]NotificationSpec, len(s.Notifications))
+		for i, n := range s.Notifications {
+			d.Notifications[i] = NotificationSpec{
+				Type:     n.Type,
+				URL:      n.URL,
+				Headers:  copyStringMap(n.Headers),
+				Template: n.Template,
+			}
+			if n.Phases != nil {
+				d.Notifications[i].Phases = make([]RolloutPhase, len(n.Phases))
+				for j, p := range n.Phases {
+					d.Notifications[i].Phases[j] = RolloutPhase(p)
+				}
+			}
+		}
+	}
+	d.FailurePolicy = FailurePolicy(s.FailurePolicy)
+	d.Paused = s.Paused
+	d.RevisionHistoryLimit = copyInt32Ptr(s.RevisionHistoryLimit)
+
+	dst.Status = RolloutStatus{
+		Phase:          RolloutPhase(src.Status.Phase),
+		StepIndex:      src.Status.StepIndex,
+		StableRevision: src.Status.StableRevision,
+		CanaryRevision: src.Status.CanaryRevision,
+		Conditions:     copyConditions(src.Status.Conditions),
+		Steps:          convertStepStatusesFrom(src.Status.Steps),
+		Revisions:      convertRevisionsFrom(src.Status.Revisions),
+		Abort:          src.Status.Abort,
+	}
+
+	// 还原之前从 v1alpha1 转换过来时暂存的字段
+	var alphaData v1alpha1ConversionData
+	if err := popConversionData(&dst.ObjectMeta, &alphaData); err != nil {
+		return err
+	}
+	d.RollbackOnFailure = alphaData.RollbackOnFailure
+	if alphaData.FailurePolicyDerived {
+		d.FailurePolicy = ""
+	}
+
+	if s.Template != nil || s.Placement != nil || s.Schedule != nil || s.Strategy.Progression != nil || s.DependsOn != nil ||
+		s.Traffic.StickySession != nil || s.Traffic.Replicas != 0 || s.DryRun || s.Hooks != nil ||
+		s.Analysis.SLOs != nil ||
+		src.Status.Plan != nil || src.Status.Hooks != nil || src.Status.Undo != nil {
+		data := v1beta1ConversionData{
+			Template:      s.Template.DeepCopy(),
+			Placement:     s.Placement.DeepCopy(),
+			Schedule:      s.Schedule.DeepCopy(),
+			Progression:   s.Strategy.Progression.DeepCopy(),
+			StickySession: s.Traffic.StickySession.DeepCopy(),
+			Replicas:      s.Traffic.Replicas,
+			DryRun:        s.DryRun,
+			Hooks:         s.Hooks.DeepCopy(),
+			Plan:          src.Status.Plan.DeepCopy(),
+			Undo:          src.Status.Undo.DeepCopy(),
+		}
+		if s.DependsOn != nil {
+			data.DependsOn = append([]v1beta1.RolloutDependency{}, s.DependsOn...)
+		}
+		if s.Analysis.SLOs != nil {
+			data.SLOs = append([]v1beta1.SLOCheck{}, s.Analysis.SLOs...)
+		}
+		for _, h := range src.Status.Hooks {
+			data.HookStatus = append(data.HookStatus, *h.DeepCopy())
+		}
+		return pushConversionData(&dst.ObjectMeta, data)
+	}
+	return nil
+}
+
+// popConversionData 读取并移除暂存注解
+func popConversionData(meta *metav1.ObjectMeta, into interface{}) error {
+	raw, ok := meta.Annotations[v1beta1.ConversionDataAnnotation]
+	if !ok {
+		return nil
+	}
+	delete(meta.Annotations, v1beta1.ConversionDataAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	if err := json.Unmarshal([]byte(raw), into); err != nil {
+		return fmt.Errorf("decode %s annotation: %w", v1beta1.ConversionDataAnnotation, err)
+	}
+	return nil
+}
+
+// pushConversionData 将目标版本无法表达的字段写入注解
+func pushConversionData(meta *metav1.ObjectMeta, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[v1beta1.ConversionDataAnnotation] = string(raw)
+	return nil
+}
+
+func copyStringMap(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func convertStepStatusesTo(in []StepStatus) []v1beta1.StepStatus {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1beta1.StepStatus, len(in))
+	for i, st := range in {
+		out[i] = v1beta1.StepStatus{Index: st.Index, Weight: st.Weight, StartedAt: *st.StartedAt.DeepCopy(), HoldUntil: st.HoldUntil.DeepCopy(),
+			AnalyzedAt: st.AnalyzedAt.DeepCopy(), Successes: st.Successes, Failures: st.Failures}
+	}
+	return out
+}
+
+func convertStepStatusesFrom(in []v1beta1.StepStatus) []StepStatus {
+	if in == nil {
+		return nil
+	}
+	out := make([]StepStatus, len(in))
+	for i, st := range in {
+		out[i] = StepStatus{Index: st.Index, Weight: st.Weight, StartedAt: *st.StartedAt.DeepCopy(), HoldUntil: st.HoldUntil.DeepCopy(),
+			AnalyzedAt: st.AnalyzedAt.DeepCopy(), Successes: st.Successes, Failures: st.Failures}
+	}
+	return out
+}
+
+func convertRevisionsTo(in []RevisionRecord) []v1beta1.RevisionRecord {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1beta1.RevisionRecord, len(in))
+	for i, r := range in {
+		out[i] = v1beta1.RevisionRecord{Name: r.Name, Revision: r.Revision, CreatedAt: *r.CreatedAt.DeepCopy(), Phase: v1beta1.RolloutPhase(r.Phase)}
+	}
+	return out
+}
+
+func convertRevisionsFrom(in []v1beta1.RevisionRecord) []RevisionRecord {
+	if in == nil {
+		return nil
+	}
+	out := make([]RevisionRecord, len(in))
+	for i, r := range in {
+		out[i] = RevisionRecord{Name: r.Name, Revision: r.Revision, CreatedAt: *r.CreatedAt.DeepCopy(), Phase: RolloutPhase(r.Phase)}
+	}
+	return out
+}
+
+func copyInt32Ptr(in *int32) *int32 {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func copyConditions(in []metav1.Condition) []metav1.Condition {
+	if in == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
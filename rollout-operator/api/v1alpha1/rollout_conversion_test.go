@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"math/rand"
+
+	fuzz "github.com/google/gofuzz"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+const fuzzIterations = 500
+
+// podTemplateFuzzer 只生成能经受 JSON 往返的 Pod 模板字段，避免 Quantity 等类型的随机内部表示干扰比较
+func podTemplateFuzzer(_ serializer.CodecFactory) []interface{} {
+	return []interface{}{
+		func(t *corev1.PodTemplateSpec, c fuzz.Continue) {
+			t.Labels = map[string]string{"app": c.RandString()}
+			t.Spec.Containers = []corev1.Container{{
+				Name:  c.RandString(),
+				Image: c.RandString(),
+				Ports: []corev1.ContainerPort{{ContainerPort: c.Int31()}},
+			}}
+		},
+	}
+}
+
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	scheme := runtime.NewScheme()
+	Expect(AddToScheme(scheme)).To(Succeed())
+	Expect(v1beta1.AddToScheme(scheme)).To(Succeed())
+	funcs := fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, podTemplateFuzzer)
+	return fuzzer.FuzzerFor(funcs, rand.NewSource(seed), serializer.NewCodecFactory(scheme))
+}
+
+var _ = Describe("Rollout conversion", func() {
+	It("round-trips v1alpha1 -> v1beta1 -> v1alpha1", func() {
+		f := newFuzzer(GinkgoRandomSeed())
+		for i := 0; i < fuzzIterations; i++ {
+			original := &Rollout{}
+			f.Fuzz(original)
+			in := original.DeepCopy()
+
+			hub := &v1beta1.Rollout{}
+			Expect(in.ConvertTo(hub)).To(Succeed())
+			out := &Rollout{}
+			Expect(out.ConvertFrom(hub)).To(Succeed())
+
+			Expect(apiequality.Semantic.DeepEqual(original, in)).To(BeTrue(), "ConvertTo mutated its input")
+			Expect(apiequality.Semantic.DeepEqual(original, out)).To(BeTrue(), diff.ObjectReflectDiff(original, out))
+		}
+	})
+
+	It("round-trips v1beta1 -> v1alpha1 -> v1beta1", func() {
+		f := newFuzzer(GinkgoRandomSeed())
+		for i := 0; i < fuzzIterations; i++ {
+			original := &v1beta1.Rollout{}
+			f.Fuzz(original)
+			in := original.DeepCopy()
+
+			spoke := &Rollout{}
+			Expect(spoke.ConvertFrom(in)).To(Succeed())
+			out := &v1beta1.Rollout{}
+			Expect(spoke.ConvertTo(out)).To(Succeed())
+
+			Expect(apiequality.Semantic.DeepEqual(original, in)).To(BeTrue(), "ConvertFrom mutated its input")
+			Expect(apiequality.Semantic.DeepEqual(original, out)).To(BeTrue(), diff.ObjectReflectDiff(original, out))
+		}
+	})
+
+	It("folds rollbackOnFailure into failurePolicy", func() {
+		disabled := false
+		ro := &Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo"},
+			Spec:       RolloutSpec{RollbackOnFailure: &disabled},
+		}
+		hub := &v1beta1.Rollout{}
+		Expect(ro.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.FailurePolicy).To(Equal(v1beta1.FailurePolicyNone))
+		Expect(hub.Annotations).To(HaveKey(v1beta1.ConversionDataAnnotation))
+	})
+
+	It("keeps the v1beta1 pod template when read through v1alpha1", func() {
+		hub := &v1beta1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo"},
+			Spec: v1beta1.RolloutSpec{
+				Template: &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "registry.local/app:v2"}},
+				}},
+			},
+		}
+		spoke := &Rollout{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Annotations).To(HaveKey(v1beta1.ConversionDataAnnotation))
+
+		// 通过 v1alpha1 修改其他字段后写回，模板不能丢
+		spoke.Spec.Traffic.Host = "demo.example.local"
+		back := &v1beta1.Rollout{}
+		Expect(spoke.ConvertTo(back)).To(Succeed())
+		Expect(back.Spec.Template).NotTo(BeNil())
+		Expect(back.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.local/app:v2"))
+		Expect(back.Spec.Traffic.Host).To(Equal("demo.example.local"))
+		Expect(back.Annotations).NotTo(HaveKey(v1beta1.ConversionDataAnnotation))
+	})
+})
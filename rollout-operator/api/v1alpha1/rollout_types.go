@@ -92,6 +92,12 @@ type RolloutStrategy struct {
 	Steps []RolloutStep `json:"steps,omitempty"`
 }
 
+// MetricCheck.Compare 取值
+const (
+	CompareLT = "LT"
+	CompareGT = "GT"
+)
+
 type MetricCheck struct {
 	Name      string `json:"name"`
 	PromQL    string `json:"promQL"`
@@ -110,8 +116,9 @@ type AnalysisSpec struct {
 	// +kubebuilder:default=2
 	// +kubebuilder:validation:Minimum=1
 	FailureThreshold int32 `json:"failureThreshold,omitempty"`
-	// 最少 1 个；先可用“就绪率”代替
-	Metrics []MetricCheck `json:"metrics"`
+	// 自定义指标检查：promQL 的结果须满足 compare（LT 小于、GT 大于）threshold，占位替换同 slos；需要控制器配置 --prometheus-address
+	// +optional
+	Metrics []MetricCheck `json:"metrics,omitempty"`
 }
 
 type TrafficSpec struct {
@@ -120,11 +127,40 @@ type TrafficSpec struct {
 	Host          string `json:"host"`
 	StableService string `json:"stableService"`
 	CanaryService string `json:"canaryService"`
+	// Rollout 删除时清理流量资源之前先把流量切到哪一侧
+	// +kubebuilder:validation:Enum=Stable;Canary
+	// +kubebuilder:default=Stable
+	// +optional
+	OnDelete TrafficTarget `json:"onDelete,omitempty"`
+}
+
+// TrafficTarget 流量切换的目标一侧
+type TrafficTarget string
+
+const (
+	TrafficTargetStable TrafficTarget = "Stable"
+	TrafficTargetCanary TrafficTarget = "Canary"
+)
+
+type NotificationSpec struct {
+	// Webhook: POST 事件 JSON；Slack: incoming webhook 格式；Template: 按 template 渲染请求体
+	// +kubebuilder:validation:Enum=Webhook;Slack;Template
+	// +kubebuilder:default=Webhook
+	Type string `json:"type,omitempty"`
+	URL  string `json:"url"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// Go text/template，数据为通知事件（Namespace/Name/OldPhase/Phase/StepIndex/Weight/Message/Time）
+	// +optional
+	Template string `json:"template,omitempty"`
+	// 只在进入这些阶段时通知；为空时只通知 Paused、Succeeded、Failed、RolledBack
+	// +optional
+	Phases []RolloutPhase `json:"phases,omitempty"`
 }
 
 type TargetRef struct {
-	// 先固定 Deployment；后续可扩展
-	// +kubebuilder:validation:Enum=Deployment
+	// Deployment：stable/canary 各一个 Deployment；StatefulSet：单个 StatefulSet 按 partition 分批升级
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
 	Kind string `json:"kind"`
 	Name string `json:"name"`
 	// +kubebuilder:validation:Minimum=1
@@ -132,13 +168,41 @@ type TargetRef struct {
 	Port int32 `json:"port"`
 }
 
+// FailurePolicy 分析失败后的处理方式
+type FailurePolicy string
+
+const (
+	// FailurePolicyAuto 自动将流量切回 stable，进入 RolledBack
+	FailurePolicyAuto FailurePolicy = "Auto"
+	// FailurePolicyManual 保持当前权重并暂停，等待人工决定继续或中止
+	FailurePolicyManual FailurePolicy = "Manual"
+	// FailurePolicyNone 不动流量，直接标记 Failed
+	FailurePolicyNone FailurePolicy = "None"
+)
+
 type RolloutSpec struct {
 	TargetRef TargetRef       `json:"targetRef"`
 	Strategy  RolloutStrategy `json:"strategy"`
 	Analysis  AnalysisSpec    `json:"analysis"`
 	Traffic   TrafficSpec     `json:"traffic"`
-	// +kubebuilder:default=true
-	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+	// 分析失败后的处理方式；未设置时由 rollbackOnFailure 推导
+	// +kubebuilder:validation:Enum=Auto;Manual;None
+	// +optional
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+	// Deprecated: 使用 failurePolicy。未设置或 true 等价于 Auto，false 等价于 None
+	// +optional
+	RollbackOnFailure *bool `json:"rollbackOnFailure,omitempty"`
+	// 阶段变化时的通知目标，与集群级通知 ConfigMap 中的目标合并
+	// +optional
+	Notifications []NotificationSpec `json:"notifications,omitempty"`
+	// 为 true 时保持当前步骤不再推进，流量维持现状
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// 保留的历史版本（Pod 模板快照）数量，当前 stable/canary 版本不计入淘汰
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 }
 
 type RolloutPhase string
@@ -150,8 +214,57 @@ const (
 	PhaseSucceeded   RolloutPhase = "Succeeded"
 	PhaseFailed      RolloutPhase = "Failed"
 	PhaseRolledBack  RolloutPhase = "RolledBack"
+	// PhasePaused 分析失败且 failurePolicy=Manual，保持当前权重等待人工处理
+	PhasePaused RolloutPhase = "Paused"
 )
 
+// InProgress 发布是否正在进行（已经开始调整流量且尚未结束）
+func (p RolloutPhase) InProgress() bool {
+	return p == PhaseProgressing || p == PhaseAnalyzing || p == PhasePaused
+}
+
+// EffectiveFailurePolicy 返回实际生效的失败处理方式，兼容旧的 rollbackOnFailure 字段
+func (s *RolloutSpec) EffectiveFailurePolicy() FailurePolicy {
+	if s.FailurePolicy != "" {
+		return s.FailurePolicy
+	}
+	if s.RollbackOnFailure != nil && !*s.RollbackOnFailure {
+		return FailurePolicyNone
+	}
+	return FailurePolicyAuto
+}
+
+// StepStatus 单个步骤的时间记录，重启或无关事件触发调和时据此计算剩余等待时间
+type StepStatus struct {
+	Index  int32 `json:"index"`
+	Weight int32 `json:"weight"`
+	// 下发该步骤权重的时间
+	StartedAt metav1.Time `json:"startedAt"`
+	// 分析通过后 hold 结束的时间；为空表示该步骤尚未通过分析
+	// +optional
+	HoldUntil *metav1.Time `json:"holdUntil,omitempty"`
+	// 最近一次分析的时间，下一次分析在 intervalSeconds 之后
+	// +optional
+	AnalyzedAt *metav1.Time `json:"analyzedAt,omitempty"`
+	// 连续通过与连续失败的分析次数，分别达到 successThreshold、failureThreshold 时本步骤通过或失败
+	// +optional
+	Successes int32 `json:"successes,omitempty"`
+	// +optional
+	Failures int32 `json:"failures,omitempty"`
+}
+
+// RevisionRecord 一个历史版本，Pod 模板快照保存在同名 ControllerRevision 中
+type RevisionRecord struct {
+	// ControllerRevision 名称，<rollout>-<模板哈希>
+	Name string `json:"name"`
+	// 递增序号；回到旧模板（undo）时沿用原快照并分配新的序号
+	Revision  int64       `json:"revision"`
+	CreatedAt metav1.Time `json:"createdAt"`
+	// 该版本最近一次发布的结果
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+}
+
 type RolloutStatus struct {
 	Phase          RolloutPhase `json:"phase,omitempty"`
 	StepIndex      int32        `json:"stepIndex,omitempty"`
@@ -160,6 +273,29 @@ type RolloutStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// 已开始步骤的时间记录
+	// +listType=map
+	// +listMapKey=index
+	// +optional
+	Steps []StepStatus `json:"steps,omitempty"`
+	// 历史版本，按 revision 升序，数量受 spec.revisionHistoryLimit 限制
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Revisions []RevisionRecord `json:"revisions,omitempty"`
+	// 由 kubectl rollout abort 设置：控制器把流量切回 stable 并进入 RolledBack，retry 时清除
+	// +optional
+	Abort bool `json:"abort,omitempty"`
+}
+
+// StepStatus 返回指定步骤的时间记录，尚未开始时返回 nil
+func (s *RolloutStatus) StepStatus(index int32) *StepStatus {
+	for i := range s.Steps {
+		if s.Steps[i].Index == index {
+			return &s.Steps[i]
+		}
+	}
+	return nil
 }
 
 // +kubebuilder:object:root=true
@@ -167,8 +303,6 @@ type RolloutStatus struct {
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Step",type=integer,JSONPath=`.status.stepIndex`
 // +kubebuilder:printcolumn:name="Strategy",type=string,JSONPath=`.spec.strategy.type`
-// +kubebuilder:webhook:path=/mutate-delivery-example-com-v1alpha1-rollout,mutating=true,failurePolicy=fail,sideEffects=None,groups=delivery.example.com,resources=rollouts,verbs=create;update,versions=v1alpha1,name=mrollout.kb.io,admissionReviewVersions=v1
-// +kubebuilder:webhook:path=/validate-delivery-example-com-v1alpha1-rollout,mutating=false,failurePolicy=fail,sideEffects=None,groups=delivery.example.com,resources=rollouts,verbs=create;update,versions=v1alpha1,name=vrollout.kb.io,admissionReviewVersions=v1
 type Rollout struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
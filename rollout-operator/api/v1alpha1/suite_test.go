@@ -17,31 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"testing"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
 )
 
-var _ = Describe("Rollout Webhook", func() {
-
-	Context("When creating Rollout under Defaulting Webhook", func() {
-		It("Should fill in the default value if a required field is empty", func() {
-
-			// TODO(user): Add your logic here
-
-		})
-	})
-
-	Context("When creating Rollout under Validating Webhook", func() {
-		It("Should deny if a required field is empty", func() {
-
-			// TODO(user): Add your logic here
-
-		})
-
-		It("Should admit if all required fields are provided", func() {
-
-			// TODO(user): Add your logic here
-
-		})
-	})
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
 
-})
+	RunSpecs(t, "v1alpha1 Suite")
+}
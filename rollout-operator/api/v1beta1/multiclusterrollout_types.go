@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MultiClusterLabel 标记成员集群中由 MultiClusterRollout 创建的 Rollout
+const MultiClusterLabel = "delivery.example.com/multicluster"
+
+// ClusterWave 一批成员集群，批内并行发布
+type ClusterWave struct {
+	// 仅用于展示，如 staging、canary-region
+	// +optional
+	Name string `json:"name,omitempty"`
+	// hub 配置中的集群名称
+	// +kubebuilder:validation:MinItems=1
+	Clusters []string `json:"clusters"`
+}
+
+type MultiClusterRolloutSpec struct {
+	// 在每个成员集群中创建的 Rollout 的 spec，Rollout 与本对象同名、同 namespace
+	Template RolloutSpec `json:"template"`
+	// 按顺序推进的集群批次：前一批全部 Succeeded 后才更新下一批
+	// +kubebuilder:validation:MinItems=1
+	Waves []ClusterWave `json:"waves"`
+}
+
+type MultiClusterPhase string
+
+const (
+	MultiClusterProgressing MultiClusterPhase = "Progressing"
+	MultiClusterSucceeded   MultiClusterPhase = "Succeeded"
+	// MultiClusterHalted 有集群回滚或失败，后续批次不再更新
+	MultiClusterHalted MultiClusterPhase = "Halted"
+)
+
+// ClusterRolloutStatus 成员集群中 Rollout 的状态摘要
+type ClusterRolloutStatus struct {
+	Cluster string `json:"cluster"`
+	Wave    int32  `json:"wave"`
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+	// +optional
+	StepIndex int32 `json:"stepIndex,omitempty"`
+	// 无法访问集群等错误
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type MultiClusterRolloutStatus struct {
+	// 模板哈希；模板变化时从第一批重新开始
+	// +optional
+	Release string `json:"release,omitempty"`
+	// +optional
+	Phase MultiClusterPhase `json:"phase,omitempty"`
+	// 正在发布的批次
+	// +optional
+	CurrentWave int32 `json:"currentWave,omitempty"`
+	// 已开始发布的集群
+	// +listType=map
+	// +listMapKey=cluster
+	// +optional
+	Clusters []ClusterRolloutStatus `json:"clusters,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Cluster 返回指定集群的状态，尚未开始时返回 nil
+func (s *MultiClusterRolloutStatus) Cluster(name string) *ClusterRolloutStatus {
+	for i := range s.Clusters {
+		if s.Clusters[i].Cluster == name {
+			return &s.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Wave",type=integer,JSONPath=`.status.currentWave`
+
+// MultiClusterRollout 由 hub 控制器按集群批次在多个集群中发布同一个 Rollout
+type MultiClusterRollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              MultiClusterRolloutSpec   `json:"spec"`
+	Status            MultiClusterRolloutStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type MultiClusterRolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MultiClusterRollout `json:"items"`
+}
+
+func init() { SchemeBuilder.Register(&MultiClusterRollout{}, &MultiClusterRolloutList{}) }
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var multiclusterrolloutlog = logf.Log.WithName("multiclusterrollout-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *MultiClusterRollout) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-delivery-example-com-v1beta1-multiclusterrollout,mutating=false,failurePolicy=fail,sideEffects=None,groups=delivery.example.com,resources=multiclusterrollouts,verbs=create;update,versions=v1beta1,name=vmulticlusterrollout.kb.io,admissionReviewVersions=v1
+
+var _ admission.Validator = &MultiClusterRollout{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *MultiClusterRollout) ValidateCreate() (admission.Warnings, error) {
+	multiclusterrolloutlog.Info("validate create", "name", r.Name)
+
+	return nil, r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *MultiClusterRollout) ValidateUpdate(_ runtime.Object) (admission.Warnings, error) {
+	multiclusterrolloutlog.Info("validate update", "name", r.Name)
+
+	return nil, r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *MultiClusterRollout) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate 只校验批次；模板由成员集群中 Rollout 的 webhook 校验，错误会出现在 status.clusters 中
+func (r *MultiClusterRollout) validate() error {
+	var allErrs field.ErrorList
+	fp := field.NewPath("spec")
+	if len(r.Spec.Waves) == 0 {
+		allErrs = append(allErrs, field.Required(fp.Child("waves"), "at least 1 wave"))
+	}
+	// 一个集群只能属于一个批次
+	seen := map[string]bool{}
+	for i, w := range r.Spec.Waves {
+		wp := fp.Child("waves").Index(i).Child("clusters")
+		if len(w.Clusters) == 0 {
+			allErrs = append(allErrs, field.Required(wp, "at least 1 cluster"))
+		}
+		for j, name := range w.Clusters {
+			switch {
+			case name == "":
+				allErrs = append(allErrs, field.Required(wp.Index(j), "cluster name required"))
+			case seen[name]:
+				allErrs = append(allErrs, field.Duplicate(wp.Index(j), name))
+			}
+			seen[name] = true
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return allErrs.ToAggregate()
+}
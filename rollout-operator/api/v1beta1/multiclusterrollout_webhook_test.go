@@ -0,0 +1,57 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("MultiClusterRollout Webhook", func() {
+	validRollout := func() *MultiClusterRollout {
+		return &MultiClusterRollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "default"},
+			Spec: MultiClusterRolloutSpec{
+				Waves: []ClusterWave{
+					{Name: "staging", Clusters: []string{"staging"}},
+					{Name: "rest", Clusters: []string{"eu-west", "us-east"}},
+				},
+			},
+		}
+	}
+
+	It("Should admit waves with distinct clusters", func() {
+		_, err := validRollout().ValidateCreate()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should deny a cluster listed in two waves", func() {
+		m := validRollout()
+		m.Spec.Waves[1].Clusters = append(m.Spec.Waves[1].Clusters, "staging")
+		_, err := m.ValidateCreate()
+		Expect(err).To(MatchError(ContainSubstring("spec.waves[1].clusters[2]: Duplicate value")))
+	})
+
+	It("Should deny an empty wave", func() {
+		m := validRollout()
+		m.Spec.Waves = append(m.Spec.Waves, ClusterWave{Name: "empty"})
+		_, err := m.ValidateUpdate(validRollout())
+		Expect(err).To(MatchError(ContainSubstring("spec.waves[2].clusters")))
+	})
+})
@@ -0,0 +1,23 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+// ConversionDataAnnotation 保存转换到另一版本时无法表达的字段，转换回来时据此还原
+const ConversionDataAnnotation = "delivery.example.com/conversion-data"
+
+// Hub 标记 v1beta1 为转换中心（存储版本），其他版本都与它互相转换
+func (*Rollout) Hub() {}
@@ -0,0 +1,755 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type StrategyType string
+
+const (
+	Canary    StrategyType = "Canary"
+	BlueGreen StrategyType = "BlueGreen"
+)
+
+type RolloutStep struct {
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+	// +kubebuilder:default=180
+	// +kubebuilder:validation:Minimum=0
+	HoldSeconds int32 `json:"holdSeconds,omitempty"`
+}
+
+type RolloutStrategy struct {
+	// +kubebuilder:default=Canary
+	Type StrategyType `json:"type,omitempty"`
+	// Canary 模式使用；BlueGreen 留空
+	// +optional
+	Steps []RolloutStep `json:"steps,omitempty"`
+	// 步骤生成模板；设置后 defaulting webhook 每次都按它重新生成 steps，手写的 steps 会被覆盖
+	// +optional
+	Progression *StepProgression `json:"progression,omitempty"`
+}
+
+// ProgressionType 步骤权重的增长方式
+type ProgressionType string
+
+const (
+	// ProgressionLinear 每步增加固定权重
+	ProgressionLinear ProgressionType = "Linear"
+	// ProgressionExponential 每步权重乘以固定倍数
+	ProgressionExponential ProgressionType = "Exponential"
+)
+
+// MaxProgressionSteps 模板最多展开的步骤数（含最后的 100%）
+const MaxProgressionSteps = 20
+
+type StepProgression struct {
+	// +kubebuilder:validation:Enum=Linear;Exponential
+	Type ProgressionType `json:"type"`
+	// 第一步的权重
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Start int32 `json:"start"`
+	// Linear：每步增加的权重
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Increment int32 `json:"increment,omitempty"`
+	// Exponential：每步权重乘以的倍数
+	// +kubebuilder:validation:Minimum=2
+	// +optional
+	Factor int32 `json:"factor,omitempty"`
+	// 除最后一步外每步的 holdSeconds
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+	// 相邻两步权重增加的上限，0 表示不限制；指数增长到后期时用它限制单步放量
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxSurge int32 `json:"maxSurge,omitempty"`
+}
+
+// Steps 按模板展开步骤，最后一步固定为 100；参数无法推进时返回 nil，
+// 超过 MaxProgressionSteps 时只展开到上限，由校验拒绝
+func (p *StepProgression) Steps() []RolloutStep {
+	if p.Start < 1 || p.Start > 100 {
+		return nil
+	}
+	var steps []RolloutStep
+	for w := p.Start; ; {
+		if w >= 100 {
+			return append(steps, RolloutStep{Weight: 100})
+		}
+		if len(steps) >= MaxProgressionSteps {
+			return steps
+		}
+		steps = append(steps, RolloutStep{Weight: w, HoldSeconds: p.IntervalSeconds})
+
+		var next int64
+		switch p.Type {
+		case ProgressionLinear:
+			if p.Increment < 1 {
+				return nil
+			}
+			next = int64(w) + int64(p.Increment)
+		case ProgressionExponential:
+			if p.Factor < 2 {
+				return nil
+			}
+			next = int64(w) * int64(p.Factor)
+		default:
+			return nil
+		}
+		if p.MaxSurge > 0 && next-int64(w) > int64(p.MaxSurge) {
+			next = int64(w) + int64(p.MaxSurge)
+		}
+		w = int32(min(next, 100))
+	}
+}
+
+// MetricCheck.Compare 取值
+const (
+	CompareLT = "LT"
+	CompareGT = "GT"
+)
+
+type MetricCheck struct {
+	Name      string `json:"name"`
+	PromQL    string `json:"promQL"`
+	Threshold string `json:"threshold"`
+	// +kubebuilder:validation:Enum=LT;GT
+	Compare string `json:"compare"`
+}
+
+type AnalysisSpec struct {
+	// +kubebuilder:default=30
+	// +kubebuilder:validation:Minimum=1
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+	// +kubebuilder:default=2
+	// +kubebuilder:validation:Minimum=1
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+	// +kubebuilder:default=2
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	// 自定义指标检查：promQL 的结果须满足 compare（LT 小于、GT 大于）threshold，占位替换同 slos；需要控制器配置 --prometheus-address
+	// +optional
+	Metrics []MetricCheck `json:"metrics,omitempty"`
+	// 基于错误预算的检查：按步骤窗口计算 canary 的燃烧率，超过 maxBurnRate 时本步骤失败；需要控制器配置 --prometheus-address
+	// +optional
+	SLOs []SLOCheck `json:"slos,omitempty"`
+}
+
+// SLOCheck.Type 取值
+const (
+	SLOAvailability = "Availability"
+	SLOLatency      = "Latency"
+)
+
+// DefaultMaxBurnRate spec.analysis.slos[].maxBurnRate 的默认值
+const DefaultMaxBurnRate = "2"
+
+// SLOCheck 一个服务等级目标。燃烧率 = 窗口内坏事件比例 / (1 - objective)，1 表示恰好按 SLO 允许的速度消耗错误预算。
+// 查询中的 {{window}} 替换为步骤开始至今的时长（不足 1m 时本次结果不计入），{{threshold}} 替换为延迟阈值（秒），
+// {{namespace}}、{{rollout}}、{{stableService}}、{{canaryService}} 替换为对应的值
+type SLOCheck struct {
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=Availability;Latency
+	Type string `json:"type"`
+	// 目标百分比，如 "99.9"；Latency 表示快于 thresholdMillis 的请求占比，即延迟分位
+	Objective string `json:"objective"`
+	// Latency 专用：延迟阈值（毫秒）
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ThresholdMillis int32 `json:"thresholdMillis,omitempty"`
+	// 窗口内 canary 的坏事件数：Availability 为失败的请求，Latency 为慢于阈值的请求
+	ErrorQuery string `json:"errorQuery"`
+	// 窗口内 canary 的请求总数；为 0 时本次检查视为通过
+	TotalQuery string `json:"totalQuery"`
+	// 允许的燃烧率上限
+	// +kubebuilder:default="2"
+	// +optional
+	MaxBurnRate string `json:"maxBurnRate,omitempty"`
+}
+
+type TrafficSpec struct {
+	// +kubebuilder:validation:Enum=NginxIngress;ReplicaRatio;SMI
+	Provider string `json:"provider"`
+	// NginxIngress 为域名；ReplicaRatio 为同时选中 stable/canary Pod 的共享 Service 名称；SMI 为 TrafficSplit 的根 Service 名称
+	Host          string `json:"host"`
+	StableService string `json:"stableService"`
+	CanaryService string `json:"canaryService"`
+	// Rollout 删除时清理流量资源之前先把流量切到哪一侧
+	// +kubebuilder:validation:Enum=Stable;Canary
+	// +kubebuilder:default=Stable
+	// +optional
+	OnDelete TrafficTarget `json:"onDelete,omitempty"`
+	// 会话保持；为空时每个请求都按权重重新选择 stable/canary
+	// +optional
+	StickySession *StickySession `json:"stickySession,omitempty"`
+	// ReplicaRatio 专用：stable 与 canary 共享的 Pod 总数，权重按该总数换算成 canary 副本数
+	// +kubebuilder:validation:Minimum=2
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// spec.traffic.provider 支持的流量层实现
+const (
+	ProviderNginxIngress = "NginxIngress"
+	ProviderReplicaRatio = "ReplicaRatio"
+	ProviderSMI          = "SMI"
+)
+
+// DefaultReplicaRatioReplicas ReplicaRatio 未设置 spec.traffic.replicas 时的 Pod 总数，权重粒度为 10%
+const DefaultReplicaRatioReplicas = 10
+
+// DefaultStickyCookieName spec.traffic.stickySession.cookieName 的默认值
+const DefaultStickyCookieName = "rollout-canary"
+
+// StickySession 基于 cookie 的会话保持：客户端被分到 canary 后，随权重增加一直留在 canary，直到发布结束或 cookie 过期
+type StickySession struct {
+	// +kubebuilder:default=rollout-canary
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-]+$`
+	// +optional
+	CookieName string `json:"cookieName,omitempty"`
+	// cookie 有效期（秒）；0 表示浏览器关闭即失效
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAgeSeconds int32 `json:"maxAgeSeconds,omitempty"`
+}
+
+// TrafficTarget 流量切换的目标一侧
+type TrafficTarget string
+
+const (
+	TrafficTargetStable TrafficTarget = "Stable"
+	TrafficTargetCanary TrafficTarget = "Canary"
+)
+
+type NotificationSpec struct {
+	// Webhook: POST 事件 JSON；Slack: incoming webhook 格式；Template: 按 template 渲染请求体
+	// +kubebuilder:validation:Enum=Webhook;Slack;Template
+	// +kubebuilder:default=Webhook
+	Type string `json:"type,omitempty"`
+	URL  string `json:"url"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// Go text/template，数据为通知事件（Namespace/Name/OldPhase/Phase/StepIndex/Weight/Message/Time）
+	// +optional
+	Template string `json:"template,omitempty"`
+	// 只在进入这些阶段时通知；为空时只通知 Paused、Succeeded、Failed、RolledBack
+	// +optional
+	Phases []RolloutPhase `json:"phases,omitempty"`
+}
+
+// targetRef.kind 支持的工作负载类型
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+)
+
+type TargetRef struct {
+	// Deployment：stable/canary 各一个 Deployment；StatefulSet：单个 StatefulSet 按 partition 分批升级
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// FailurePolicy 分析失败后的处理方式
+type FailurePolicy string
+
+const (
+	// FailurePolicyAuto 自动将流量切回 stable，进入 RolledBack
+	FailurePolicyAuto FailurePolicy = "Auto"
+	// FailurePolicyManual 保持当前权重并暂停，等待人工决定继续或中止
+	FailurePolicyManual FailurePolicy = "Manual"
+	// FailurePolicyNone 不动流量，直接标记 Failed
+	FailurePolicyNone FailurePolicy = "None"
+)
+
+type RolloutSpec struct {
+	TargetRef TargetRef `json:"targetRef"`
+	// stable/canary 工作负载的 Pod 模板；为空时使用内置的演示镜像
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Template *corev1.PodTemplateSpec `json:"template,omitempty"`
+	Strategy RolloutStrategy         `json:"strategy"`
+	Analysis AnalysisSpec            `json:"analysis"`
+	Traffic  TrafficSpec             `json:"traffic"`
+	// 分析失败后的处理方式
+	// +kubebuilder:validation:Enum=Auto;Manual;None
+	// +kubebuilder:default=Auto
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+	// 阶段变化时的通知目标，与集群级通知 ConfigMap 中的目标合并
+	// +optional
+	Notifications []NotificationSpec `json:"notifications,omitempty"`
+	// 为 true 时保持当前步骤不再推进，流量维持现状
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// 保留的历史版本（Pod 模板快照）数量，当前 stable/canary 版本不计入淘汰
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// canary Pod 的调度约束，注入到 canary 的 Pod 模板中
+	// +optional
+	Placement *CanaryPlacement `json:"placement,omitempty"`
+	// 发布窗口与封禁日期；窗口外保持当前步骤，回滚与中止不受限制
+	// +optional
+	Schedule *RolloutSchedule `json:"schedule,omitempty"`
+	// 这些 Rollout 达到 Succeeded 之前不开始第一步；任一依赖回滚时本 Rollout 一并中止
+	// +optional
+	DependsOn []RolloutDependency `json:"dependsOn,omitempty"`
+	// 为 true 时控制器只计算发布计划写入 status.plan，不创建或修改任何资源；改回 false 后按计划开始发布
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// 发布过程中各个时机执行的 hook，如提升前的数据库迁移、提升后的缓存预热
+	// +optional
+	Hooks *RolloutHooks `json:"hooks,omitempty"`
+}
+
+// HookPoint hook 的执行时机
+type HookPoint string
+
+const (
+	// HookPreRollout 第一步调整权重之前
+	HookPreRollout HookPoint = "PreRollout"
+	// HookPrePromotion 全部步骤通过之后、提升到 100% 之前
+	HookPrePromotion HookPoint = "PrePromotion"
+	// HookPostPromotion 提升完成、进入 Succeeded 之后
+	HookPostPromotion HookPoint = "PostPromotion"
+	// HookOnRollback 流量切回 stable、进入 RolledBack 之后
+	HookOnRollback HookPoint = "OnRollback"
+)
+
+// HookFailurePolicy hook 失败或超时后的处理方式
+type HookFailurePolicy string
+
+const (
+	// HookFailureAbort 中止发布并把流量切回 stable；只能用于 preRollout 与 prePromotion
+	HookFailureAbort HookFailurePolicy = "Abort"
+	// HookFailureIgnore 只记录失败，发布照常继续
+	HookFailureIgnore HookFailurePolicy = "Ignore"
+)
+
+// DefaultHookTimeoutSeconds spec.hooks[].timeoutSeconds 的默认值
+const DefaultHookTimeoutSeconds = 300
+
+// RolloutHooks 各时机的 hook；同一时机内按顺序逐个执行，前一个结束后才开始下一个。
+// 每次发布（新版本或 retry）每个 hook 只执行一次，结果记录在 status.hooks
+type RolloutHooks struct {
+	// +optional
+	PreRollout []RolloutHook `json:"preRollout,omitempty"`
+	// +optional
+	PrePromotion []RolloutHook `json:"prePromotion,omitempty"`
+	// +optional
+	PostPromotion []RolloutHook `json:"postPromotion,omitempty"`
+	// +optional
+	OnRollback []RolloutHook `json:"onRollback,omitempty"`
+}
+
+// At 返回指定时机的 hook
+func (h *RolloutHooks) At(point HookPoint) []RolloutHook {
+	if h == nil {
+		return nil
+	}
+	switch point {
+	case HookPreRollout:
+		return h.PreRollout
+	case HookPrePromotion:
+		return h.PrePromotion
+	case HookPostPromotion:
+		return h.PostPromotion
+	case HookOnRollback:
+		return h.OnRollback
+	}
+	return nil
+}
+
+// RolloutHook 以 Job 或 HTTP 调用的形式执行，二者必须且只能设置一个
+type RolloutHook struct {
+	// 在所有时机中唯一，同时用于 Job 名称 <rollout>-<name>-<版本哈希>
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=20
+	Name string `json:"name"`
+	// Job 模板；Job 成功结束即 hook 成功，activeDeadlineSeconds 不超过 timeoutSeconds
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Job *batchv1.JobTemplateSpec `json:"job,omitempty"`
+	// +optional
+	HTTP *HTTPHook `json:"http,omitempty"`
+	// 超过该时长仍未结束视为失败
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// 为空时 preRollout/prePromotion 为 Abort，postPromotion/onRollback 为 Ignore
+	// +kubebuilder:validation:Enum=Abort;Ignore
+	// +optional
+	FailurePolicy HookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// EffectiveFailurePolicy 返回 hook 在 point 时机实际生效的失败处理方式
+func (h *RolloutHook) EffectiveFailurePolicy(point HookPoint) HookFailurePolicy {
+	if h.FailurePolicy != "" {
+		return h.FailurePolicy
+	}
+	if point == HookPreRollout || point == HookPrePromotion {
+		return HookFailureAbort
+	}
+	return HookFailureIgnore
+}
+
+// HTTPHook 请求体为 JSON：namespace、name、hook、point、revision；返回 2xx 即成功
+type HTTPHook struct {
+	URL string `json:"url"`
+	// +kubebuilder:validation:Enum=GET;POST;PUT
+	// +kubebuilder:default=POST
+	// +optional
+	Method string `json:"method,omitempty"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ReleaseLabel 标记 Rollout 所属的发布批次；设置后只认可同一批次的依赖状态
+const ReleaseLabel = "delivery.example.com/release"
+
+// RolloutDependency 引用另一个 Rollout
+type RolloutDependency struct {
+	Name string `json:"name"`
+	// 为空表示与本 Rollout 相同的 namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RolloutSchedule 限定发布推进的时段
+type RolloutSchedule struct {
+	// IANA 时区名，如 Asia/Shanghai；为空表示 UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// 允许推进的时段；为空表示除封禁日期外任何时间都允许
+	// +optional
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+	// 禁止推进的日期，YYYY-MM-DD 或 YYYY-MM-DD/YYYY-MM-DD（含首尾），按 timeZone 计算
+	// +optional
+	Blackouts []string `json:"blackouts,omitempty"`
+}
+
+// ScheduleWindow 从 start 每次触发开始、持续 duration 的允许时段
+type ScheduleWindow struct {
+	// 5 段 cron 表达式（分 时 日 月 周），如 "0 10 * * 1-5"
+	Start string `json:"start"`
+	// 窗口长度，如 6h
+	Duration metav1.Duration `json:"duration"`
+}
+
+// SpreadMode 拓扑打散无法满足时的处理方式
+type SpreadMode string
+
+const (
+	// SpreadRequired 无法满足时不调度（DoNotSchedule）
+	SpreadRequired SpreadMode = "Required"
+	// SpreadPreferred 尽量打散，无法满足时仍然调度（ScheduleAnyway）
+	SpreadPreferred SpreadMode = "Preferred"
+)
+
+// CanaryPlacement 让 canary Pod 分散到不同的可用区/节点，或固定到专用的 canary 节点池
+type CanaryPlacement struct {
+	// 按这些拓扑键打散 canary Pod，如 topology.kubernetes.io/zone、kubernetes.io/hostname
+	// +optional
+	SpreadAcross []string `json:"spreadAcross,omitempty"`
+	// +kubebuilder:validation:Enum=Required;Preferred
+	// +kubebuilder:default=Preferred
+	// +optional
+	SpreadMode SpreadMode `json:"spreadMode,omitempty"`
+	// canary 节点池的节点标签；非空时 canary Pod 只调度到这些节点
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// 节点池污点对应的容忍
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+type RolloutPhase string
+
+const (
+	PhaseIdle        RolloutPhase = "Idle"
+	PhaseProgressing RolloutPhase = "Progressing"
+	PhaseAnalyzing   RolloutPhase = "Analyzing"
+	PhaseSucceeded   RolloutPhase = "Succeeded"
+	PhaseFailed      RolloutPhase = "Failed"
+	PhaseRolledBack  RolloutPhase = "RolledBack"
+	// PhasePaused 分析失败且 failurePolicy=Manual，保持当前权重等待人工处理
+	PhasePaused RolloutPhase = "Paused"
+)
+
+// InProgress 发布是否正在进行（已经开始调整流量且尚未结束）
+func (p RolloutPhase) InProgress() bool {
+	return p == PhaseProgressing || p == PhaseAnalyzing || p == PhasePaused
+}
+
+// Rollout 的条件类型
+const (
+	// ConditionAnalysisFailed 分析失败时记录的条件，Reason 为生效的 failurePolicy
+	ConditionAnalysisFailed = "AnalysisFailed"
+	// ConditionTrafficDrift 流量层实际权重与当前步骤不一致并已恢复时为 True
+	ConditionTrafficDrift = "TrafficDrift"
+	// ConditionAborted 发布被 kubectl rollout abort 中止
+	ConditionAborted = "Aborted"
+	// ConditionHookFailed hook 失败或超时时为 True，Reason 为 hook 的时机
+	ConditionHookFailed = "HookFailed"
+	// ConditionDependenciesReady spec.dependsOn 中的 Rollout 是否都已在同一发布批次中 Succeeded
+	ConditionDependenciesReady = "DependenciesReady"
+	// ConditionWaiting 当前不在发布窗口内或处于封禁日期，发布停在当前步骤
+	ConditionWaiting = "Waiting"
+)
+
+// EffectiveFailurePolicy 返回实际生效的失败处理方式，未设置时为 Auto
+func (s *RolloutSpec) EffectiveFailurePolicy() FailurePolicy {
+	if s.FailurePolicy != "" {
+		return s.FailurePolicy
+	}
+	return FailurePolicyAuto
+}
+
+// CanarySteps 本轮发布依次经过的步骤。倒序发布（status.undo）时为 spec 中权重低于 fromWeight 的步骤倒序排列，
+// 权重表示仍留在 canary 上的旧版本的比例
+func (r *Rollout) CanarySteps() []RolloutStep {
+	if r.Status.Undo == nil {
+		return r.Spec.Strategy.Steps
+	}
+	var steps []RolloutStep
+	for i := len(r.Spec.Strategy.Steps) - 1; i >= 0; i-- {
+		if step := r.Spec.Strategy.Steps[i]; step.Weight < r.Status.Undo.FromWeight {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// FinalWeight 全部步骤完成后的 canary 权重：正常发布为 100，倒序发布为 0
+func (r *Rollout) FinalWeight() int32 {
+	if r.Status.Undo != nil {
+		return 0
+	}
+	return 100
+}
+
+// RollbackWeight 回滚或中止后的 canary 权重：正常发布为 0，倒序发布回到 undo 开始时的权重
+func (r *Rollout) RollbackWeight() int32 {
+	if r.Status.Undo != nil {
+		return r.Status.Undo.FromWeight
+	}
+	return 0
+}
+
+// UndoAnnotation 由 kubectl-rollout undo 写入，值为目标版本的 ControllerRevision 名称；
+// 控制器登记该版本时沿 canary 步骤倒序把流量从当前版本退回目标版本（仅 Deployment）
+const UndoAnnotation = "delivery.example.com/undo-revision"
+
+// UndoStatus 倒序发布的起点
+type UndoStatus struct {
+	// undo 开始时承载 canary 流量的版本，倒序发布期间留在 canary 工作负载上
+	FromRevision string `json:"fromRevision"`
+	// undo 开始时的 canary 权重
+	FromWeight int32 `json:"fromWeight"`
+}
+
+// StepStatus 单个步骤的时间记录，重启或无关事件触发调和时据此计算剩余等待时间
+type StepStatus struct {
+	Index  int32 `json:"index"`
+	Weight int32 `json:"weight"`
+	// 下发该步骤权重的时间
+	StartedAt metav1.Time `json:"startedAt"`
+	// 分析通过后 hold 结束的时间；为空表示该步骤尚未通过分析
+	// +optional
+	HoldUntil *metav1.Time `json:"holdUntil,omitempty"`
+	// 最近一次分析的时间，下一次分析在 intervalSeconds 之后
+	// +optional
+	AnalyzedAt *metav1.Time `json:"analyzedAt,omitempty"`
+	// 连续通过与连续失败的分析次数，分别达到 successThreshold、failureThreshold 时本步骤通过或失败
+	// +optional
+	Successes int32 `json:"successes,omitempty"`
+	// +optional
+	Failures int32 `json:"failures,omitempty"`
+}
+
+// RevisionRecord 一个历史版本，Pod 模板快照保存在同名 ControllerRevision 中
+type RevisionRecord struct {
+	// ControllerRevision 名称，<rollout>-<模板哈希>
+	Name string `json:"name"`
+	// 递增序号；回到旧模板（undo）时沿用原快照并分配新的序号
+	Revision  int64       `json:"revision"`
+	CreatedAt metav1.Time `json:"createdAt"`
+	// 该版本最近一次发布的结果
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+}
+
+type RolloutStatus struct {
+	Phase          RolloutPhase `json:"phase,omitempty"`
+	StepIndex      int32        `json:"stepIndex,omitempty"`
+	StableRevision string       `json:"stableRevision,omitempty"`
+	CanaryRevision string       `json:"canaryRevision,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// 已开始步骤的时间记录
+	// +listType=map
+	// +listMapKey=index
+	// +optional
+	Steps []StepStatus `json:"steps,omitempty"`
+	// 历史版本，按 revision 升序，数量受 spec.revisionHistoryLimit 限制
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Revisions []RevisionRecord `json:"revisions,omitempty"`
+	// 由 kubectl rollout abort 设置：控制器把流量切回 stable 并进入 RolledBack，retry 时清除
+	// +optional
+	Abort bool `json:"abort,omitempty"`
+	// spec.dryRun 为 true 时计算出的发布计划；关闭 dryRun 后清除
+	// +optional
+	Plan *RolloutPlan `json:"plan,omitempty"`
+	// 本次发布中已开始的 hook 及其结果；新版本或 retry 时清除
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Hooks []HookStatus `json:"hooks,omitempty"`
+	// 按 UndoAnnotation 倒序发布时的起点：目标版本放到 stable 工作负载上，canary 上的版本沿倒序步骤降到 0；
+	// 下一个版本登记时清除
+	// +optional
+	Undo *UndoStatus `json:"undo,omitempty"`
+}
+
+// HookPhase hook 的执行状态
+type HookPhase string
+
+const (
+	HookRunning   HookPhase = "Running"
+	HookSucceeded HookPhase = "Succeeded"
+	HookFailed    HookPhase = "Failed"
+)
+
+// HookStatus 单个 hook 的执行记录
+type HookStatus struct {
+	Name  string    `json:"name"`
+	Point HookPoint `json:"point"`
+	Phase HookPhase `json:"phase"`
+	// Job hook 创建的 Job
+	// +optional
+	Job       string      `json:"job,omitempty"`
+	StartedAt metav1.Time `json:"startedAt"`
+	// +optional
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+	// 失败原因或 HTTP 响应状态
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutPlan 控制器按当前 spec 与集群状态计算出的一次完整发布的动作序列
+type RolloutPlan struct {
+	// 计划发布的版本，即将要登记的 ControllerRevision 名称
+	Revision string `json:"revision"`
+	// 动作序列最近一次变化的时间
+	GeneratedAt metav1.Time `json:"generatedAt"`
+	// +listType=atomic
+	// +optional
+	Actions []PlannedAction `json:"actions,omitempty"`
+}
+
+// PlanActionType 计划中动作的类型
+type PlanActionType string
+
+const (
+	// PlanCreate/PlanUpdate/PlanDelete 控制器或流量层将对 target 执行的写操作
+	PlanCreate PlanActionType = "Create"
+	PlanUpdate PlanActionType = "Update"
+	PlanDelete PlanActionType = "Delete"
+	// PlanWait 等待依赖或发布窗口
+	PlanWait PlanActionType = "Wait"
+	// PlanAnalyze 按 spec.analysis 检查当前步骤
+	PlanAnalyze PlanActionType = "Analyze"
+	// PlanHold 分析通过后保持当前权重
+	PlanHold PlanActionType = "Hold"
+	// PlanHook 执行 spec.hooks 中的 hook
+	PlanHook PlanActionType = "Hook"
+)
+
+// PlannedAction 计划中的一个动作
+type PlannedAction struct {
+	Type PlanActionType `json:"type"`
+	// 写操作的对象，<Kind>/<name>
+	// +optional
+	Target string `json:"target,omitempty"`
+	// 动作所属的步骤；为空表示第一步之前或全部步骤之后
+	// +optional
+	Step *int32 `json:"step,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// StepStatus 返回指定步骤的时间记录，尚未开始时返回 nil
+func (s *RolloutStatus) StepStatus(index int32) *StepStatus {
+	for i := range s.Steps {
+		if s.Steps[i].Index == index {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+// HookStatus 返回指定 hook 的执行记录，尚未开始时返回 nil
+func (s *RolloutStatus) HookStatus(name string) *HookStatus {
+	for i := range s.Hooks {
+		if s.Hooks[i].Name == name {
+			return &s.Hooks[i]
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Step",type=integer,JSONPath=`.status.stepIndex`
+// +kubebuilder:printcolumn:name="Strategy",type=string,JSONPath=`.spec.strategy.type`
+type Rollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              RolloutSpec   `json:"spec"`
+	Status            RolloutStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type RolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Rollout `json:"items"`
+}
+
+func init() { SchemeBuilder.Register(&Rollout{}, &RolloutList{}) }
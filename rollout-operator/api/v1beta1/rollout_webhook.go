@@ -0,0 +1,621 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/ormasia/rollout-operator/pkg/schedule"
+)
+
+// log is for logging in this package.
+var rolloutlog = logf.Log.WithName("rollout-resource")
+
+// cookieNameRE 会话保持 cookie 名称只允许不需要转义的字符
+var cookieNameRE = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *Rollout) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+//+kubebuilder:webhook:path=/mutate-delivery-example-com-v1beta1-rollout,mutating=true,failurePolicy=fail,sideEffects=None,groups=delivery.example.com,resources=rollouts,verbs=create;update,versions=v1beta1,name=mrollout.kb.io,admissionReviewVersions=v1
+
+var _ admission.Defaulter = &Rollout{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *Rollout) Default() {
+	rolloutlog.Info("default", "name", r.Name)
+
+	// 1. 默认策略类型为 Canary
+	if r.Spec.Strategy.Type == "" {
+		r.Spec.Strategy.Type = Canary
+		rolloutlog.Info("set default strategy type to Canary", "name", r.Name)
+	}
+
+	// 2. Canary 策略按 progression 模板生成步骤；都没有时默认 10% → 30% → 100%
+	if p := r.Spec.Strategy.Progression; r.Spec.Strategy.Type == Canary && p != nil {
+		// 参数不合法时保持原样，交给校验报错
+		if steps := p.Steps(); steps != nil {
+			r.Spec.Strategy.Steps = steps
+			rolloutlog.Info("expanded canary steps from progression", "name", r.Name, "type", p.Type, "steps", steps)
+		}
+	} else if r.Spec.Strategy.Type == Canary && len(r.Spec.Strategy.Steps) == 0 {
+		r.Spec.Strategy.Steps = []RolloutStep{
+			{Weight: 10, HoldSeconds: 60},
+			{Weight: 30, HoldSeconds: 60},
+			{Weight: 100},
+		}
+		rolloutlog.Info("set default canary steps", "name", r.Name, "steps", r.Spec.Strategy.Steps)
+	}
+
+	// 3. 分析配置默认值
+	if r.Spec.Analysis.IntervalSeconds == 0 {
+		r.Spec.Analysis.IntervalSeconds = 30
+	}
+	if r.Spec.Analysis.SuccessThreshold == 0 {
+		r.Spec.Analysis.SuccessThreshold = 2
+	}
+	if r.Spec.Analysis.FailureThreshold == 0 {
+		r.Spec.Analysis.FailureThreshold = 2
+	}
+	for i := range r.Spec.Analysis.SLOs {
+		if r.Spec.Analysis.SLOs[i].MaxBurnRate == "" {
+			r.Spec.Analysis.SLOs[i].MaxBurnRate = DefaultMaxBurnRate
+		}
+	}
+
+	// 4. 失败处理方式默认 Auto（v1alpha1 的 rollbackOnFailure 在转换时已折算进 failurePolicy）
+	if r.Spec.FailurePolicy == "" {
+		r.Spec.FailurePolicy = r.Spec.EffectiveFailurePolicy()
+	}
+
+	// 5. 删除时默认把流量切回 stable
+	if r.Spec.Traffic.OnDelete == "" {
+		r.Spec.Traffic.OnDelete = TrafficTargetStable
+	}
+
+	// 6. canary 打散默认尽力而为，不因拓扑不足阻塞发布
+	if r.Spec.Placement != nil && r.Spec.Placement.SpreadMode == "" {
+		r.Spec.Placement.SpreadMode = SpreadPreferred
+	}
+
+	// 7. 会话保持默认 cookie 名称
+	if r.Spec.Traffic.StickySession != nil && r.Spec.Traffic.StickySession.CookieName == "" {
+		r.Spec.Traffic.StickySession.CookieName = DefaultStickyCookieName
+	}
+
+	// 8. 按副本比例分流时默认 10 个 Pod
+	if r.Spec.Traffic.Provider == ProviderReplicaRatio && r.Spec.Traffic.Replicas == 0 {
+		r.Spec.Traffic.Replicas = DefaultReplicaRatioReplicas
+	}
+
+	// 9. hook 默认超时、失败处理方式与 HTTP 方法；失败处理方式按所在时机决定
+	for _, point := range hookPoints {
+		for i := range r.Spec.Hooks.At(point) {
+			h := &r.Spec.Hooks.At(point)[i]
+			if h.TimeoutSeconds == 0 {
+				h.TimeoutSeconds = DefaultHookTimeoutSeconds
+			}
+			if h.FailurePolicy == "" {
+				h.FailurePolicy = h.EffectiveFailurePolicy(point)
+			}
+			if h.HTTP != nil && h.HTTP.Method == "" {
+				h.HTTP.Method = http.MethodPost
+			}
+		}
+	}
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+//+kubebuilder:webhook:path=/validate-delivery-example-com-v1beta1-rollout,mutating=false,failurePolicy=fail,sideEffects=None,groups=delivery.example.com,resources=rollouts,verbs=create;update,versions=v1beta1,name=vrollout.kb.io,admissionReviewVersions=v1
+
+var _ admission.Validator = &Rollout{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Rollout) ValidateCreate() (admission.Warnings, error) {
+	rolloutlog.Info("validate create", "name", r.Name)
+
+	return r.warnings(), r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Rollout) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	rolloutlog.Info("validate update", "name", r.Name)
+
+	oldRollout, ok := old.(*Rollout)
+	if !ok {
+		return nil, fmt.Errorf("expected a Rollout but got a %T", old)
+	}
+	// 删除中的对象只会有移除 finalizer 的更新，不能因为 spec 校验卡住删除
+	if r.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	// spec 未变化的更新（finalizer、标签、存储版本迁移的重写）不重新校验，
+	// 否则后来加入的校验规则会拒绝这些已存储对象的任何更新
+	if equality.Semantic.DeepEqual(r.Spec, oldRollout.Spec) {
+		return nil, nil
+	}
+	allErrs := r.validateSpec()
+	allErrs = append(allErrs, r.validateTransition(oldRollout)...)
+	if len(allErrs) == 0 {
+		return r.warnings(), nil
+	}
+	return r.warnings(), allErrs.ToAggregate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Rollout) ValidateDelete() (admission.Warnings, error) {
+	rolloutlog.Info("validate delete", "name", r.Name)
+
+	// TODO(user): fill in your validation logic upon object deletion.
+	return nil, nil
+}
+
+// validate 校验 spec 本身，不涉及新旧对象比较
+func (r *Rollout) validate() error {
+	allErrs := r.validateSpec()
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return allErrs.ToAggregate()
+}
+
+func (r *Rollout) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	fp := field.NewPath("spec")
+
+	// targetRef
+	tp := fp.Child("targetRef")
+	switch r.Spec.TargetRef.Kind {
+	case KindDeployment:
+	case KindStatefulSet:
+		// StatefulSet 只有一个工作负载，无法同时保留两套完整副本做蓝绿切换
+		if r.Spec.Strategy.Type == BlueGreen {
+			allErrs = append(allErrs, field.Invalid(tp.Child("kind"), r.Spec.TargetRef.Kind, "StatefulSet targets support the Canary strategy only"))
+		}
+		// 新旧 Pod 共用一个模板，发布完成后节点池约束会作用到全部 Pod
+		if p := r.Spec.Placement; p != nil && (len(p.NodeSelector) > 0 || len(p.Tolerations) > 0) {
+			allErrs = append(allErrs, field.Forbidden(fp.Child("placement", "nodeSelector"), "a canary node pool is only supported for Deployment targets"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(tp.Child("kind"), r.Spec.TargetRef.Kind, []string{KindDeployment, KindStatefulSet}))
+	}
+	if r.Spec.TargetRef.Name == "" {
+		allErrs = append(allErrs, field.Required(tp.Child("name"), "target name required"))
+	}
+	if r.Spec.TargetRef.Port < 1 || r.Spec.TargetRef.Port > 65535 {
+		allErrs = append(allErrs, field.Invalid(tp.Child("port"), r.Spec.TargetRef.Port, "must be in 1..65535"))
+	}
+
+	// strategy
+	sp := fp.Child("strategy", "steps")
+	switch r.Spec.Strategy.Type {
+	case BlueGreen:
+		if len(r.Spec.Strategy.Steps) > 0 {
+			allErrs = append(allErrs, field.Invalid(sp, r.Spec.Strategy.Steps, "BlueGreen must not define steps"))
+		}
+		if r.Spec.Strategy.Progression != nil {
+			allErrs = append(allErrs, field.Forbidden(fp.Child("strategy", "progression"), "BlueGreen must not define a progression"))
+		}
+	case Canary:
+		if p := r.Spec.Strategy.Progression; p != nil {
+			allErrs = append(allErrs, r.validateProgression(fp.Child("strategy", "progression"), p)...)
+		}
+		steps := r.Spec.Strategy.Steps
+		if len(steps) == 0 {
+			allErrs = append(allErrs, field.Required(sp, "steps required for canary"))
+			break
+		}
+		prev := int32(-1)
+		for i, s := range steps {
+			if s.Weight < 0 || s.Weight > 100 {
+				allErrs = append(allErrs, field.Invalid(sp.Index(i).Child("weight"), s.Weight, "0..100"))
+			}
+			if s.Weight < prev {
+				allErrs = append(allErrs, field.Invalid(sp.Index(i).Child("weight"), s.Weight, "weights must be non-decreasing"))
+			}
+			if s.HoldSeconds < 0 {
+				allErrs = append(allErrs, field.Invalid(sp.Index(i).Child("holdSeconds"), s.HoldSeconds, "must be >= 0"))
+			}
+			prev = s.Weight
+		}
+		if last := steps[len(steps)-1]; last.Weight != 100 {
+			allErrs = append(allErrs, field.Invalid(sp.Index(len(steps)-1).Child("weight"), last.Weight, "final step must reach 100"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fp.Child("strategy", "type"), r.Spec.Strategy.Type, []string{string(Canary), string(BlueGreen)}))
+	}
+
+	switch r.Spec.FailurePolicy {
+	case "", FailurePolicyAuto, FailurePolicyManual, FailurePolicyNone:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fp.Child("failurePolicy"), r.Spec.FailurePolicy,
+			[]string{string(FailurePolicyAuto), string(FailurePolicyManual), string(FailurePolicyNone)}))
+	}
+
+	// analysis
+	ap := fp.Child("analysis")
+	if r.Spec.Analysis.IntervalSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(ap.Child("intervalSeconds"), r.Spec.Analysis.IntervalSeconds, "must be >= 1"))
+	}
+	if r.Spec.Analysis.SuccessThreshold < 1 {
+		allErrs = append(allErrs, field.Invalid(ap.Child("successThreshold"), r.Spec.Analysis.SuccessThreshold, "must be >= 1"))
+	}
+	if r.Spec.Analysis.FailureThreshold < 1 {
+		allErrs = append(allErrs, field.Invalid(ap.Child("failureThreshold"), r.Spec.Analysis.FailureThreshold, "must be >= 1"))
+	}
+	names := map[string]bool{}
+	for i, m := range r.Spec.Analysis.Metrics {
+		mp := ap.Child("metrics").Index(i)
+		if m.Name == "" {
+			allErrs = append(allErrs, field.Required(mp.Child("name"), "metric name required"))
+		} else if names[m.Name] {
+			allErrs = append(allErrs, field.Duplicate(mp.Child("name"), m.Name))
+		}
+		names[m.Name] = true
+		if m.PromQL == "" {
+			allErrs = append(allErrs, field.Required(mp.Child("promQL"), "query required"))
+		}
+		if _, err := strconv.ParseFloat(m.Threshold, 64); err != nil {
+			allErrs = append(allErrs, field.Invalid(mp.Child("threshold"), m.Threshold, "must be a number"))
+		}
+		if m.Compare != CompareLT && m.Compare != CompareGT {
+			allErrs = append(allErrs, field.NotSupported(mp.Child("compare"), m.Compare, []string{CompareLT, CompareGT}))
+		}
+	}
+	sloNames := map[string]bool{}
+	for i, o := range r.Spec.Analysis.SLOs {
+		op := ap.Child("slos").Index(i)
+		if o.Name == "" {
+			allErrs = append(allErrs, field.Required(op.Child("name"), "SLO name required"))
+		} else if sloNames[o.Name] {
+			allErrs = append(allErrs, field.Duplicate(op.Child("name"), o.Name))
+		}
+		sloNames[o.Name] = true
+		switch o.Type {
+		case SLOAvailability:
+			if o.ThresholdMillis != 0 {
+				allErrs = append(allErrs, field.Forbidden(op.Child("thresholdMillis"), "thresholdMillis is only used by Latency SLOs"))
+			}
+		case SLOLatency:
+			if o.ThresholdMillis < 1 {
+				allErrs = append(allErrs, field.Required(op.Child("thresholdMillis"), "Latency SLOs need a threshold"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(op.Child("type"), o.Type, []string{SLOAvailability, SLOLatency}))
+		}
+		// 100% 没有错误预算，燃烧率无从计算
+		if v, err := strconv.ParseFloat(o.Objective, 64); err != nil || v <= 0 || v >= 100 {
+			allErrs = append(allErrs, field.Invalid(op.Child("objective"), o.Objective, "must be a percentage above 0 and below 100"))
+		}
+		if o.ErrorQuery == "" {
+			allErrs = append(allErrs, field.Required(op.Child("errorQuery"), "query required"))
+		}
+		if o.TotalQuery == "" {
+			allErrs = append(allErrs, field.Required(op.Child("totalQuery"), "query required"))
+		}
+		if v, err := strconv.ParseFloat(o.MaxBurnRate, 64); err != nil || v <= 0 {
+			allErrs = append(allErrs, field.Invalid(op.Child("maxBurnRate"), o.MaxBurnRate, "must be a number above 0"))
+		}
+	}
+
+	// traffic
+	trp := fp.Child("traffic")
+	switch r.Spec.Traffic.Provider {
+	case ProviderNginxIngress:
+		if r.Spec.Traffic.Replicas != 0 {
+			allErrs = append(allErrs, field.Forbidden(trp.Child("replicas"), "replicas is only used by the ReplicaRatio provider"))
+		}
+	case ProviderReplicaRatio, ProviderSMI:
+		// host 是 provider 使用的 Service 名称
+		if r.Spec.Traffic.Host != "" {
+			for _, msg := range validation.IsDNS1035Label(r.Spec.Traffic.Host) {
+				allErrs = append(allErrs, field.Invalid(trp.Child("host"), r.Spec.Traffic.Host, "must be a Service name: "+msg))
+			}
+		}
+		// 没有 ingress，无法用 cookie 做会话保持
+		if r.Spec.Traffic.StickySession != nil {
+			allErrs = append(allErrs, field.Forbidden(trp.Child("stickySession"), "sticky sessions are only supported by the NginxIngress provider"))
+		}
+		if r.Spec.Traffic.Provider == ProviderSMI {
+			if r.Spec.Traffic.Replicas != 0 {
+				allErrs = append(allErrs, field.Forbidden(trp.Child("replicas"), "replicas is only used by the ReplicaRatio provider"))
+			}
+			break
+		}
+		// 比例靠两个 Deployment 的副本数实现；StatefulSet 只有一个工作负载
+		if r.Spec.TargetRef.Kind != KindDeployment {
+			allErrs = append(allErrs, field.Invalid(trp.Child("provider"), r.Spec.Traffic.Provider, "the ReplicaRatio provider supports Deployment targets only"))
+		}
+		if r.Spec.Traffic.Replicas < 2 {
+			allErrs = append(allErrs, field.Invalid(trp.Child("replicas"), r.Spec.Traffic.Replicas, "must be at least 2"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(trp.Child("provider"), r.Spec.Traffic.Provider, []string{ProviderNginxIngress, ProviderReplicaRatio, ProviderSMI}))
+	}
+	if r.Spec.Traffic.Host == "" {
+		allErrs = append(allErrs, field.Required(trp.Child("host"), "host required"))
+	}
+	if r.Spec.Traffic.StableService == "" {
+		allErrs = append(allErrs, field.Required(trp.Child("stableService"), "stableService required"))
+	}
+	if r.Spec.Traffic.CanaryService == "" {
+		allErrs = append(allErrs, field.Required(trp.Child("canaryService"), "canaryService required"))
+	}
+	if r.Spec.Traffic.StableService != "" && r.Spec.Traffic.StableService == r.Spec.Traffic.CanaryService {
+		allErrs = append(allErrs, field.Invalid(trp.Child("canaryService"), r.Spec.Traffic.CanaryService, "must differ from stableService"))
+	}
+	if ss := r.Spec.Traffic.StickySession; ss != nil {
+		ssp := trp.Child("stickySession")
+		if ss.CookieName != "" && !cookieNameRE.MatchString(ss.CookieName) {
+			allErrs = append(allErrs, field.Invalid(ssp.Child("cookieName"), ss.CookieName, "must contain only letters, digits, '_' and '-'"))
+		}
+		if ss.MaxAgeSeconds < 0 {
+			allErrs = append(allErrs, field.Invalid(ssp.Child("maxAgeSeconds"), ss.MaxAgeSeconds, "must not be negative"))
+		}
+	}
+	switch r.Spec.Traffic.OnDelete {
+	case "", TrafficTargetStable, TrafficTargetCanary:
+	default:
+		allErrs = append(allErrs, field.NotSupported(trp.Child("onDelete"), r.Spec.Traffic.OnDelete,
+			[]string{string(TrafficTargetStable), string(TrafficTargetCanary)}))
+	}
+
+	// placement
+	if p := r.Spec.Placement; p != nil {
+		pp := fp.Child("placement")
+		seen := map[string]bool{}
+		for i, key := range p.SpreadAcross {
+			switch {
+			case key == "":
+				allErrs = append(allErrs, field.Required(pp.Child("spreadAcross").Index(i), "topology key required"))
+			case seen[key]:
+				allErrs = append(allErrs, field.Duplicate(pp.Child("spreadAcross").Index(i), key))
+			}
+			seen[key] = true
+		}
+		switch p.SpreadMode {
+		case "", SpreadRequired, SpreadPreferred:
+		default:
+			allErrs = append(allErrs, field.NotSupported(pp.Child("spreadMode"), p.SpreadMode,
+				[]string{string(SpreadRequired), string(SpreadPreferred)}))
+		}
+	}
+
+	// schedule
+	if s := r.Spec.Schedule; s != nil {
+		schp := fp.Child("schedule")
+		if s.TimeZone != "" {
+			if _, err := time.LoadLocation(s.TimeZone); err != nil {
+				allErrs = append(allErrs, field.Invalid(schp.Child("timeZone"), s.TimeZone, "unknown time zone"))
+			}
+		}
+		for i, w := range s.Windows {
+			wp := schp.Child("windows").Index(i)
+			if _, err := schedule.ParseCron(w.Start); err != nil {
+				allErrs = append(allErrs, field.Invalid(wp.Child("start"), w.Start, err.Error()))
+			}
+			if w.Duration.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(wp.Child("duration"), w.Duration.Duration.String(), "must be > 0"))
+			}
+		}
+		for i, b := range s.Blackouts {
+			if _, err := schedule.ParseBlackout(b); err != nil {
+				allErrs = append(allErrs, field.Invalid(schp.Child("blackouts").Index(i), b, err.Error()))
+			}
+		}
+	}
+
+	// dependsOn
+	deps := map[string]bool{}
+	for i, d := range r.Spec.DependsOn {
+		dp := fp.Child("dependsOn").Index(i)
+		if d.Name == "" {
+			allErrs = append(allErrs, field.Required(dp.Child("name"), "dependency name required"))
+			continue
+		}
+		ns := d.Namespace
+		if ns == "" {
+			ns = r.Namespace
+		}
+		key := ns + "/" + d.Name
+		switch {
+		case ns == r.Namespace && d.Name == r.Name:
+			allErrs = append(allErrs, field.Invalid(dp.Child("name"), d.Name, "a rollout cannot depend on itself"))
+		case deps[key]:
+			allErrs = append(allErrs, field.Duplicate(dp, key))
+		}
+		deps[key] = true
+	}
+
+	// hooks
+	hookNames := map[string]bool{}
+	for _, point := range hookPoints {
+		for i, h := range r.Spec.Hooks.At(point) {
+			hp := fp.Child("hooks", hookField(point)).Index(i)
+			if h.Name == "" {
+				allErrs = append(allErrs, field.Required(hp.Child("name"), "hook name required"))
+			} else if len(h.Name) > 20 {
+				allErrs = append(allErrs, field.TooLong(hp.Child("name"), h.Name, 20))
+			} else if msgs := validation.IsDNS1123Label(h.Name); len(msgs) > 0 {
+				allErrs = append(allErrs, field.Invalid(hp.Child("name"), h.Name, msgs[0]))
+			} else if hookNames[h.Name] {
+				allErrs = append(allErrs, field.Duplicate(hp.Child("name"), h.Name))
+			}
+			hookNames[h.Name] = true
+			switch {
+			case h.Job == nil && h.HTTP == nil:
+				allErrs = append(allErrs, field.Required(hp, "one of job or http required"))
+			case h.Job != nil && h.HTTP != nil:
+				allErrs = append(allErrs, field.Forbidden(hp, "job and http are mutually exclusive"))
+			case h.Job != nil && len(h.Job.Spec.Template.Spec.Containers) == 0:
+				allErrs = append(allErrs, field.Required(hp.Child("job", "spec", "template", "spec", "containers"), "at least 1 container"))
+			case h.HTTP != nil:
+				if u, err := url.Parse(h.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					allErrs = append(allErrs, field.Invalid(hp.Child("http", "url"), h.HTTP.URL, "must be an absolute http or https URL"))
+				}
+				switch h.HTTP.Method {
+				case "", http.MethodGet, http.MethodPost, http.MethodPut:
+				default:
+					allErrs = append(allErrs, field.NotSupported(hp.Child("http", "method"), h.HTTP.Method,
+						[]string{http.MethodGet, http.MethodPost, http.MethodPut}))
+				}
+			}
+			if h.TimeoutSeconds < 0 {
+				allErrs = append(allErrs, field.Invalid(hp.Child("timeoutSeconds"), h.TimeoutSeconds, "must not be negative"))
+			}
+			switch h.FailurePolicy {
+			case "", HookFailureIgnore:
+			case HookFailureAbort:
+				// 提升之后或已经回滚时没有可以中止的发布
+				if point == HookPostPromotion || point == HookOnRollback {
+					allErrs = append(allErrs, field.Invalid(hp.Child("failurePolicy"), h.FailurePolicy, "Abort is only supported before promotion"))
+				}
+			default:
+				allErrs = append(allErrs, field.NotSupported(hp.Child("failurePolicy"), h.FailurePolicy,
+					[]string{string(HookFailureAbort), string(HookFailureIgnore)}))
+			}
+		}
+	}
+	return allErrs
+}
+
+// hookPoints 所有 hook 时机，按发布过程中的先后顺序
+var hookPoints = []HookPoint{HookPreRollout, HookPrePromotion, HookPostPromotion, HookOnRollback}
+
+// hookField 时机在 spec.hooks 中对应的字段名
+func hookField(point HookPoint) string {
+	switch point {
+	case HookPreRollout:
+		return "preRollout"
+	case HookPrePromotion:
+		return "prePromotion"
+	case HookPostPromotion:
+		return "postPromotion"
+	}
+	return "onRollback"
+}
+
+// validateProgression 校验模板参数及展开结果，展开结果须与 steps 一致（由 defaulting webhook 写入）
+func (r *Rollout) validateProgression(pp *field.Path, p *StepProgression) field.ErrorList {
+	var allErrs field.ErrorList
+	if p.Start < 1 || p.Start > 100 {
+		allErrs = append(allErrs, field.Invalid(pp.Child("start"), p.Start, "1..100"))
+	}
+	switch p.Type {
+	case ProgressionLinear:
+		if p.Increment < 1 {
+			allErrs = append(allErrs, field.Invalid(pp.Child("increment"), p.Increment, "must be >= 1 for Linear"))
+		}
+	case ProgressionExponential:
+		if p.Factor < 2 {
+			allErrs = append(allErrs, field.Invalid(pp.Child("factor"), p.Factor, "must be >= 2 for Exponential"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(pp.Child("type"), p.Type,
+			[]string{string(ProgressionLinear), string(ProgressionExponential)}))
+	}
+	if p.IntervalSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(pp.Child("intervalSeconds"), p.IntervalSeconds, "must be >= 0"))
+	}
+	if p.MaxSurge < 0 || p.MaxSurge > 100 {
+		allErrs = append(allErrs, field.Invalid(pp.Child("maxSurge"), p.MaxSurge, "0..100"))
+	}
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	expanded := p.Steps()
+	if expanded[len(expanded)-1].Weight != 100 {
+		return append(allErrs, field.Invalid(pp, p, fmt.Sprintf("does not reach 100 within %d steps", MaxProgressionSteps)))
+	}
+	if !equality.Semantic.DeepEqual(expanded, r.Spec.Strategy.Steps) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "strategy", "steps"), r.Spec.Strategy.Steps, "must match the steps generated from progression"))
+	}
+	return allErrs
+}
+
+// validateTransition 发布进行中时，流量入口和目标工作负载不可修改，否则已下发的权重会指向错误的对象
+func (r *Rollout) validateTransition(old *Rollout) field.ErrorList {
+	if !old.Status.Phase.InProgress() {
+		return nil
+	}
+	var allErrs field.ErrorList
+	msg := fmt.Sprintf("immutable while rollout is %s", old.Status.Phase)
+	trp := field.NewPath("spec", "traffic")
+	if r.Spec.Traffic.Provider != old.Spec.Traffic.Provider {
+		allErrs = append(allErrs, field.Forbidden(trp.Child("provider"), msg))
+	}
+	if r.Spec.Traffic.Host != old.Spec.Traffic.Host {
+		allErrs = append(allErrs, field.Forbidden(trp.Child("host"), msg))
+	}
+	if r.Spec.Traffic.StableService != old.Spec.Traffic.StableService {
+		allErrs = append(allErrs, field.Forbidden(trp.Child("stableService"), msg))
+	}
+	if r.Spec.Traffic.CanaryService != old.Spec.Traffic.CanaryService {
+		allErrs = append(allErrs, field.Forbidden(trp.Child("canaryService"), msg))
+	}
+	if r.Spec.TargetRef != old.Spec.TargetRef {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "targetRef"), msg))
+	}
+	if r.Spec.Strategy.Type != old.Spec.Strategy.Type {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "strategy", "type"), msg))
+	}
+	return allErrs
+}
+
+// warnings 合法但有风险的配置
+func (r *Rollout) warnings() admission.Warnings {
+	var w admission.Warnings
+	steps := r.Spec.Strategy.Steps
+	for i, s := range steps {
+		if i < len(steps)-1 && s.HoldSeconds == 0 {
+			w = append(w, fmt.Sprintf("spec.strategy.steps[%d].holdSeconds is 0: weight %d%% moves on as soon as its analysis passes", i, s.Weight))
+		}
+	}
+	if r.Spec.Strategy.Type == Canary && len(steps) == 1 {
+		w = append(w, "spec.strategy.steps has a single step: traffic jumps straight to the canary without a partial stage")
+	}
+	if r.Spec.Analysis.FailureThreshold == 1 {
+		w = append(w, "spec.analysis.failureThreshold is 1: a single failed check will fail the rollout")
+	}
+	switch r.Spec.EffectiveFailurePolicy() {
+	case FailurePolicyNone:
+		w = append(w, "spec.failurePolicy is None: a failed canary keeps receiving traffic until someone intervenes")
+	case FailurePolicyManual:
+		w = append(w, "spec.failurePolicy is Manual: a failed canary holds its current weight until someone promotes or aborts it")
+	}
+	return w
+}
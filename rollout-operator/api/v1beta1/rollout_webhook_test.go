@@ -0,0 +1,501 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validRollout() *Rollout {
+	return &Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec: RolloutSpec{
+			TargetRef: TargetRef{Kind: "Deployment", Name: "demo", Port: 8080},
+			Strategy: RolloutStrategy{
+				Type: Canary,
+				Steps: []RolloutStep{
+					{Weight: 10, HoldSeconds: 30},
+					{Weight: 50, HoldSeconds: 30},
+					{Weight: 100},
+				},
+			},
+			Analysis: AnalysisSpec{
+				IntervalSeconds:  30,
+				SuccessThreshold: 2,
+				FailureThreshold: 2,
+				Metrics: []MetricCheck{{
+					Name:      "error-rate",
+					PromQL:    `sum(rate(http_requests_total{code=~"5.."}[1m]))`,
+					Threshold: "0.05",
+					Compare:   CompareLT,
+				}},
+			},
+			Traffic: TrafficSpec{
+				Provider:      "NginxIngress",
+				Host:          "demo.example.local",
+				StableService: "demo-stable",
+				CanaryService: "demo-canary",
+			},
+			FailurePolicy: FailurePolicyAuto,
+		},
+	}
+}
+
+// availabilitySLO 99.9% 可用性目标
+func availabilitySLO() SLOCheck {
+	return SLOCheck{
+		Name:        "availability",
+		Type:        SLOAvailability,
+		Objective:   "99.9",
+		ErrorQuery:  `sum(increase(http_requests_total{service="{{canaryService}}",code=~"5.."}[{{window}}]))`,
+		TotalQuery:  `sum(increase(http_requests_total{service="{{canaryService}}"}[{{window}}]))`,
+		MaxBurnRate: DefaultMaxBurnRate,
+	}
+}
+
+// migrateJob 一个最小的 hook Job 模板
+func migrateJob() *batchv1.JobTemplateSpec {
+	return &batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "migrate", Image: "registry.local/migrate:v2"}}},
+	}}}
+}
+
+var _ = Describe("Rollout Webhook", func() {
+
+	Context("When creating Rollout under Defaulting Webhook", func() {
+		It("Should fill in the default value if a required field is empty", func() {
+			ro := validRollout()
+			ro.Spec.Strategy = RolloutStrategy{}
+			ro.Spec.Analysis.IntervalSeconds = 0
+			ro.Default()
+			Expect(ro.Spec.Strategy.Type).To(Equal(Canary))
+			Expect(ro.Spec.Strategy.Steps).To(HaveLen(3))
+			Expect(ro.Spec.Analysis.IntervalSeconds).To(Equal(int32(30)))
+			Expect(ro.Spec.FailurePolicy).To(Equal(FailurePolicyAuto))
+			Expect(ro.Spec.Traffic.OnDelete).To(Equal(TrafficTargetStable))
+		})
+
+		It("Should respect an explicit failure policy", func() {
+			ro := validRollout()
+			ro.Spec.FailurePolicy = FailurePolicyManual
+			ro.Default()
+			Expect(ro.Spec.FailurePolicy).To(Equal(FailurePolicyManual))
+		})
+
+		It("Should expand a linear progression into steps", func() {
+			ro := validRollout()
+			ro.Spec.Strategy.Progression = &StepProgression{Type: ProgressionLinear, Start: 10, Increment: 20, IntervalSeconds: 60}
+			ro.Default()
+			Expect(ro.Spec.Strategy.Steps).To(Equal([]RolloutStep{
+				{Weight: 10, HoldSeconds: 60},
+				{Weight: 30, HoldSeconds: 60},
+				{Weight: 50, HoldSeconds: 60},
+				{Weight: 70, HoldSeconds: 60},
+				{Weight: 90, HoldSeconds: 60},
+				{Weight: 100},
+			}))
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should cap exponential growth with maxSurge", func() {
+			ro := validRollout()
+			ro.Spec.Strategy.Progression = &StepProgression{Type: ProgressionExponential, Start: 5, Factor: 2, MaxSurge: 25, IntervalSeconds: 30}
+			ro.Default()
+			var weights []int32
+			for _, s := range ro.Spec.Strategy.Steps {
+				weights = append(weights, s.Weight)
+			}
+			Expect(weights).To(Equal([]int32{5, 10, 20, 40, 65, 90, 100}))
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should default the sticky session cookie name", func() {
+			ro := validRollout()
+			ro.Spec.Traffic.StickySession = &StickySession{}
+			ro.Default()
+			Expect(ro.Spec.Traffic.StickySession.CookieName).To(Equal(DefaultStickyCookieName))
+		})
+
+		It("Should default the replica count for the ReplicaRatio provider only", func() {
+			ro := validRollout()
+			ro.Default()
+			Expect(ro.Spec.Traffic.Replicas).To(BeZero())
+
+			ro.Spec.Traffic.Provider = ProviderReplicaRatio
+			ro.Default()
+			Expect(ro.Spec.Traffic.Replicas).To(Equal(int32(DefaultReplicaRatioReplicas)))
+		})
+
+		It("Should default hook timeouts and failure policies by hook point", func() {
+			ro := validRollout()
+			ro.Spec.Hooks = &RolloutHooks{
+				PrePromotion:  []RolloutHook{{Name: "migrate", Job: migrateJob()}},
+				PostPromotion: []RolloutHook{{Name: "warm-cache", HTTP: &HTTPHook{URL: "http://cache.default.svc/warm"}}},
+			}
+			ro.Default()
+			pre, post := ro.Spec.Hooks.PrePromotion[0], ro.Spec.Hooks.PostPromotion[0]
+			Expect(pre.TimeoutSeconds).To(Equal(int32(DefaultHookTimeoutSeconds)))
+			Expect(pre.FailurePolicy).To(Equal(HookFailureAbort))
+			Expect(post.FailurePolicy).To(Equal(HookFailureIgnore))
+			Expect(post.HTTP.Method).To(Equal("POST"))
+		})
+
+		It("Should default the SLO burn rate limit", func() {
+			ro := validRollout()
+			ro.Spec.Analysis.SLOs = []SLOCheck{availabilitySLO()}
+			ro.Default()
+			Expect(ro.Spec.Analysis.SLOs[0].MaxBurnRate).To(Equal(DefaultMaxBurnRate))
+		})
+	})
+
+	Context("When creating Rollout under Validating Webhook", func() {
+		It("Should deny if a required field is empty", func() {
+			ro := validRollout()
+			ro.Spec.Traffic.Host = ""
+			_, err := ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.host")))
+		})
+
+		It("Should admit if all required fields are provided", func() {
+			warnings, err := validRollout().ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should admit an analysis without metric checks", func() {
+			ro := validRollout()
+			ro.Spec.Analysis.Metrics = nil
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny malformed metric checks", func() {
+			ro := validRollout()
+			ro.Spec.Analysis.Metrics = append(ro.Spec.Analysis.Metrics,
+				MetricCheck{Name: "latency", PromQL: "", Threshold: "fast", Compare: "EQ"},
+				MetricCheck{Name: "error-rate", PromQL: "up", Threshold: "1", Compare: CompareGT},
+			)
+			_, err := ro.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(And(
+				ContainSubstring("spec.analysis.metrics[1].promQL"),
+				ContainSubstring("spec.analysis.metrics[1].threshold"),
+				ContainSubstring("spec.analysis.metrics[1].compare"),
+				ContainSubstring("spec.analysis.metrics[2].name"),
+			))
+		})
+
+		It("Should deny canary steps that never reach 100", func() {
+			ro := validRollout()
+			ro.Spec.Strategy.Steps = []RolloutStep{{Weight: 10}, {Weight: 50}}
+			_, err := ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("final step must reach 100")))
+		})
+
+		It("Should deny an invalid target port", func() {
+			ro := validRollout()
+			ro.Spec.TargetRef.Port = 0
+			_, err := ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.targetRef.port")))
+		})
+
+		It("Should validate canary placement", func() {
+			ro := validRollout()
+			ro.Spec.Placement = &CanaryPlacement{
+				SpreadAcross: []string{"topology.kubernetes.io/zone", "kubernetes.io/hostname"},
+				NodeSelector: map[string]string{"pool": "canary"},
+			}
+			ro.Default()
+			Expect(ro.Spec.Placement.SpreadMode).To(Equal(SpreadPreferred))
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Placement.SpreadAcross = append(ro.Spec.Placement.SpreadAcross, "kubernetes.io/hostname")
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.placement.spreadAcross[2]")))
+
+			ro.Spec.Placement.SpreadAcross = nil
+			ro.Spec.TargetRef.Kind = KindStatefulSet
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("only supported for Deployment targets")))
+		})
+
+		It("Should validate progressions and their expanded steps", func() {
+			ro := validRollout()
+			ro.Spec.Strategy.Progression = &StepProgression{Type: ProgressionExponential, Start: 10, Factor: 1}
+			ro.Default()
+			_, err := ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.strategy.progression.factor")))
+
+			ro.Spec.Strategy.Progression = &StepProgression{Type: ProgressionLinear, Start: 1, Increment: 1}
+			ro.Default()
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("does not reach 100 within 20 steps")))
+
+			By("rejecting steps that drifted from the progression")
+			ro.Spec.Strategy.Progression = &StepProgression{Type: ProgressionLinear, Start: 50, Increment: 50}
+			ro.Spec.Strategy.Steps = []RolloutStep{{Weight: 20}, {Weight: 100}}
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("must match the steps generated from progression")))
+
+			ro.Spec.Strategy = RolloutStrategy{Type: BlueGreen, Progression: ro.Spec.Strategy.Progression}
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.strategy.progression")))
+		})
+
+		It("Should validate rollout dependencies", func() {
+			ro := validRollout()
+			ro.Spec.DependsOn = []RolloutDependency{{Name: "backend"}, {Name: "backend", Namespace: "payments"}}
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.DependsOn = append(ro.Spec.DependsOn,
+				RolloutDependency{Name: "backend", Namespace: "default"},
+				RolloutDependency{Name: "demo"},
+				RolloutDependency{})
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.dependsOn[2]: Duplicate value")))
+			Expect(err).To(MatchError(ContainSubstring("cannot depend on itself")))
+			Expect(err).To(MatchError(ContainSubstring("spec.dependsOn[4].name")))
+		})
+
+		It("Should validate the rollout schedule", func() {
+			ro := validRollout()
+			ro.Spec.Schedule = &RolloutSchedule{
+				TimeZone:  "Asia/Shanghai",
+				Windows:   []ScheduleWindow{{Start: "0 10 * * 1-5", Duration: metav1.Duration{Duration: 6 * time.Hour}}},
+				Blackouts: []string{"2025-12-24/2025-12-26", "2026-01-01"},
+			}
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Schedule.TimeZone = "Mars/Olympus"
+			ro.Spec.Schedule.Windows[0].Start = "0 25 * * *"
+			ro.Spec.Schedule.Windows[0].Duration = metav1.Duration{}
+			ro.Spec.Schedule.Blackouts[1] = "01/01/2026"
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.schedule.timeZone")))
+			Expect(err).To(MatchError(ContainSubstring("spec.schedule.windows[0].start")))
+			Expect(err).To(MatchError(ContainSubstring("spec.schedule.windows[0].duration")))
+			Expect(err).To(MatchError(ContainSubstring("spec.schedule.blackouts[1]")))
+		})
+
+		It("Should admit a StatefulSet canary but not blue-green", func() {
+			ro := validRollout()
+			ro.Spec.TargetRef.Kind = KindStatefulSet
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Strategy = RolloutStrategy{Type: BlueGreen}
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("Canary strategy only")))
+
+			ro.Spec.TargetRef.Kind = "DaemonSet"
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.targetRef.kind")))
+		})
+
+		It("Should validate the ReplicaRatio provider", func() {
+			ro := validRollout()
+			ro.Spec.Traffic.Provider = ProviderReplicaRatio
+			ro.Spec.Traffic.Host = "demo"
+			ro.Spec.Traffic.Replicas = 10
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Traffic.Host = "demo.example.local"
+			ro.Spec.Traffic.Replicas = 1
+			ro.Spec.Traffic.StickySession = &StickySession{CookieName: DefaultStickyCookieName}
+			ro.Spec.TargetRef.Kind = KindStatefulSet
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.host")))
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.replicas")))
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.stickySession")))
+			Expect(err).To(MatchError(ContainSubstring("Deployment targets only")))
+
+			ro = validRollout()
+			ro.Spec.Traffic.Replicas = 10
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.replicas")))
+		})
+
+		It("Should validate the SMI provider", func() {
+			ro := validRollout()
+			ro.Spec.Traffic.Provider = ProviderSMI
+			ro.Spec.Traffic.Host = "demo"
+			ro.Spec.TargetRef.Kind = KindStatefulSet
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Traffic.Host = "demo.example.local"
+			ro.Spec.Traffic.Replicas = 10
+			ro.Spec.Traffic.StickySession = &StickySession{CookieName: DefaultStickyCookieName}
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.host")))
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.replicas")))
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.stickySession")))
+		})
+
+		It("Should validate hooks", func() {
+			ro := validRollout()
+			ro.Spec.Hooks = &RolloutHooks{
+				PreRollout:   []RolloutHook{{Name: "migrate", Job: migrateJob()}},
+				PrePromotion: []RolloutHook{{Name: "smoke", HTTP: &HTTPHook{URL: "https://smoke.example.local/run", Method: "GET"}}},
+				OnRollback:   []RolloutHook{{Name: "page", HTTP: &HTTPHook{URL: "http://pager.default.svc/rollback"}, FailurePolicy: HookFailureIgnore}},
+			}
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Hooks.PostPromotion = []RolloutHook{
+				{Name: "migrate", HTTP: &HTTPHook{URL: "http://cache.default.svc/warm"}},
+				{Name: "warm", Job: migrateJob(), HTTP: &HTTPHook{URL: "http://cache.default.svc/warm"}},
+				{Name: "Notify", HTTP: &HTTPHook{URL: "cache.default.svc/warm", Method: "DELETE"}},
+				{Name: "flush", HTTP: &HTTPHook{URL: "http://cache.default.svc/flush"}, FailurePolicy: HookFailureAbort},
+			}
+			_, err = ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.hooks.postPromotion[0].name: Duplicate value")))
+			Expect(err).To(MatchError(ContainSubstring("job and http are mutually exclusive")))
+			Expect(err).To(MatchError(ContainSubstring("spec.hooks.postPromotion[2].name")))
+			Expect(err).To(MatchError(ContainSubstring("spec.hooks.postPromotion[2].http.url")))
+			Expect(err).To(MatchError(ContainSubstring("spec.hooks.postPromotion[2].http.method")))
+			Expect(err).To(MatchError(ContainSubstring("Abort is only supported before promotion")))
+		})
+
+		It("Should validate SLO checks", func() {
+			ro := validRollout()
+			latency := SLOCheck{Name: "p99", Type: SLOLatency, Objective: "99", ThresholdMillis: 300,
+				ErrorQuery: "slow", TotalQuery: "total", MaxBurnRate: "1.5"}
+			ro.Spec.Analysis.SLOs = []SLOCheck{availabilitySLO(), latency}
+			_, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Analysis.SLOs = append(ro.Spec.Analysis.SLOs,
+				SLOCheck{Name: "p99", Type: SLOLatency, Objective: "100", ErrorQuery: "slow", TotalQuery: "total", MaxBurnRate: "0"},
+				SLOCheck{Name: "errors", Type: SLOAvailability, Objective: "99.9", ThresholdMillis: 300, MaxBurnRate: "fast"},
+			)
+			_, err = ro.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(And(
+				ContainSubstring("spec.analysis.slos[2].name: Duplicate value"),
+				ContainSubstring("spec.analysis.slos[2].thresholdMillis: Required value"),
+				ContainSubstring("spec.analysis.slos[2].objective"),
+				ContainSubstring("spec.analysis.slos[2].maxBurnRate"),
+				ContainSubstring("spec.analysis.slos[3].thresholdMillis: Forbidden"),
+				ContainSubstring("spec.analysis.slos[3].errorQuery"),
+				ContainSubstring("spec.analysis.slos[3].totalQuery"),
+				ContainSubstring("spec.analysis.slos[3].maxBurnRate"),
+			))
+		})
+
+		It("Should warn about risky settings", func() {
+			ro := validRollout()
+			ro.Spec.Strategy.Steps[0].HoldSeconds = 0
+			ro.Spec.Analysis.FailureThreshold = 1
+			warnings, err := ro.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElements(
+				ContainSubstring("steps[0].holdSeconds is 0"),
+				ContainSubstring("failureThreshold is 1"),
+			))
+		})
+	})
+
+	Context("When updating Rollout under Validating Webhook", func() {
+		It("Should deny traffic changes while the rollout is progressing", func() {
+			old := validRollout()
+			old.Status.Phase = PhaseProgressing
+			ro := old.DeepCopy()
+			ro.Spec.Traffic.Host = "other.example.local"
+			ro.Spec.Traffic.CanaryService = "other-canary"
+			_, err := ro.ValidateUpdate(old)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(And(
+				ContainSubstring("spec.traffic.host"),
+				ContainSubstring("spec.traffic.canaryService"),
+			))
+		})
+
+		It("Should admit traffic changes once the rollout has finished", func() {
+			old := validRollout()
+			old.Status.Phase = PhaseSucceeded
+			ro := old.DeepCopy()
+			ro.Spec.Traffic.Host = "other.example.local"
+			_, err := ro.ValidateUpdate(old)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should admit step changes while progressing", func() {
+			old := validRollout()
+			old.Status.Phase = PhaseAnalyzing
+			ro := old.DeepCopy()
+			ro.Spec.Strategy.Steps[1].HoldSeconds = 120
+			_, err := ro.ValidateUpdate(old)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny a sticky session cookie name that needs escaping", func() {
+			ro := validRollout()
+			ro.Spec.Traffic.StickySession = &StickySession{CookieName: "canary session", MaxAgeSeconds: -1}
+			_, err := ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.stickySession.cookieName")))
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.stickySession.maxAgeSeconds")))
+		})
+
+		It("Should deny an unknown onDelete target", func() {
+			ro := validRollout()
+			ro.Spec.Traffic.OnDelete = "Both"
+			_, err := ro.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.traffic.onDelete")))
+		})
+
+		It("Should admit an update that leaves a stored invalid spec unchanged", func() {
+			old := validRollout()
+			old.Spec.Analysis.SuccessThreshold = 0
+			ro := old.DeepCopy()
+			ro.Labels = map[string]string{"team": "payments"}
+			_, err := ro.ValidateUpdate(old)
+			Expect(err).NotTo(HaveOccurred())
+
+			ro.Spec.Traffic.Host = "other.example.local"
+			_, err = ro.ValidateUpdate(old)
+			Expect(err).To(MatchError(ContainSubstring("spec.analysis.successThreshold")))
+		})
+
+		It("Should not block finalizer removal on a deleting rollout", func() {
+			old := validRollout()
+			old.Status.Phase = PhaseProgressing
+			now := metav1.Now()
+			old.DeletionTimestamp = &now
+			old.Finalizers = []string{"delivery.example.com/traffic-cleanup"}
+			ro := old.DeepCopy()
+			ro.Finalizers = nil
+			ro.Spec.Traffic.Host = ""
+			_, err := ro.ValidateUpdate(old)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+})
@@ -0,0 +1,121 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GroupLabel 标记 Rollout 所属的 RolloutGroup
+const GroupLabel = "delivery.example.com/group"
+
+// RolloutWave 一个波次，波内的 Rollout 并行发布
+type RolloutWave struct {
+	// 与 RolloutGroup 同 namespace 的 Rollout 名称
+	// +kubebuilder:validation:MinItems=1
+	Rollouts []string `json:"rollouts"`
+}
+
+type RolloutGroupSpec struct {
+	// 发布批次，写入成员的 delivery.example.com/release 标签；修改它即开始新一轮发布
+	// +kubebuilder:validation:MinLength=1
+	Release string `json:"release"`
+	// 按顺序推进的波次：前一波全部 Succeeded 后下一波才开始第一步
+	// +kubebuilder:validation:MinItems=1
+	Waves []RolloutWave `json:"waves"`
+}
+
+type GroupPhase string
+
+const (
+	GroupProgressing GroupPhase = "Progressing"
+	GroupSucceeded   GroupPhase = "Succeeded"
+	// GroupRolledBack 有成员回滚或失败，其余成员已中止或恢复到本轮发布前的版本
+	GroupRolledBack GroupPhase = "RolledBack"
+)
+
+// GroupMemberStatus 成员 Rollout 的状态摘要
+type GroupMemberStatus struct {
+	Name string `json:"name"`
+	Wave int32  `json:"wave"`
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+	// +optional
+	StepIndex int32 `json:"stepIndex,omitempty"`
+	// 本轮发布开始时的 stable 版本，整组回滚时已完成的成员恢复到它
+	// +optional
+	BaselineRevision string `json:"baselineRevision,omitempty"`
+	// 成员最近一次分析失败的原因
+	// +optional
+	AnalysisMessage string `json:"analysisMessage,omitempty"`
+}
+
+type RolloutGroupStatus struct {
+	// status 对应的发布批次，与 spec.release 不同时重新开始
+	// +optional
+	Release string `json:"release,omitempty"`
+	// 本轮发布开始的时间，早于它的成员回滚不计入本轮
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// +optional
+	Phase GroupPhase `json:"phase,omitempty"`
+	// 正在发布的波次
+	// +optional
+	CurrentWave int32 `json:"currentWave,omitempty"`
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Members []GroupMemberStatus `json:"members,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Member 返回指定成员的状态，不存在时返回 nil
+func (s *RolloutGroupStatus) Member(name string) *GroupMemberStatus {
+	for i := range s.Members {
+		if s.Members[i].Name == name {
+			return &s.Members[i]
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Release",type=string,JSONPath=`.spec.release`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Wave",type=integer,JSONPath=`.status.currentWave`
+
+// RolloutGroup 把多个 Rollout 组织成一次发布：按波次推进，任一成员失败时整组回滚
+type RolloutGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              RolloutGroupSpec   `json:"spec"`
+	Status            RolloutGroupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type RolloutGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RolloutGroup `json:"items"`
+}
+
+func init() { SchemeBuilder.Register(&RolloutGroup{}, &RolloutGroupList{}) }
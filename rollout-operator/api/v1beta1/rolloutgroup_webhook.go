@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var rolloutgrouplog = logf.Log.WithName("rolloutgroup-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *RolloutGroup) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-delivery-example-com-v1beta1-rolloutgroup,mutating=false,failurePolicy=fail,sideEffects=None,groups=delivery.example.com,resources=rolloutgroups,verbs=create;update,versions=v1beta1,name=vrolloutgroup.kb.io,admissionReviewVersions=v1
+
+var _ admission.Validator = &RolloutGroup{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *RolloutGroup) ValidateCreate() (admission.Warnings, error) {
+	rolloutgrouplog.Info("validate create", "name", r.Name)
+
+	return nil, r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *RolloutGroup) ValidateUpdate(_ runtime.Object) (admission.Warnings, error) {
+	rolloutgrouplog.Info("validate update", "name", r.Name)
+
+	// 删除中的对象只会有移除 finalizer 的更新
+	if r.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *RolloutGroup) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *RolloutGroup) validate() error {
+	var allErrs field.ErrorList
+	fp := field.NewPath("spec")
+	if r.Spec.Release == "" {
+		allErrs = append(allErrs, field.Required(fp.Child("release"), "release required"))
+	}
+	if len(r.Spec.Waves) == 0 {
+		allErrs = append(allErrs, field.Required(fp.Child("waves"), "at least 1 wave"))
+	}
+	// 一个 Rollout 只能属于一个波次
+	seen := map[string]bool{}
+	for i, w := range r.Spec.Waves {
+		wp := fp.Child("waves").Index(i).Child("rollouts")
+		if len(w.Rollouts) == 0 {
+			allErrs = append(allErrs, field.Required(wp, "at least 1 rollout"))
+		}
+		for j, name := range w.Rollouts {
+			switch {
+			case name == "":
+				allErrs = append(allErrs, field.Required(wp.Index(j), "rollout name required"))
+			case seen[name]:
+				allErrs = append(allErrs, field.Duplicate(wp.Index(j), name))
+			}
+			seen[name] = true
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return allErrs.ToAggregate()
+}
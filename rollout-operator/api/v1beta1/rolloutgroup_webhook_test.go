@@ -0,0 +1,57 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("RolloutGroup Webhook", func() {
+	validGroup := func() *RolloutGroup {
+		return &RolloutGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "default"},
+			Spec: RolloutGroupSpec{
+				Release: "2025.03",
+				Waves:   []RolloutWave{{Rollouts: []string{"backend"}}, {Rollouts: []string{"frontend", "search"}}},
+			},
+		}
+	}
+
+	It("Should admit a group with distinct members", func() {
+		_, err := validGroup().ValidateCreate()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should deny a rollout listed in two waves", func() {
+		g := validGroup()
+		g.Spec.Waves[1].Rollouts = append(g.Spec.Waves[1].Rollouts, "backend")
+		_, err := g.ValidateCreate()
+		Expect(err).To(MatchError(ContainSubstring("spec.waves[1].rollouts[2]: Duplicate value")))
+	})
+
+	It("Should deny empty waves and a missing release", func() {
+		g := validGroup()
+		g.Spec.Release = ""
+		g.Spec.Waves = append(g.Spec.Waves, RolloutWave{})
+		_, err := g.ValidateUpdate(validGroup())
+		Expect(err).To(MatchError(ContainSubstring("spec.release")))
+		Expect(err).To(MatchError(ContainSubstring("spec.waves[2].rollouts")))
+	})
+})
@@ -19,24 +19,41 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	deliveryv1alpha1 "github.com/ormasia/rollout-operator/api/v1alpha1"
+	deliveryv1beta1 "github.com/ormasia/rollout-operator/api/v1beta1"
 	"github.com/ormasia/rollout-operator/internal/controller"
+	"github.com/ormasia/rollout-operator/internal/migration"
 	"github.com/ormasia/rollout-operator/pkg/analysis"
+	"github.com/ormasia/rollout-operator/pkg/cluster"
+	"github.com/ormasia/rollout-operator/pkg/notify"
 	"github.com/ormasia/rollout-operator/pkg/traffic"
 	//+kubebuilder:scaffold:imports
 )
@@ -48,8 +65,10 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(deliveryv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(deliveryv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -59,6 +78,14 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var notificationConfigMap string
+	var migrateStorageVersion bool
+	var watchNamespaces string
+	var namespaceSelector string
+	var trafficConfigMap string
+	var cacheManagedOnly bool
+	var clusterKubeconfigDir string
+	var prometheusAddress string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -68,6 +95,25 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&notificationConfigMap, "notification-configmap", "",
+		"The <namespace>/<name> of a ConfigMap holding cluster-wide rollout notification targets.")
+	flag.BoolVar(&migrateStorageVersion, "migrate-storage-version", true,
+		"If set, Rollouts still stored as an older API version are rewritten in the current storage version on startup.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated namespaces to watch. Empty watches the whole cluster.")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "",
+		"Label selector on Namespaces; only Rollouts in matching namespaces are reconciled (e.g. rollouts.example.com/enabled=true).")
+	flag.StringVar(&trafficConfigMap, "traffic-configmap", "",
+		"The <namespace>/<name> of a ConfigMap holding default and per-namespace traffic provider settings.")
+	flag.BoolVar(&cacheManagedOnly, "cache-managed-only", false,
+		"If set, only Deployments, StatefulSets, Services, Ingresses, ControllerRevisions and Jobs labeled "+
+			traffic.ManagedByLabel+"="+traffic.ManagedByValue+" are cached. "+
+			"Unlabeled objects, such as a target Deployment or Service created by hand, are read from the API server on each reconcile.")
+	flag.StringVar(&clusterKubeconfigDir, "cluster-kubeconfig-dir", "",
+		"Directory of member cluster kubeconfigs, one file per cluster named after it. "+
+			"If set, the manager also runs the MultiClusterRollout hub controller.")
+	flag.StringVar(&prometheusAddress, "prometheus-address", "",
+		"Base URL of a Prometheus-compatible query API (e.g. http://prometheus.monitoring.svc:9090) used by spec.analysis.metrics and spec.analysis.slos.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -96,8 +142,30 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
+	notificationCM, err := parseNamespacedName("--notification-configmap", notificationConfigMap)
+	if err != nil {
+		setupLog.Error(err, "invalid flag")
+		os.Exit(1)
+	}
+	trafficCM, err := parseNamespacedName("--traffic-configmap", trafficConfigMap)
+	if err != nil {
+		setupLog.Error(err, "invalid flag")
+		os.Exit(1)
+	}
+	nsSelector, err := labels.Parse(namespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid --namespace-selector", "value", namespaceSelector)
+		os.Exit(1)
+	}
+	cacheOpts, err := cacheOptions(splitList(watchNamespaces), cacheManagedOnly, notificationCM, trafficCM)
+	if err != nil {
+		setupLog.Error(err, "unable to build cache options")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache:  cacheOpts,
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
@@ -124,23 +192,87 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 只缓存带 managed-by 标签的对象时，缓存中找不到的对象再直接读 API server
+	mgrClient := mgr.GetClient()
+	if cacheManagedOnly {
+		mgrClient = cluster.NewFallbackClient(mgrClient, mgr.GetAPIReader(), managedOnlyObjects()...)
+	}
+
+	trafficFactory := traffic.ByProvider{
+		traffic.ProviderNginxIngress: &traffic.NginxFactory{Client: mgrClient, ConfigMap: trafficCM},
+		traffic.ProviderReplicaRatio: &traffic.ReplicaRatioFactory{Client: mgrClient},
+		traffic.ProviderSMI:          &traffic.SMIFactory{Client: mgrClient},
+	}
+	// 缓存只包含 --watch-namespaces 时，spec.dependsOn 可能指向缓存之外的 namespace，直接读 API server
+	var dependencyReader client.Reader
+	if len(splitList(watchNamespaces)) > 0 {
+		dependencyReader = mgr.GetAPIReader()
+	}
 	if err = (&controller.RolloutReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Traffic:  &traffic.NginxProvider{Client: mgr.GetClient(), Namespace: "default"},
-		Analysis: &analysis.ReadyEngine{Client: mgr.GetClient()},
+		Client:                mgrClient,
+		Scheme:                mgr.GetScheme(),
+		Traffic:               trafficFactory,
+		Analysis:              analysisEngine(mgrClient, prometheusAddress),
+		Notifier:              notify.NewDispatcher(),
+		NotificationConfigMap: notificationCM,
+		Recorder:              mgr.GetEventRecorderFor("rollout-controller"),
+		NamespaceSelector:     nsSelector,
+		DependencyReader:      dependencyReader,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Rollout")
 		os.Exit(1)
 	}
+	if err = (&controller.RolloutGroupReconciler{
+		Client:   mgrClient,
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("rolloutgroup-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RolloutGroup")
+		os.Exit(1)
+	}
+	if clusterKubeconfigDir != "" {
+		clusters, err := cluster.LoadDir(clusterKubeconfigDir, scheme)
+		if err != nil {
+			setupLog.Error(err, "unable to load member clusters", "dir", clusterKubeconfigDir)
+			os.Exit(1)
+		}
+		setupLog.Info("hub mode enabled", "clusters", clusters.Names())
+		if err = (&controller.MultiClusterRolloutReconciler{
+			Client:   mgrClient,
+			Scheme:   mgr.GetScheme(),
+			Clusters: clusters,
+			Recorder: mgr.GetEventRecorderFor("multiclusterrollout-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "MultiClusterRollout")
+			os.Exit(1)
+		}
+	}
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		if err = (&deliveryv1alpha1.Rollout{}).SetupWebhookWithManager(mgr); err != nil {
+		if err = (&deliveryv1beta1.Rollout{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Rollout")
 			os.Exit(1)
 		}
+		if err = (&deliveryv1beta1.RolloutGroup{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RolloutGroup")
+			os.Exit(1)
+		}
+		if err = (&deliveryv1beta1.MultiClusterRollout{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MultiClusterRollout")
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
+	if migrateStorageVersion {
+		if err := mgr.Add(&migration.StorageVersionMigrator{
+			Client: mgrClient,
+			Reader: mgr.GetAPIReader(),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up storage version migration")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -156,3 +288,84 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseNamespacedName 解析 <namespace>/<name> 形式的参数，空值表示不使用
+func parseNamespacedName(flagName, value string) (types.NamespacedName, error) {
+	if value == "" {
+		return types.NamespacedName{}, nil
+	}
+	ns, name, ok := strings.Cut(value, "/")
+	if !ok || ns == "" || name == "" {
+		return types.NamespacedName{}, fmt.Errorf("%s: expected <namespace>/<name>, got %q", flagName, value)
+	}
+	return types.NamespacedName{Namespace: ns, Name: name}, nil
+}
+
+// analysisEngine 就绪检查之上叠加指标与 SLO 检查；未配置 Prometheus 时带指标或 SLO 的 Rollout 分析报错
+func analysisEngine(c client.Client, prometheusAddress string) analysis.Engine {
+	e := &analysis.SLOEngine{Base: &analysis.ReadyEngine{Client: c}}
+	if prometheusAddress != "" {
+		e.Prometheus = &analysis.PrometheusClient{
+			Address:    prometheusAddress,
+			HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	return e
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// cacheOptions 限制 manager 缓存的范围：
+// 指定 namespace 时只缓存这些 namespace；ConfigMap 只缓存配置所在的 namespace；
+// managedOnly 时工作负载与流量资源只缓存带 managed-by 标签的对象
+func cacheOptions(namespaces []string, managedOnly bool, configMaps ...types.NamespacedName) (cache.Options, error) {
+	var opts cache.Options
+	if len(namespaces) > 0 {
+		opts.DefaultNamespaces = map[string]cache.Config{}
+		for _, ns := range namespaces {
+			opts.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+	opts.ByObject = map[client.Object]cache.ByObject{}
+
+	cmNamespaces := map[string]cache.Config{}
+	for _, cm := range configMaps {
+		if cm.Name != "" {
+			cmNamespaces[cm.Namespace] = cache.Config{}
+		}
+	}
+	if len(cmNamespaces) > 0 {
+		opts.ByObject[&corev1.ConfigMap{}] = cache.ByObject{Namespaces: cmNamespaces}
+	}
+
+	if managedOnly {
+		managed, err := labels.ValidatedSelectorFromSet(labels.Set{traffic.ManagedByLabel: traffic.ManagedByValue})
+		if err != nil {
+			return opts, err
+		}
+		for _, obj := range managedOnlyObjects() {
+			opts.ByObject[obj] = cache.ByObject{Label: managed}
+		}
+	}
+	return opts, nil
+}
+
+// managedOnlyObjects managedOnly 时只缓存带 managed-by 标签的对象类型
+func managedOnlyObjects() []client.Object {
+	return []client.Object{
+		&appsv1.Deployment{},
+		&appsv1.StatefulSet{},
+		&appsv1.ControllerRevision{},
+		&corev1.Service{},
+		&networkingv1.Ingress{},
+		&batchv1.Job{},
+	}
+}
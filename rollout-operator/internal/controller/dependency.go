@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+// dependsOnIndex Rollout 按依赖（namespace/name）建立的索引，用于依赖变化时找回依赖方
+const dependsOnIndex = "spec.dependsOn"
+
+// dependencyRecheckInterval 通过 DependencyReader 读取依赖时，等待依赖期间重新检查的间隔
+const dependencyRecheckInterval = 30 * time.Second
+
+// dependencyState 依赖检查的结论
+type dependencyState int
+
+const (
+	dependenciesReady dependencyState = iota
+	dependenciesPending
+	dependencyRolledBack
+)
+
+// dependencyKeys 返回 Rollout 依赖的 namespace/name 列表
+func dependencyKeys(ro *dlv1.Rollout) []types.NamespacedName {
+	keys := make([]types.NamespacedName, 0, len(ro.Spec.DependsOn))
+	for _, d := range ro.Spec.DependsOn {
+		ns := d.Namespace
+		if ns == "" {
+			ns = ro.Namespace
+		}
+		keys = append(keys, types.NamespacedName{Namespace: ns, Name: d.Name})
+	}
+	return keys
+}
+
+// syncDependencies 检查依赖状态并更新 DependenciesReady 条件；
+// 依赖只在带有相同 ReleaseLabel 值时才算数，避免沿用上一批次的结果
+func (r *RolloutReconciler) syncDependencies(ctx context.Context, ro *dlv1.Rollout) (dependencyState, string, error) {
+	lg := log.FromContext(ctx)
+	release := ro.Labels[dlv1.ReleaseLabel]
+	reader := r.DependencyReader
+	if reader == nil {
+		reader = r.Client
+	}
+	var pending []string
+	for _, key := range dependencyKeys(ro) {
+		var dep dlv1.Rollout
+		if err := reader.Get(ctx, key, &dep); err != nil {
+			if apierrors.IsNotFound(err) {
+				pending = append(pending, key.String()+" (not found)")
+				continue
+			}
+			lg.Error(err, "Failed to get dependency", "dependency", key.String())
+			err = fmt.Errorf("read dependency %s: %w", key, err)
+			setDependencyCondition(ro, metav1.ConditionFalse, "DependencyUnreadable", err.Error())
+			return dependenciesPending, "", err
+		}
+		if dep.Labels[dlv1.ReleaseLabel] != release {
+			pending = append(pending, fmt.Sprintf("%s (release %q)", key, dep.Labels[dlv1.ReleaseLabel]))
+			continue
+		}
+		switch dep.Status.Phase {
+		case dlv1.PhaseSucceeded:
+		case dlv1.PhaseRolledBack:
+			msg := fmt.Sprintf("dependency %s rolled back", key)
+			setDependencyCondition(ro, metav1.ConditionFalse, "DependencyRolledBack", msg)
+			return dependencyRolledBack, msg, nil
+		default:
+			pending = append(pending, fmt.Sprintf("%s (%s)", key, orUnknown(string(dep.Status.Phase))))
+		}
+	}
+	if len(pending) > 0 {
+		msg := "waiting for " + strings.Join(pending, ", ")
+		setDependencyCondition(ro, metav1.ConditionFalse, "Waiting", msg)
+		return dependenciesPending, msg, nil
+	}
+	setDependencyCondition(ro, metav1.ConditionTrue, "Succeeded", "all dependencies succeeded")
+	return dependenciesReady, "", nil
+}
+
+func setDependencyCondition(ro *dlv1.Rollout, status metav1.ConditionStatus, reason, msg string) {
+	meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+		Type:               dlv1.ConditionDependenciesReady,
+		Status:             status,
+		ObservedGeneration: ro.Generation,
+		Reason:             reason,
+		Message:            msg,
+	})
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "not started"
+	}
+	return s
+}
+
+// rolloutStarted 是否已经下发过第一步的流量
+func rolloutStarted(ro *dlv1.Rollout) bool {
+	return ro.Status.StepIndex > 0 || len(ro.Status.Steps) > 0
+}
+
+// dependentRollouts 依赖方随被依赖的 Rollout 状态变化重新调和
+func (r *RolloutReconciler) dependentRollouts(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list dlv1.RolloutList
+	key := client.ObjectKeyFromObject(obj).String()
+	if err := r.List(ctx, &list, client.MatchingFields{dependsOnIndex: key}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list dependent rollouts", "rollout", key)
+		return nil
+	}
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for i := range list.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+	}
+	return reqs
+}
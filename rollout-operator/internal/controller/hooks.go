@@ -0,0 +1,374 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/traffic"
+)
+
+// HookNameLabel 标记 hook Job 对应的 hook 名称
+const HookNameLabel = "delivery.example.com/hook"
+
+// hookPollInterval Job hook 运行期间重新检查的间隔；Job 状态变化本身也会触发调和
+const hookPollInterval = 10 * time.Second
+
+// httpHookAttemptTimeout 单次 HTTP hook 请求的超时时间。请求在调和中同步执行，
+// 超时或连接失败时在下一次调和中重试，直到 hook 的 timeoutSeconds 用完
+const httpHookAttemptTimeout = 10 * time.Second
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// runHookGate 在继续发布之前执行 point 时机的 hook：仍有 hook 在运行时返回 done=false 与重新检查的时间；
+// Abort 策略的 hook 失败时中止发布，同样返回 done=false
+func (r *RolloutReconciler) runHookGate(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, point dlv1.HookPoint) (ctrl.Result, bool, error) {
+	wait, failed, err := r.runHooks(ctx, ro, point)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+	if failed != "" {
+		res, err := r.abort(ctx, tp, ro, dlv1.ConditionHookFailed, failed)
+		return res, false, err
+	}
+	if wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, false, nil
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// hookGate 执行 point 时机 hook 的动作，见 runHookGate；计划模式下假设 hook 全部成功
+func (r *RolloutReconciler) hookGate(tp traffic.Provider, ro *dlv1.Rollout, point dlv1.HookPoint) action {
+	return action{
+		plan:   hookPlan(ro, point),
+		assume: func() { assumeHooks(ro, point) },
+		run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			return r.runHookGate(ctx, tp, ro, point)
+		},
+	}
+}
+
+// followUpHooks 执行提升或回滚之后 hook 的动作，见 runFollowUpHooks；它总是本次调和的最后一个动作
+func (r *RolloutReconciler) followUpHooks(ro *dlv1.Rollout, point dlv1.HookPoint) action {
+	return action{
+		plan:   hookPlan(ro, point),
+		assume: func() { assumeHooks(ro, point) },
+		run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			res, err := r.runFollowUpHooks(ctx, ro, point)
+			return res, false, err
+		},
+	}
+}
+
+// hookPlan 描述 point 时机将要执行的 hook；本次发布中已有结果的不再执行。
+// hook 只描述不模拟：HTTP 调用无法只记录不发送
+func hookPlan(ro *dlv1.Rollout, point dlv1.HookPoint) []dlv1.PlannedAction {
+	var plan []dlv1.PlannedAction
+	hooks := ro.Spec.Hooks.At(point)
+	for i := range hooks {
+		h := &hooks[i]
+		if st := ro.Status.HookStatus(h.Name); st != nil && st.Phase != dlv1.HookRunning {
+			continue
+		}
+		target, what := "", ""
+		if h.HTTP != nil {
+			method := h.HTTP.Method
+			if method == "" {
+				method = http.MethodPost
+			}
+			what = fmt.Sprintf("call %s %s", method, h.HTTP.URL)
+		} else {
+			target = "Job/" + hookJobName(ro, h.Name)
+			what = "run a Job"
+		}
+		plan = append(plan, dlv1.PlannedAction{Type: dlv1.PlanHook, Target: target, Message: fmt.Sprintf("%s hook %s: %s (timeout %s, failurePolicy %s)",
+			point, h.Name, what, hookTimeout(h), h.EffectiveFailurePolicy(point))})
+	}
+	return plan
+}
+
+// assumeHooks 计划模式下把 point 时机尚无结果的 hook 记为成功
+func assumeHooks(ro *dlv1.Rollout, point dlv1.HookPoint) {
+	hooks := ro.Spec.Hooks.At(point)
+	for i := range hooks {
+		st := ro.Status.HookStatus(hooks[i].Name)
+		if st == nil {
+			ro.Status.Hooks = append(ro.Status.Hooks, dlv1.HookStatus{Name: hooks[i].Name, Point: point, StartedAt: metav1.Now()})
+			st = &ro.Status.Hooks[len(ro.Status.Hooks)-1]
+		}
+		if st.Phase == "" || st.Phase == dlv1.HookRunning {
+			st.Phase = dlv1.HookSucceeded
+		}
+	}
+}
+
+// runFollowUpHooks 执行提升或回滚之后的 hook，它们的失败只记录在状态中
+func (r *RolloutReconciler) runFollowUpHooks(ctx context.Context, ro *dlv1.Rollout, point dlv1.HookPoint) (ctrl.Result, error) {
+	wait, _, err := r.runHooks(ctx, ro, point)
+	return ctrl.Result{RequeueAfter: wait}, err
+}
+
+// runHooks 按顺序执行 point 时机的 hook，已有结果的直接跳过。
+// 返回 wait>0 表示仍有 hook 在运行；failed 非空表示某个 Abort 策略的 hook 失败
+func (r *RolloutReconciler) runHooks(ctx context.Context, ro *dlv1.Rollout, point dlv1.HookPoint) (wait time.Duration, failed string, err error) {
+	lg := log.FromContext(ctx)
+	hooks := ro.Spec.Hooks.At(point)
+	for i := range hooks {
+		h := &hooks[i]
+		st := ro.Status.HookStatus(h.Name)
+		if st == nil {
+			lg.Info("Starting hook", "hook", h.Name, "point", point)
+			ro.Status.Hooks = append(ro.Status.Hooks, dlv1.HookStatus{
+				Name:      h.Name,
+				Point:     point,
+				Phase:     dlv1.HookRunning,
+				StartedAt: metav1.Now(),
+			})
+			st = &ro.Status.Hooks[len(ro.Status.Hooks)-1]
+		}
+		if st.Phase == dlv1.HookRunning {
+			if h.HTTP != nil {
+				r.runHTTPHook(ctx, ro, point, h, st)
+			} else if err := r.runJobHook(ctx, ro, h, st); err != nil {
+				lg.Error(err, "Failed to run job hook", "hook", h.Name)
+				return 0, "", err
+			}
+		}
+		if st.Phase == dlv1.HookRunning {
+			timeout := hookTimeout(h)
+			remaining := time.Until(st.StartedAt.Add(timeout))
+			if remaining > 0 {
+				lg.Info("Waiting for hook", "hook", h.Name, "point", point, "job", st.Job)
+				return min(remaining, hookPollInterval), "", nil
+			}
+			msg := fmt.Sprintf("timed out after %s", timeout)
+			if st.Message != "" {
+				msg += ": " + st.Message
+			}
+			r.finishHook(ro, st, dlv1.HookFailed, msg)
+		}
+		if st.Phase == dlv1.HookFailed && h.EffectiveFailurePolicy(point) == dlv1.HookFailureAbort {
+			return 0, fmt.Sprintf("%s hook %s failed: %s", point, h.Name, st.Message), nil
+		}
+	}
+	return 0, "", nil
+}
+
+// finishHook 记录 hook 的结果；失败时设置 HookFailed 条件
+func (r *RolloutReconciler) finishHook(ro *dlv1.Rollout, st *dlv1.HookStatus, phase dlv1.HookPhase, msg string) {
+	now := metav1.Now()
+	st.Phase = phase
+	st.FinishedAt = &now
+	st.Message = msg
+	eventType := corev1.EventTypeNormal
+	if phase == dlv1.HookFailed {
+		eventType = corev1.EventTypeWarning
+		meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+			Type:               dlv1.ConditionHookFailed,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: ro.Generation,
+			Reason:             string(st.Point),
+			Message:            fmt.Sprintf("hook %s: %s", st.Name, msg),
+		})
+	}
+	if r.Recorder != nil {
+		r.Recorder.Event(ro, eventType, "Hook"+string(phase), fmt.Sprintf("%s hook %s: %s", st.Point, st.Name, msg))
+	}
+}
+
+// hookRequest HTTP hook 的请求体
+type hookRequest struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Hook      string `json:"hook"`
+	Point     string `json:"point"`
+	Revision  string `json:"revision"`
+}
+
+// runHTTPHook 调用一次 HTTP hook，单次最多等待 httpHookAttemptTimeout：2xx 为成功，其他状态码为失败；
+// 请求出错或超时时 hook 保持 Running，错误记录在 Message 中，由 runHooks 重新排队后重试
+func (r *RolloutReconciler) runHTTPHook(ctx context.Context, ro *dlv1.Rollout, point dlv1.HookPoint, h *dlv1.RolloutHook, st *dlv1.HookStatus) {
+	remaining := time.Until(st.StartedAt.Add(hookTimeout(h)))
+	if remaining <= 0 {
+		return
+	}
+	hctx, cancel := context.WithTimeout(ctx, min(remaining, httpHookAttemptTimeout))
+	defer cancel()
+
+	body, err := json.Marshal(hookRequest{
+		Namespace: ro.Namespace,
+		Name:      ro.Name,
+		Hook:      h.Name,
+		Point:     string(point),
+		Revision:  ro.Status.CanaryRevision,
+	})
+	if err != nil {
+		r.finishHook(ro, st, dlv1.HookFailed, err.Error())
+		return
+	}
+	method := h.HTTP.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(hctx, method, h.HTTP.URL, bytes.NewReader(body))
+	if err != nil {
+		r.finishHook(ro, st, dlv1.HookFailed, err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.HTTP.Headers {
+		req.Header.Set(k, v)
+	}
+	hc := r.HookClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		log.FromContext(ctx).Info("HTTP hook attempt failed, retrying", "hook", h.Name, "error", err.Error())
+		st.Message = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		r.finishHook(ro, st, dlv1.HookFailed, fmt.Sprintf("%s %s returned %s", method, h.HTTP.URL, resp.Status))
+		return
+	}
+	r.finishHook(ro, st, dlv1.HookSucceeded, fmt.Sprintf("%s %s returned %s", method, h.HTTP.URL, resp.Status))
+}
+
+// runJobHook 创建 hook 的 Job 并根据 Job 的状态更新结果。
+// Job 名称按版本固定：状态中没有记录的同名 Job 来自上一次尝试（retry 之前），
+// 成功的直接沿用结果，失败的删除后重新创建
+func (r *RolloutReconciler) runJobHook(ctx context.Context, ro *dlv1.Rollout, h *dlv1.RolloutHook, st *dlv1.HookStatus) error {
+	lg := log.FromContext(ctx)
+	name := hookJobName(ro, h.Name)
+	var job batchv1.Job
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ro.Namespace}, &job)
+	if apierrors.IsNotFound(err) {
+		newJob := hookJob(ro, h, name)
+		if err := controllerutil.SetControllerReference(ro, newJob, r.Scheme); err != nil {
+			return err
+		}
+		lg.Info("Creating hook job", "hook", h.Name, "job", name)
+		if err := r.Create(ctx, newJob); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		st.Job = name
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(&job, ro) {
+		r.finishHook(ro, st, dlv1.HookFailed, fmt.Sprintf("job %s exists and is not owned by this rollout", name))
+		return nil
+	}
+	if !job.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if st.Job == "" {
+		if jobCondition(&job, batchv1.JobFailed) != nil {
+			lg.Info("Deleting failed hook job from a previous attempt", "hook", h.Name, "job", name)
+			return client.IgnoreNotFound(r.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+		}
+		st.Job = name
+	}
+	if c := jobCondition(&job, batchv1.JobComplete); c != nil {
+		r.finishHook(ro, st, dlv1.HookSucceeded, fmt.Sprintf("job %s completed", name))
+	} else if c := jobCondition(&job, batchv1.JobFailed); c != nil {
+		r.finishHook(ro, st, dlv1.HookFailed, fmt.Sprintf("job %s failed: %s", name, c.Message))
+	}
+	return nil
+}
+
+// hookJob 按 hook 的模板构造 Job：activeDeadlineSeconds 不超过 hook 的超时时间
+func hookJob(ro *dlv1.Rollout, h *dlv1.RolloutHook, name string) *batchv1.Job {
+	tpl := h.Job.DeepCopy()
+	labels := map[string]string{}
+	for k, v := range tpl.Labels {
+		labels[k] = v
+	}
+	labels[traffic.ManagedByLabel] = traffic.ManagedByValue
+	labels[RolloutNameLabel] = ro.Name
+	labels[HookNameLabel] = h.Name
+
+	deadline := int64(hookTimeout(h) / time.Second)
+	if tpl.Spec.ActiveDeadlineSeconds == nil || *tpl.Spec.ActiveDeadlineSeconds > deadline {
+		tpl.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	if tpl.Spec.Template.Spec.RestartPolicy == "" {
+		tpl.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ro.Namespace,
+			Labels:      labels,
+			Annotations: tpl.Annotations,
+		},
+		Spec: tpl.Spec,
+	}
+}
+
+// hookJobName <rollout>-<hook>-<版本哈希>；超过 63 个字符时截短 Rollout 名称部分
+func hookJobName(ro *dlv1.Rollout, hook string) string {
+	suffix := "-" + hook + "-" + strings.TrimPrefix(ro.Status.CanaryRevision, ro.Name+"-")
+	prefix := ro.Name
+	if n := validation.DNS1123LabelMaxLength - len(suffix); len(prefix) > n {
+		prefix = strings.TrimRight(prefix[:n], "-.")
+	}
+	return prefix + suffix
+}
+
+// hookTimeout hook 的超时时间，未设置时使用默认值
+func hookTimeout(h *dlv1.RolloutHook) time.Duration {
+	if h.TimeoutSeconds > 0 {
+		return time.Duration(h.TimeoutSeconds) * time.Second
+	}
+	return dlv1.DefaultHookTimeoutSeconds * time.Second
+}
+
+// jobCondition 返回 Job 上为 True 的指定类型条件
+func jobCondition(job *batchv1.Job, t batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		if c := &job.Status.Conditions[i]; c.Type == t && c.Status == corev1.ConditionTrue {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/cluster"
+)
+
+// ConditionClustersReady spec 中已开始发布的集群是否都已注册且可以访问
+const ConditionClustersReady = "ClustersReady"
+
+// ConditionHalted 有成员集群回滚或失败，后续批次不再更新
+const ConditionHalted = "Halted"
+
+// memberPollInterval 成员集群中的 Rollout 无法在 hub 中 watch，发布进行中按此间隔轮询
+const memberPollInterval = 15 * time.Second
+
+// MultiClusterRolloutReconciler hub 控制器：按批次在成员集群中创建或更新同名 Rollout，前一批全部成功后才推进下一批
+type MultiClusterRolloutReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Clusters 成员集群的客户端，按 spec.waves 中的集群名称查找
+	Clusters *cluster.Registry
+	// Recorder 为空时不记录事件
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=delivery.example.com,resources=multiclusterrollouts,verbs=get;list;watch
+// +kubebuilder:rbac:groups=delivery.example.com,resources=multiclusterrollouts/status,verbs=get;update;patch
+
+func (r *MultiClusterRolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	lg := log.FromContext(ctx)
+
+	var mcr dlv1.MultiClusterRollout
+	if err := r.Get(ctx, req.NamespacedName, &mcr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	base := mcr.DeepCopy()
+	defer func() {
+		if equality.Semantic.DeepEqual(base.Status, mcr.Status) {
+			return
+		}
+		if err := r.Status().Patch(ctx, &mcr, client.MergeFrom(base)); err != nil {
+			lg.Error(err, "Failed to patch multi-cluster rollout status")
+			if retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	// 模板变化即新一轮发布，从第一批重新开始
+	release, err := releaseHash(&mcr.Spec.Template)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if mcr.Status.Release != release {
+		lg.Info("Starting multi-cluster release", "release", release)
+		mcr.Status = dlv1.MultiClusterRolloutStatus{Release: release, Phase: dlv1.MultiClusterProgressing}
+	}
+	// 中止后保持现状，等待模板修改后重新开始
+	if mcr.Status.Phase == dlv1.MultiClusterHalted {
+		return ctrl.Result{}, nil
+	}
+
+	// 逐批推进：遇到第一个未全部成功的批次即停止，之后的批次不创建也不更新
+	current := -1
+	var statuses []dlv1.ClusterRolloutStatus
+	var failed *dlv1.ClusterRolloutStatus
+	var problems []string
+	for i, w := range mcr.Spec.Waves {
+		if current >= 0 {
+			break
+		}
+		for _, name := range w.Clusters {
+			st, done := r.syncCluster(ctx, &mcr, name, i)
+			if st.Message != "" {
+				problems = append(problems, name+": "+st.Message)
+			}
+			if failed == nil && (st.Phase == dlv1.PhaseRolledBack || st.Phase == dlv1.PhaseFailed) {
+				failed = st.DeepCopy()
+			}
+			if !done {
+				current = i
+			}
+			statuses = append(statuses, st)
+		}
+	}
+	mcr.Status.Clusters = statuses
+
+	if len(problems) > 0 {
+		meta.SetStatusCondition(&mcr.Status.Conditions, metav1.Condition{
+			Type:               ConditionClustersReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: mcr.Generation,
+			Reason:             "ClusterUnavailable",
+			Message:            strings.Join(problems, "; "),
+		})
+	} else {
+		meta.SetStatusCondition(&mcr.Status.Conditions, metav1.Condition{
+			Type:               ConditionClustersReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: mcr.Generation,
+			Reason:             "AllClustersReachable",
+			Message:            fmt.Sprintf("%d clusters started", len(statuses)),
+		})
+	}
+
+	switch {
+	case failed != nil:
+		msg := fmt.Sprintf("rollout in cluster %s is %s, later waves are not updated", failed.Cluster, failed.Phase)
+		lg.Info("Member cluster failed, halting", "cluster", failed.Cluster, "phase", failed.Phase)
+		mcr.Status.Phase = dlv1.MultiClusterHalted
+		mcr.Status.CurrentWave = failed.Wave
+		meta.SetStatusCondition(&mcr.Status.Conditions, metav1.Condition{
+			Type:               ConditionHalted,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: mcr.Generation,
+			Reason:             "ClusterFailed",
+			Message:            msg,
+		})
+		if r.Recorder != nil {
+			r.Recorder.Event(&mcr, corev1.EventTypeWarning, ConditionHalted, msg)
+		}
+		return ctrl.Result{}, nil
+	case current < 0:
+		if mcr.Status.Phase != dlv1.MultiClusterSucceeded {
+			lg.Info("All waves succeeded", "release", release)
+		}
+		mcr.Status.Phase = dlv1.MultiClusterSucceeded
+		mcr.Status.CurrentWave = int32(len(mcr.Spec.Waves) - 1)
+		return ctrl.Result{}, nil
+	default:
+		mcr.Status.Phase = dlv1.MultiClusterProgressing
+		mcr.Status.CurrentWave = int32(current)
+		return ctrl.Result{RequeueAfter: memberPollInterval}, nil
+	}
+}
+
+// syncCluster 确保成员集群中的 Rollout 与模板一致并汇总其状态；
+// 只有成员控制器已接手当前模板后才报告阶段，避免把上一轮的 Succeeded 当成本轮结果
+func (r *MultiClusterRolloutReconciler) syncCluster(ctx context.Context, mcr *dlv1.MultiClusterRollout, name string, wave int) (dlv1.ClusterRolloutStatus, bool) {
+	lg := log.FromContext(ctx)
+	st := dlv1.ClusterRolloutStatus{Cluster: name, Wave: int32(wave)}
+	c, ok := r.Clusters.Get(name)
+	if !ok {
+		st.Message = "cluster is not registered in the hub"
+		return st, false
+	}
+	ro, err := r.ensureMember(ctx, c, mcr)
+	if err != nil {
+		lg.Error(err, "Failed to sync member rollout", "cluster", name)
+		st.Message = err.Error()
+		return st, false
+	}
+	rev, err := memberRevision(ro)
+	if err != nil {
+		st.Message = err.Error()
+		return st, false
+	}
+	if ro.Status.CanaryRevision != rev {
+		return st, false
+	}
+	st.Phase = ro.Status.Phase
+	st.StepIndex = ro.Status.StepIndex
+	return st, st.Phase == dlv1.PhaseSucceeded
+}
+
+// ensureMember 在成员集群中创建与本对象同名的 Rollout，或把已有 Rollout 的 spec 更新为模板。
+// 模板先按 Rollout 的默认值补全，避免与成员集群 webhook 补全后的 spec 反复比较不一致
+func (r *MultiClusterRolloutReconciler) ensureMember(ctx context.Context, c client.Client, mcr *dlv1.MultiClusterRollout) (*dlv1.Rollout, error) {
+	want := &dlv1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mcr.Name,
+			Namespace: mcr.Namespace,
+			Labels:    map[string]string{dlv1.MultiClusterLabel: mcr.Name},
+		},
+		Spec: *mcr.Spec.Template.DeepCopy(),
+	}
+	want.Default()
+
+	var ro dlv1.Rollout
+	err := c.Get(ctx, client.ObjectKeyFromObject(want), &ro)
+	switch {
+	case apierrors.IsNotFound(err):
+		log.FromContext(ctx).Info("Creating member rollout", "rollout", client.ObjectKeyFromObject(want))
+		if err := c.Create(ctx, want); err != nil {
+			return nil, err
+		}
+		return want, nil
+	case err != nil:
+		return nil, err
+	}
+	if ro.Labels[dlv1.MultiClusterLabel] == mcr.Name && equality.Semantic.DeepEqual(ro.Spec, want.Spec) {
+		return &ro, nil
+	}
+	base := ro.DeepCopy()
+	if ro.Labels == nil {
+		ro.Labels = map[string]string{}
+	}
+	ro.Labels[dlv1.MultiClusterLabel] = mcr.Name
+	ro.Spec = want.Spec
+	if err := c.Patch(ctx, &ro, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+		return nil, err
+	}
+	return &ro, nil
+}
+
+// memberRevision 成员 Rollout 当前模板对应的 ControllerRevision 名称，与 syncRevision 的命名一致
+func memberRevision(ro *dlv1.Rollout) (string, error) {
+	tpl := revisionTemplate(ro)
+	hash, err := templateHash(&tpl)
+	if err != nil {
+		return "", err
+	}
+	return ro.Name + "-" + hash, nil
+}
+
+// releaseHash Rollout 模板的短哈希，作为 status.release
+func releaseHash(spec *dlv1.RolloutSpec) (string, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(raw)
+	return rand.SafeEncodeString(fmt.Sprint(h.Sum32())), nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MultiClusterRolloutReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dlv1.MultiClusterRollout{}).
+		Complete(r)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	deliveryv1beta1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/cluster"
+)
+
+var _ = Describe("MultiClusterRollout Controller", Ordered, func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "checkout", Namespace: "default"}
+	var memberEnv *envtest.Environment
+	var prodClient client.Client
+	var reconciler *MultiClusterRolloutReconciler
+
+	// staging 复用 hub 所在的测试集群，prod 是单独启动的第二个 envtest 集群
+	BeforeAll(func() {
+		memberEnv = &envtest.Environment{
+			CRDDirectoryPaths:     testEnv.CRDDirectoryPaths,
+			ErrorIfCRDPathMissing: true,
+			BinaryAssetsDirectory: testEnv.BinaryAssetsDirectory,
+		}
+		memberCfg, err := memberEnv.Start()
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { Expect(memberEnv.Stop()).To(Succeed()) })
+		prodClient, err = client.New(memberCfg, client.Options{Scheme: k8sClient.Scheme()})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	template := func(image string) deliveryv1beta1.RolloutSpec {
+		return deliveryv1beta1.RolloutSpec{
+			TargetRef: deliveryv1beta1.TargetRef{Kind: deliveryv1beta1.KindDeployment, Name: "checkout", Port: 8080},
+			Strategy: deliveryv1beta1.RolloutStrategy{
+				Type:  deliveryv1beta1.Canary,
+				Steps: []deliveryv1beta1.RolloutStep{{Weight: 50}, {Weight: 100}},
+			},
+			Analysis: deliveryv1beta1.AnalysisSpec{
+				Metrics: []deliveryv1beta1.MetricCheck{{Name: "ok", PromQL: "vector(0)", Threshold: "1", Compare: deliveryv1beta1.CompareLT}},
+			},
+			Traffic: deliveryv1beta1.TrafficSpec{
+				Provider: "NginxIngress", Host: "checkout.example.com",
+				StableService: "checkout-stable", CanaryService: "checkout-canary",
+			},
+			Template: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+			},
+		}
+	}
+	getMember := func(c client.Client) *deliveryv1beta1.Rollout {
+		ro := &deliveryv1beta1.Rollout{}
+		Expect(c.Get(ctx, key, ro)).To(Succeed())
+		return ro
+	}
+	// pickUp 模拟成员集群中的 Rollout 控制器接手当前模板并推进到指定阶段
+	pickUp := func(c client.Client, phase deliveryv1beta1.RolloutPhase) {
+		ro := getMember(c)
+		rev, err := memberRevision(ro)
+		Expect(err).NotTo(HaveOccurred())
+		ro.Status.CanaryRevision = rev
+		ro.Status.Phase = phase
+		Expect(c.Status().Update(ctx, ro)).To(Succeed())
+	}
+	reconcileMCR := func() *deliveryv1beta1.MultiClusterRollout {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		mcr := &deliveryv1beta1.MultiClusterRollout{}
+		Expect(k8sClient.Get(ctx, key, mcr)).To(Succeed())
+		return mcr
+	}
+
+	BeforeEach(func() {
+		mcr := &deliveryv1beta1.MultiClusterRollout{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: deliveryv1beta1.MultiClusterRolloutSpec{
+				Template: template("checkout:1.0"),
+				Waves: []deliveryv1beta1.ClusterWave{
+					{Name: "staging", Clusters: []string{"staging"}},
+					{Name: "prod", Clusters: []string{"prod"}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, mcr)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, mcr))).To(Succeed())
+			for _, c := range []client.Client{k8sClient, prodClient} {
+				ro := &deliveryv1beta1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+				Expect(client.IgnoreNotFound(c.Delete(ctx, ro))).To(Succeed())
+			}
+		})
+
+		clusters := cluster.NewRegistry()
+		clusters.Add("staging", k8sClient)
+		clusters.Add("prod", prodClient)
+		reconciler = &MultiClusterRolloutReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Clusters: clusters}
+	})
+
+	It("should gate each wave on the previous wave's success", func() {
+		mcr := reconcileMCR()
+		Expect(mcr.Status.Phase).To(Equal(deliveryv1beta1.MultiClusterProgressing))
+		Expect(mcr.Status.CurrentWave).To(Equal(int32(0)))
+		Expect(mcr.Status.Release).NotTo(BeEmpty())
+		Expect(meta.IsStatusConditionTrue(mcr.Status.Conditions, ConditionClustersReady)).To(BeTrue())
+
+		staging := getMember(k8sClient)
+		Expect(staging.Labels).To(HaveKeyWithValue(deliveryv1beta1.MultiClusterLabel, "checkout"))
+		Expect(staging.Spec.Template.Spec.Containers[0].Image).To(Equal("checkout:1.0"))
+		Expect(staging.Spec.Analysis.IntervalSeconds).To(Equal(int32(30)))
+		Expect(prodClient.Get(ctx, key, &deliveryv1beta1.Rollout{})).NotTo(Succeed())
+
+		By("Ignoring a Succeeded phase the member controller reported before picking up the template")
+		staging.Status.Phase = deliveryv1beta1.PhaseSucceeded
+		staging.Status.CanaryRevision = "checkout-old"
+		Expect(k8sClient.Status().Update(ctx, staging)).To(Succeed())
+		mcr = reconcileMCR()
+		Expect(mcr.Status.CurrentWave).To(Equal(int32(0)))
+		Expect(mcr.Status.Cluster("staging").Phase).To(BeEmpty())
+
+		By("Creating the next wave once the first succeeded")
+		pickUp(k8sClient, deliveryv1beta1.PhaseSucceeded)
+		mcr = reconcileMCR()
+		Expect(mcr.Status.CurrentWave).To(Equal(int32(1)))
+		Expect(mcr.Status.Cluster("staging").Phase).To(Equal(deliveryv1beta1.PhaseSucceeded))
+		Expect(getMember(prodClient).Spec.Template.Spec.Containers[0].Image).To(Equal("checkout:1.0"))
+
+		By("Succeeding once every wave succeeded")
+		pickUp(prodClient, deliveryv1beta1.PhaseSucceeded)
+		mcr = reconcileMCR()
+		Expect(mcr.Status.Phase).To(Equal(deliveryv1beta1.MultiClusterSucceeded))
+
+		By("Starting over from the first wave when the template changes")
+		mcr.Spec.Template = template("checkout:2.0")
+		Expect(k8sClient.Update(ctx, mcr)).To(Succeed())
+		mcr = reconcileMCR()
+		Expect(mcr.Status.Phase).To(Equal(deliveryv1beta1.MultiClusterProgressing))
+		Expect(mcr.Status.CurrentWave).To(Equal(int32(0)))
+		Expect(getMember(k8sClient).Spec.Template.Spec.Containers[0].Image).To(Equal("checkout:2.0"))
+		Expect(getMember(prodClient).Spec.Template.Spec.Containers[0].Image).To(Equal("checkout:1.0"))
+	})
+
+	It("should halt later waves when a cluster rolls back", func() {
+		reconcileMCR()
+		pickUp(k8sClient, deliveryv1beta1.PhaseRolledBack)
+
+		mcr := reconcileMCR()
+		Expect(mcr.Status.Phase).To(Equal(deliveryv1beta1.MultiClusterHalted))
+		Expect(meta.FindStatusCondition(mcr.Status.Conditions, ConditionHalted).Message).To(ContainSubstring("cluster staging is RolledBack"))
+		Expect(prodClient.Get(ctx, key, &deliveryv1beta1.Rollout{})).NotTo(Succeed())
+
+		By("Staying halted even if the member recovers")
+		pickUp(k8sClient, deliveryv1beta1.PhaseSucceeded)
+		mcr = reconcileMCR()
+		Expect(mcr.Status.Phase).To(Equal(deliveryv1beta1.MultiClusterHalted))
+		Expect(prodClient.Get(ctx, key, &deliveryv1beta1.Rollout{})).NotTo(Succeed())
+	})
+
+	It("should report clusters missing from the hub", func() {
+		mcr := &deliveryv1beta1.MultiClusterRollout{}
+		Expect(k8sClient.Get(ctx, key, mcr)).To(Succeed())
+		mcr.Spec.Waves[0].Clusters = append(mcr.Spec.Waves[0].Clusters, "dr")
+		Expect(k8sClient.Update(ctx, mcr)).To(Succeed())
+
+		reconcileMCR()
+		pickUp(k8sClient, deliveryv1beta1.PhaseSucceeded)
+		mcr = reconcileMCR()
+		Expect(mcr.Status.CurrentWave).To(Equal(int32(0)))
+		Expect(prodClient.Get(ctx, key, &deliveryv1beta1.Rollout{})).NotTo(Succeed())
+		c := meta.FindStatusCondition(mcr.Status.Conditions, ConditionClustersReady)
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Message).To(ContainSubstring("dr: cluster is not registered"))
+	})
+})
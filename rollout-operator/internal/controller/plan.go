@@ -0,0 +1,257 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+// reconcilePlan spec.dryRun 为 true 时代替正常调和：只计算发布计划写入 status.plan，
+// 不添加 finalizer、不登记版本，也不修改任何工作负载或流量资源
+func (r *RolloutReconciler) reconcilePlan(ctx context.Context, ro *dlv1.Rollout) error {
+	lg := log.FromContext(ctx)
+	plan, err := r.planRollout(ctx, ro)
+	if err != nil {
+		lg.Error(err, "Failed to plan rollout")
+		return err
+	}
+	if old := ro.Status.Plan; old != nil && old.Revision == plan.Revision && equality.Semantic.DeepEqual(old.Actions, plan.Actions) {
+		return nil
+	}
+	lg.Info("Rollout plan changed", "revision", plan.Revision, "actions", len(plan.Actions))
+	base := ro.DeepCopy()
+	plan.GeneratedAt = metav1.Now()
+	ro.Status.Plan = plan
+	return r.patchStatus(ctx, base, ro)
+}
+
+// planRollout 从当前状态出发模拟一次完整发布，每一轮相当于一次调和：prepare 之后列出与 Reconcile 相同的动作，
+// 写动作对 planClient 执行，写操作只被记录而不发送；其他动作只记录描述，并假设分析通过、hold 结束、hook 成功。
+// 发布成功后再模拟一轮让 stable 跟进到新版本，停在需要人工处理的阶段时结束
+func (r *RolloutReconciler) planRollout(ctx context.Context, ro *dlv1.Rollout) (*dlv1.RolloutPlan, error) {
+	pc := newPlanClient(r.Client)
+	pr := *r
+	pr.Client = pc
+	sim := ro.DeepCopy()
+	sim.Status.Plan = nil
+
+	// 每一步一轮，加上第一轮之前的准备、提升与 stable 跟进
+	for round := 0; round < len(sim.Spec.Strategy.Steps)+3; round++ {
+		pc.step, pc.message = nil, "record the pod template as a revision"
+		wl, tp, pending, err := pr.prepare(ctx, sim)
+		if err != nil {
+			return nil, err
+		}
+		last := sim.Status.Phase == dlv1.PhaseSucceeded
+		if err := pc.record(ctx, pr.actions(sim, sim.DeepCopy(), wl, tp, pending, time.Now())); err != nil {
+			return nil, err
+		}
+		settleRevision(sim)
+		switch sim.Status.Phase {
+		case dlv1.PhaseRolledBack, dlv1.PhaseFailed, dlv1.PhasePaused:
+			last = true
+		}
+		if last {
+			break
+		}
+	}
+	return &dlv1.RolloutPlan{Revision: sim.Status.CanaryRevision, Actions: pc.actions}, nil
+}
+
+// record 按顺序记录 actions：写动作对 planClient 执行，返回 done=false 时本轮结束；其他动作记录描述后假设成功
+func (c *planClient) record(ctx context.Context, actions []action) error {
+	for _, a := range actions {
+		c.step, c.message = a.step, a.message
+		if a.write {
+			if _, done, err := a.run(ctx); err != nil || !done {
+				return err
+			}
+			continue
+		}
+		for _, p := range a.plan {
+			c.add(p.Type, p.Target, p.Message)
+		}
+		if a.assume != nil {
+			a.assume()
+		}
+	}
+	return nil
+}
+
+// analysisSummary 描述每一步执行的分析检查
+func analysisSummary(ro *dlv1.Rollout) string {
+	a := ro.Spec.Analysis
+	checks := make([]string, 0, 1+len(a.Metrics)+len(a.SLOs))
+	checks = append(checks, "canary readiness")
+	for _, m := range a.Metrics {
+		op := "<"
+		if m.Compare == dlv1.CompareGT {
+			op = ">"
+		}
+		checks = append(checks, fmt.Sprintf("metric %s %s %s", m.Name, op, m.Threshold))
+	}
+	for _, o := range a.SLOs {
+		maxBurn := o.MaxBurnRate
+		if maxBurn == "" {
+			maxBurn = dlv1.DefaultMaxBurnRate
+		}
+		checks = append(checks, fmt.Sprintf("SLO %s (%s %s%%) burn rate <= %s", o.Name, o.Type, o.Objective, maxBurn))
+	}
+	return fmt.Sprintf("check %s every %s; pass after %d consecutive successes, fail after %d consecutive failures (failurePolicy %s)",
+		strings.Join(checks, ", "), analysisInterval(ro), max(a.SuccessThreshold, 1), max(a.FailureThreshold, 1), ro.Spec.EffectiveFailurePolicy())
+}
+
+type planKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+// planClient 计划模式使用的 client：写操作只记录为计划动作，并保存在内存中，
+// 之后的读操作先返回本次计划中写入的对象，再回落到集群；List 不包含计划中的写入
+type planClient struct {
+	client.Client
+	objects map[planKey]client.Object
+	deleted map[planKey]bool
+	actions []dlv1.PlannedAction
+	// step/message 描述当前模拟到的阶段，附加到记录的写操作上
+	step    *int32
+	message string
+}
+
+func newPlanClient(c client.Client) *planClient {
+	return &planClient{Client: c, objects: map[planKey]client.Object{}, deleted: map[planKey]bool{}}
+}
+
+func (c *planClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	k := planKey{gvk: gvk, key: key}
+	if c.deleted[k] {
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+	if stored, ok := c.objects[k]; ok {
+		return copyObject(stored, obj)
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *planClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	return c.write(dlv1.PlanCreate, obj)
+}
+
+func (c *planClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	return c.write(dlv1.PlanUpdate, obj)
+}
+
+func (c *planClient) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	return c.write(dlv1.PlanUpdate, obj)
+}
+
+func (c *planClient) Delete(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	k := planKey{gvk: gvk, key: client.ObjectKeyFromObject(obj)}
+	delete(c.objects, k)
+	c.deleted[k] = true
+	c.add(dlv1.PlanDelete, gvk.Kind+"/"+obj.GetName(), c.message)
+	return nil
+}
+
+func (c *planClient) DeleteAllOf(_ context.Context, obj client.Object, _ ...client.DeleteAllOfOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	c.add(dlv1.PlanDelete, gvk.Kind+"/*", c.message)
+	return nil
+}
+
+// Status 计划模式下不写任何对象的状态
+func (c *planClient) Status() client.SubResourceWriter {
+	return planStatusWriter{}
+}
+
+func (c *planClient) write(typ dlv1.PlanActionType, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	k := planKey{gvk: gvk, key: client.ObjectKeyFromObject(obj)}
+	delete(c.deleted, k)
+	c.objects[k] = obj.DeepCopyObject().(client.Object)
+	c.add(typ, gvk.Kind+"/"+obj.GetName(), c.message)
+	return nil
+}
+
+// add 记录一个计划动作；之后各轮重复出现的同一动作（如等待 spec.paused）只记录一次
+func (c *planClient) add(typ dlv1.PlanActionType, target, message string) {
+	a := dlv1.PlannedAction{Type: typ, Target: target, Message: message}
+	if c.step != nil {
+		step := *c.step
+		a.Step = &step
+	}
+	for _, old := range c.actions {
+		if equality.Semantic.DeepEqual(old, a) {
+			return
+		}
+	}
+	c.actions = append(c.actions, a)
+}
+
+// copyObject 把计划中保存的对象复制到调用方传入的对象中，typed 与 unstructured 对象都适用
+func copyObject(src, dst client.Object) error {
+	raw, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(dst).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return json.Unmarshal(raw, dst)
+}
+
+type planStatusWriter struct{}
+
+func (planStatusWriter) Create(context.Context, client.Object, client.Object, ...client.SubResourceCreateOption) error {
+	return nil
+}
+
+func (planStatusWriter) Update(context.Context, client.Object, ...client.SubResourceUpdateOption) error {
+	return nil
+}
+
+func (planStatusWriter) Patch(context.Context, client.Object, client.Patch, ...client.SubResourcePatchOption) error {
+	return nil
+}
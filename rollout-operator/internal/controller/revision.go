@@ -0,0 +1,252 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/traffic"
+)
+
+// RolloutNameLabel 标记 ControllerRevision 所属的 Rollout
+const RolloutNameLabel = "delivery.example.com/rollout"
+
+// defaultRevisionHistoryLimit spec.revisionHistoryLimit 未设置时保留的版本数
+const defaultRevisionHistoryLimit = 10
+
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
+
+// revisionTemplate 当前 spec 对应的 Pod 模板快照，不含 track 标签，stable/canary 共用
+func revisionTemplate(ro *dlv1.Rollout) corev1.PodTemplateSpec {
+	tpl := podTemplate(ro, "")
+	delete(tpl.Labels, "track")
+	return tpl
+}
+
+// templateHash 模板内容的短哈希，用作 ControllerRevision 名称后缀
+func templateHash(tpl *corev1.PodTemplateSpec) (string, error) {
+	raw, err := json.Marshal(tpl)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(raw)
+	return rand.SafeEncodeString(fmt.Sprint(h.Sum32())), nil
+}
+
+// syncRevision 为当前模板保存快照并登记到 status.revisions。
+// 模板变化时把它设为 canary 版本并从第 0 步开始新一轮发布；kubectl-rollout undo 回到的旧模板按倒序步骤发布
+func (r *RolloutReconciler) syncRevision(ctx context.Context, ro *dlv1.Rollout) error {
+	lg := log.FromContext(ctx)
+	tpl := revisionTemplate(ro)
+	hash, err := templateHash(&tpl)
+	if err != nil {
+		return err
+	}
+	name := ro.Name + "-" + hash
+	if ro.Status.CanaryRevision == name {
+		return nil
+	}
+
+	raw, err := json.Marshal(&tpl)
+	if err != nil {
+		return err
+	}
+	next := int64(1)
+	for _, rec := range ro.Status.Revisions {
+		if rec.Revision >= next {
+			next = rec.Revision + 1
+		}
+	}
+	var cr appsv1.ControllerRevision
+	err = r.Get(ctx, client.ObjectKey{Namespace: ro.Namespace, Name: name}, &cr)
+	switch {
+	case apierrors.IsNotFound(err):
+		cr = appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ro.Namespace,
+				Labels: map[string]string{
+					"app":                  ro.Spec.TargetRef.Name,
+					RolloutNameLabel:       ro.Name,
+					traffic.ManagedByLabel: traffic.ManagedByValue,
+				},
+			},
+			Data:     runtime.RawExtension{Raw: raw},
+			Revision: next,
+		}
+		if err := controllerutil.SetControllerReference(ro, &cr, r.Scheme); err != nil {
+			return err
+		}
+		lg.Info("Creating ControllerRevision", "name", name, "revision", next)
+		if err := r.Create(ctx, &cr); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case cr.Revision != next:
+		// 回到已有快照（undo），沿用快照并分配新序号
+		lg.Info("Reusing ControllerRevision", "name", name, "revision", next)
+		cr.Revision = next
+		if err := r.Update(ctx, &cr); err != nil {
+			return err
+		}
+	}
+
+	recs := ro.Status.Revisions[:0]
+	for _, rec := range ro.Status.Revisions {
+		if rec.Name != name {
+			recs = append(recs, rec)
+		}
+	}
+	ro.Status.Revisions = append(recs, dlv1.RevisionRecord{Name: name, Revision: next, CreatedAt: metav1.Now()})
+
+	first := ro.Status.CanaryRevision == "" && ro.Status.StableRevision == ""
+	undo := undoStatus(ro, name)
+	ro.Status.CanaryRevision = name
+	if first {
+		// 首次登记（新建或升级前创建的 Rollout）：没有可对比的旧版本，保持当前进度
+		ro.Status.StableRevision = name
+	} else {
+		lg.Info("New revision detected, restarting rollout from step 0", "revision", name, "stableRevision", ro.Status.StableRevision, "undo", undo)
+		ro.Status.Phase = ""
+		ro.Status.StepIndex = 0
+		ro.Status.Steps = nil
+		ro.Status.Abort = false
+		ro.Status.Hooks = nil
+		ro.Status.Undo = undo
+		for _, t := range []string{dlv1.ConditionAnalysisFailed, dlv1.ConditionAborted, dlv1.ConditionTrafficDrift, dlv1.ConditionHookFailed} {
+			meta.RemoveStatusCondition(&ro.Status.Conditions, t)
+		}
+	}
+	return r.pruneRevisions(ctx, ro)
+}
+
+// undoStatus 模板被 kubectl-rollout undo 改回 name 时返回倒序发布的起点：此刻 canary 工作负载上的版本与 canary 权重。
+// StatefulSet 只有一个 Pod 模板，无法让两个版本各自承载流量，undo 仍按正常方向发布
+func undoStatus(ro *dlv1.Rollout, name string) *dlv1.UndoStatus {
+	if ro.Annotations[dlv1.UndoAnnotation] != name || ro.Spec.TargetRef.Kind != dlv1.KindDeployment {
+		return nil
+	}
+	return &dlv1.UndoStatus{FromRevision: canaryWorkloadRevision(ro), FromWeight: currentWeight(ro)}
+}
+
+// canaryWorkloadRevision canary 工作负载当前运行的版本，与 ensureWorkloads 的选择一致
+func canaryWorkloadRevision(ro *dlv1.Rollout) string {
+	switch {
+	case ro.Status.Undo != nil && ro.Status.Phase != dlv1.PhaseSucceeded:
+		return ro.Status.Undo.FromRevision
+	case ro.Status.Undo == nil && newRevisionPending(ro):
+		return ro.Status.StableRevision
+	}
+	return ro.Status.CanaryRevision
+}
+
+// newRevisionPending 新版本已登记但还没开始第一步（包括 retry 后重新开始）。
+// 此时流量应全部在 stable：上一轮提升后主入口可能仍指向 canary，而 canary 即将换成未经验证的新模板
+func newRevisionPending(ro *dlv1.Rollout) bool {
+	if ro.Status.CanaryRevision == ro.Status.StableRevision || rolloutStarted(ro) {
+		return false
+	}
+	return ro.Status.Phase == "" || ro.Status.Phase == dlv1.PhaseProgressing
+}
+
+// settleRevision 把本轮结果记到 canary 版本上；成功后 canary 版本成为新的 stable
+func settleRevision(ro *dlv1.Rollout) {
+	for i := range ro.Status.Revisions {
+		if ro.Status.Revisions[i].Name == ro.Status.CanaryRevision {
+			ro.Status.Revisions[i].Phase = ro.Status.Phase
+		}
+	}
+	if ro.Status.Phase == dlv1.PhaseSucceeded {
+		ro.Status.StableRevision = ro.Status.CanaryRevision
+	}
+}
+
+// pruneRevisions 超出 revisionHistoryLimit 时按序号从旧到新删除，当前 stable/canary 版本与倒序发布的起点版本保留
+func (r *RolloutReconciler) pruneRevisions(ctx context.Context, ro *dlv1.Rollout) error {
+	limit := defaultRevisionHistoryLimit
+	if ro.Spec.RevisionHistoryLimit != nil {
+		limit = int(*ro.Spec.RevisionHistoryLimit)
+	}
+	recs := ro.Status.Revisions
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Revision < recs[j].Revision })
+	excess := len(recs) - limit
+	kept := recs[:0]
+	for _, rec := range recs {
+		if excess > 0 && rec.Name != ro.Status.StableRevision && rec.Name != ro.Status.CanaryRevision && !undoSource(ro, rec.Name) {
+			cr := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: rec.Name, Namespace: ro.Namespace}}
+			if err := r.Delete(ctx, cr); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			log.FromContext(ctx).Info("Pruned ControllerRevision", "name", rec.Name, "revision", rec.Revision)
+			excess--
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	ro.Status.Revisions = kept
+	return nil
+}
+
+// undoSource name 是否是倒序发布中仍留在 canary 工作负载上的版本
+func undoSource(ro *dlv1.Rollout, name string) bool {
+	return ro.Status.Undo != nil && ro.Status.Undo.FromRevision == name
+}
+
+// workloadTemplate stable/canary Deployment 应使用的 Pod 模板：
+// canary 始终是当前 spec，stable 取 stableRevision 对应的快照
+func (r *RolloutReconciler) workloadTemplate(ctx context.Context, ro *dlv1.Rollout, track string) (corev1.PodTemplateSpec, error) {
+	if track != "stable" || ro.Status.StableRevision == "" || ro.Status.StableRevision == ro.Status.CanaryRevision {
+		return podTemplate(ro, track), nil
+	}
+	return r.revisionPodTemplate(ctx, ro, ro.Status.StableRevision, track)
+}
+
+// revisionPodTemplate 读取 ControllerRevision 中保存的模板快照，补上 track 对应的标签
+func (r *RolloutReconciler) revisionPodTemplate(ctx context.Context, ro *dlv1.Rollout, name, track string) (corev1.PodTemplateSpec, error) {
+	var cr appsv1.ControllerRevision
+	if err := r.Get(ctx, client.ObjectKey{Namespace: ro.Namespace, Name: name}, &cr); err != nil {
+		return corev1.PodTemplateSpec{}, fmt.Errorf("load revision %s: %w", name, err)
+	}
+	var tpl corev1.PodTemplateSpec
+	if err := json.Unmarshal(cr.Data.Raw, &tpl); err != nil {
+		return corev1.PodTemplateSpec{}, fmt.Errorf("decode revision %s: %w", name, err)
+	}
+	if tpl.Labels == nil {
+		tpl.Labels = map[string]string{}
+	}
+	tpl.Labels["app"] = ro.Spec.TargetRef.Name
+	tpl.Labels["track"] = track
+	return tpl, nil
+}
@@ -65,39 +65,91 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	dlv1 "github.com/ormasia/rollout-operator/api/v1alpha1"
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
 	"github.com/ormasia/rollout-operator/pkg/analysis"
+	"github.com/ormasia/rollout-operator/pkg/metrics"
+	"github.com/ormasia/rollout-operator/pkg/notify"
 	"github.com/ormasia/rollout-operator/pkg/traffic"
 )
 
+// trafficHostIndex Rollout 按 spec.traffic.host 建立的索引，用于从 Ingress 找回 Rollout
+const trafficHostIndex = "spec.traffic.host"
+
+// trafficCleanupFinalizer 保证 Rollout 删除前先收回流量并删除 provider 创建的资源
+const trafficCleanupFinalizer = "delivery.example.com/traffic-cleanup"
+
+// rolloutPhases 所有阶段，用于 rollout_phase 指标置 0/1
+var rolloutPhases = []string{
+	string(dlv1.PhaseIdle),
+	string(dlv1.PhaseProgressing),
+	string(dlv1.PhaseAnalyzing),
+	string(dlv1.PhaseSucceeded),
+	string(dlv1.PhaseFailed),
+	string(dlv1.PhaseRolledBack),
+	string(dlv1.PhasePaused),
+}
+
 type RolloutReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	Traffic  traffic.Provider
+	Scheme *runtime.Scheme
+	// Traffic 按 Rollout 所在 namespace 返回流量层实现
+	Traffic  traffic.Factory
 	Analysis analysis.Engine
+	// Notifier 为空时不发送阶段变化通知
+	Notifier *notify.Dispatcher
+	// NotificationConfigMap 集群级通知目标所在的 ConfigMap；Name 为空表示不使用
+	NotificationConfigMap types.NamespacedName
+	// Recorder 为空时不记录事件
+	Recorder record.EventRecorder
+	// NamespaceSelector 只处理标签命中的 namespace 中的 Rollout；为空时处理所有 namespace
+	NamespaceSelector labels.Selector
+	// HookClient 执行 HTTP hook 的 client，为空时使用 http.DefaultClient；单次请求的超时为 httpHookAttemptTimeout
+	HookClient *http.Client
+	// DependencyReader 读取 spec.dependsOn 中的 Rollout，为空时使用 Client；--watch-namespaces 限制缓存时设为 API reader，
+	// 这样其他 namespace 的依赖也能读到，但它们的变化不会触发调和，等待期间按 dependencyRecheckInterval 重新检查
+	DependencyReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=delivery.example.com,resources=rollouts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=delivery.example.com,resources=rollouts/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=delivery.example.com,resources=rollouts/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=split.smi-spec.io,resources=trafficsplits,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
-func (r *RolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *RolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
 	lg := log.FromContext(ctx)
 
 	lg.Info("Reconciling Rollout", "namespace", req.Namespace, "name", req.Name)
@@ -105,269 +157,1047 @@ func (r *RolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err := r.Get(ctx, req.NamespacedName, &ro); err != nil {
 		if apierrors.IsNotFound(err) {
 			lg.Info("Rollout resource not found. Ignoring since object must be deleted")
+			metrics.Forget(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		lg.Error(err, "Failed to get Rollout")
 		return ctrl.Result{}, err
 	}
 
-	// 确保 stable/canary 资源存在
-	if err := r.ensureWorkloads(ctx, &ro); err != nil {
-		lg.Error(err, "Failed to ensure workloads")
+	if !ro.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, &ro)
+	}
+	// 删除仍然照常收尾，避免 namespace 移出选择范围后 finalizer 无人处理
+	if selected, err := r.namespaceSelected(ctx, ro.Namespace); err != nil {
+		lg.Error(err, "Failed to check namespace selector")
 		return ctrl.Result{}, err
+	} else if !selected {
+		lg.Info("Namespace not selected by namespace selector, skipping")
+		return ctrl.Result{}, nil
+	}
+	// 计划模式：只计算动作序列，不触碰任何资源
+	if ro.Spec.DryRun {
+		return ctrl.Result{}, r.reconcilePlan(ctx, &ro)
+	}
+	if controllerutil.AddFinalizer(&ro, trafficCleanupFinalizer) {
+		lg.Info("Adding traffic cleanup finalizer")
+		if err := r.Update(ctx, &ro); err != nil {
+			lg.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
 	}
 
-	// 初始化状态
+	// 调和过程中只修改内存中的状态，结束时基于 base 一次性 patch；
+	// 状态只在对应的流量动作成功后才修改，出错返回时已完成的部分同样会落盘。
+	// 阶段成功落盘且发生变化时刷新指标并发送通知
+	base := ro.DeepCopy()
+	oldPhase := ro.Status.Phase
+	// 关闭 dryRun 后按计划执行，计划本身不再保留
+	ro.Status.Plan = nil
+	defer func() {
+		settleRevision(&ro)
+		if err := r.patchStatus(ctx, base, &ro); err != nil {
+			lg.Error(err, "Failed to patch rollout status")
+			if retErr == nil {
+				retErr = err
+			}
+			return
+		}
+		metrics.SetPhase(ro.Namespace, ro.Name, string(ro.Status.Phase), rolloutPhases)
+		if ro.Status.Phase != oldPhase {
+			r.notifyPhaseChange(ctx, &ro, oldPhase)
+		}
+	}()
+
+	wl, tp, pending, err := r.prepare(ctx, &ro)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// 先按当前状态列出本次调和的全部动作，再依次执行；计划模式列出的是同一份动作
+	return r.execute(ctx, r.actions(&ro, base, wl, tp, pending, time.Now()))
+}
+
+// action 一次调和中按顺序执行的一个动作
+type action struct {
+	// step 动作所属的步骤；为空表示第一步之前或全部步骤之后
+	step *int32
+	// write 动作只通过 client、工作负载与流量层写资源并修改内存中的状态；
+	// 计划模式下对 planClient 执行，写入的对象以 message 为说明记录为计划动作
+	write   bool
+	message string
+	// plan 计划模式下非写动作记录的动作；assume 把状态推进到动作成功之后，例如分析通过、hook 成功
+	plan   []dlv1.PlannedAction
+	assume func()
+	// run 执行动作；done=false 时本次调和到此结束并返回 res
+	run func(ctx context.Context) (res ctrl.Result, done bool, err error)
+}
+
+// execute 依次执行 actions，遇到返回 done=false 的动作时结束
+func (r *RolloutReconciler) execute(ctx context.Context, actions []action) (ctrl.Result, error) {
+	for _, a := range actions {
+		if a.run == nil {
+			continue
+		}
+		res, done, err := a.run(ctx)
+		if err != nil || !done {
+			return res, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// prepare 登记当前模板对应的版本（模板变化时从第 0 步开始新一轮发布），解析工作负载与流量层实现，并初始化状态。
+// 之后的动作都取决于这里写入的状态，所以它先于 actions 执行
+func (r *RolloutReconciler) prepare(ctx context.Context, ro *dlv1.Rollout) (workload, traffic.Provider, bool, error) {
+	lg := log.FromContext(ctx)
+	if err := r.syncRevision(ctx, ro); err != nil {
+		lg.Error(err, "Failed to sync revision")
+		return nil, nil, false, err
+	}
+	wl, err := r.workloadFor(ro)
+	if err != nil {
+		lg.Error(err, "Failed to resolve workload")
+		return nil, nil, false, err
+	}
+	tp, err := r.trafficProvider(ctx, ro)
+	if err != nil {
+		lg.Error(err, "Failed to resolve traffic provider")
+		return nil, nil, false, err
+	}
+	pending := newRevisionPending(ro)
 	if ro.Status.Phase == "" {
 		lg.Info("Initialize rollout status")
 		ro.Status.Phase = dlv1.PhaseProgressing
 		ro.Status.StepIndex = 0
-		if err := r.Status().Update(ctx, &ro); err != nil {
-			lg.Error(err, "Failed to update rollout status")
-			return ctrl.Result{}, err
-		}
 	}
+	return wl, tp, pending, nil
+}
 
-	switch ro.Spec.Strategy.Type {
-	case dlv1.BlueGreen:
-		lg.Info("BlueGreen strategy: promoting canary to 100%", "host", ro.Spec.Traffic.Host)
-		if err := r.Traffic.Promote(ctx, ro.Spec.Traffic.Host, ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService); err != nil {
-			lg.Error(err, "Failed to promote traffic")
-			return ctrl.Result{}, err
-		}
-		lg.Info("BlueGreen promoted, marking Succeeded")
-		ro.Status.Phase = dlv1.PhaseSucceeded
-		return r.updateStatus(ctx, &ro)
+// actions 按当前状态列出本次调和要执行的动作。条件在列出时判断；
+// 分析结果、hold 与 hook 是否结束、发布窗口等只能在执行时知道的部分由动作自己判断，不满足时结束本次调和
+func (r *RolloutReconciler) actions(ro, base *dlv1.Rollout, wl workload, tp traffic.Provider, pending bool, now time.Time) []action {
+	var actions []action
+	add := func(a ...action) { actions = append(actions, a...) }
 
-	default: // Canary
-		steps := ro.Spec.Strategy.Steps
-		idx := int(ro.Status.StepIndex)
-		if idx >= len(steps) {
-			lg.Info("Canary finished all steps, promoting", "host", ro.Spec.Traffic.Host)
-			if err := r.Traffic.Promote(ctx, ro.Spec.Traffic.Host, ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService); err != nil {
-				lg.Error(err, "Failed to promote traffic")
-				return ctrl.Result{}, err
+	// 新版本开始之前先把流量切回 stable（倒序发布时留在 undo 开始时的权重）；
+	// 依赖、发布窗口与 preRollout hook 都通过之前新版本不进入工作负载
+	if pending {
+		add(action{write: true, message: "send traffic back to stable before the new revision starts", run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			if err := setTrafficWeight(ctx, tp, ro, ro.RollbackWeight()); err != nil {
+				log.FromContext(ctx).Error(err, "Failed to reset traffic before the new revision")
+				return ctrl.Result{}, false, err
 			}
-			lg.Info("Canary promoted, marking Succeeded")
-			ro.Status.Phase = dlv1.PhaseSucceeded
-			return r.updateStatus(ctx, &ro)
+			return ctrl.Result{}, true, nil
+		}})
+	}
+	msg := "prepare the stable and canary workloads"
+	if ro.Status.Phase == dlv1.PhaseSucceeded {
+		msg = "move stable to the promoted revision"
+	}
+	add(action{write: true, message: msg, run: func(ctx context.Context) (ctrl.Result, bool, error) {
+		if err := r.ensureWorkloads(ctx, ro, wl, pending); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to ensure workloads")
+			return ctrl.Result{}, false, err
 		}
-		step := steps[idx]
-		lg.Info("Canary step", "index", idx, "weight", step.Weight, "holdSeconds", step.HoldSeconds)
+		return ctrl.Result{}, true, nil
+	}})
 
-		// 调整权重
-		if err := r.Traffic.SetWeight(ctx, ro.Spec.Traffic.Host, ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService, step.Weight); err != nil {
-			lg.Error(err, "Failed to set traffic weight")
-			return ctrl.Result{}, err
-		}
-		lg.Info("Traffic weight set", "host", ro.Spec.Traffic.Host, "weight", step.Weight)
-		ro.Status.Phase = dlv1.PhaseAnalyzing
-		if err := r.Status().Update(ctx, &ro); err != nil {
-			lg.Error(err, "Failed to update rollout status")
-			return ctrl.Result{}, err
+	// 用户请求中止：流量切回 stable
+	if ro.Status.Abort && (ro.Status.Phase.InProgress() || ro.Status.Phase == dlv1.PhaseFailed) {
+		add(r.abortActions(tp, ro, "UserRequested", fmt.Sprintf("aborted at step %d", ro.Status.StepIndex))...)
+		return actions
+	}
+
+	// 依赖回滚时一并中止；依赖全部 Succeeded 之前不开始第一步
+	if len(ro.Spec.DependsOn) > 0 && ro.Status.Phase.InProgress() {
+		a := action{run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			state, msg, err := r.syncDependencies(ctx, ro)
+			if err != nil {
+				return ctrl.Result{}, false, err
+			}
+			switch {
+			case state == dependencyRolledBack:
+				res, err := r.abort(ctx, tp, ro, "DependencyRolledBack", msg)
+				return res, false, err
+			case state == dependenciesPending && !rolloutStarted(ro):
+				// 新版本此时还没进入 canary，流量也已切回 stable
+				log.FromContext(ctx).Info("Waiting for dependencies before the first step", "reason", msg)
+				if r.DependencyReader != nil {
+					return ctrl.Result{RequeueAfter: dependencyRecheckInterval}, false, nil
+				}
+				return ctrl.Result{}, false, nil
+			}
+			return ctrl.Result{}, true, nil
+		}}
+		if !rolloutStarted(ro) {
+			var deps []string
+			for _, key := range dependencyKeys(ro) {
+				deps = append(deps, key.String())
+			}
+			a.plan = []dlv1.PlannedAction{{Type: dlv1.PlanWait, Message: "wait for dependencies to succeed: " + strings.Join(deps, ", ")}}
 		}
+		add(a)
+	}
 
-		// 调用分析引擎，检查本次 Canary 对应的 Deployment 是否就绪
-		lg.Info("Evaluating canary readiness", "deployment", ro.Name+"-canary", "namespace", ro.Namespace)
-		res, err := r.Analysis.Evaluate(ctx, analysis.Spec{}, map[string]string{
-			"app":        ro.Spec.TargetRef.Name,
-			"deployment": ro.Name + "-canary",
-			"namespace":  ro.Namespace,
+	// 流量层被手工修改时恢复到当前步骤应有的权重，本次不推进步骤
+	add(action{run: func(ctx context.Context) (ctrl.Result, bool, error) {
+		drifted, err := r.restoreDriftedTraffic(ctx, tp, base, ro)
+		return ctrl.Result{}, err == nil && !drifted, err
+	}})
+
+	if ro.Spec.Paused && ro.Status.Phase.InProgress() {
+		add(action{
+			plan: []dlv1.PlannedAction{{Type: dlv1.PlanWait, Message: "wait until spec.paused is cleared"}},
+			run: func(ctx context.Context) (ctrl.Result, bool, error) {
+				log.FromContext(ctx).Info("Rollout paused by spec.paused, holding current step", "stepIndex", ro.Status.StepIndex)
+				return ctrl.Result{}, false, nil
+			},
 		})
-		if err != nil {
-			lg.Error(err, "Failed to evaluate analysis")
-			return ctrl.Result{}, err
+	}
+
+	// 已回滚、已失败或暂停等待人工处理的发布不再自动推进；回滚后的 hook 仍需执行完
+	switch ro.Status.Phase {
+	case dlv1.PhaseRolledBack, dlv1.PhaseFailed, dlv1.PhasePaused:
+		rolledBack := ro.Status.Phase == dlv1.PhaseRolledBack
+		add(action{
+			plan: []dlv1.PlannedAction{{Type: dlv1.PlanWait, Message: fmt.Sprintf("rollout is %s; waiting for retry, promote or a new template", ro.Status.Phase)}},
+			run: func(ctx context.Context) (ctrl.Result, bool, error) {
+				log.FromContext(ctx).Info("Rollout halted, waiting for manual action", "phase", ro.Status.Phase)
+				return ctrl.Result{}, rolledBack, nil
+			},
+		})
+		if rolledBack {
+			add(r.followUpHooks(ro, dlv1.HookOnRollback))
 		}
-		lg.Info("Analysis result", "passed", res.Passed, "reason", res.Reason)
+		return actions
+	}
 
-		if res.Passed {
-			lg.Info("Analysis passed, advancing to next step", "nextStepIndex", ro.Status.StepIndex+1)
-			ro.Status.StepIndex++
-			ro.Status.Phase = dlv1.PhaseProgressing
-			if err := r.Status().Update(ctx, &ro); err != nil {
-				lg.Error(err, "Failed to update rollout status")
-				return ctrl.Result{}, err
+	// 窗口外不再调整权重；Analyzing 阶段仍按当前权重继续分析
+	if ro.Status.Phase == dlv1.PhaseProgressing {
+		a := action{run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			wait, err := r.waitForSchedule(ctx, ro, now)
+			if err != nil {
+				log.FromContext(ctx).Error(err, "Failed to evaluate rollout schedule")
+				return ctrl.Result{}, false, err
 			}
-			lg.Info("Requeueing after hold seconds", "seconds", step.HoldSeconds)
-			return ctrl.Result{RequeueAfter: time.Duration(step.HoldSeconds) * time.Second}, nil
-		} else {
-			if ro.Spec.RollbackOnFailure {
-				lg.Info("Analysis failed, rollback enabled -> resetting traffic")
-				_ = r.Traffic.Reset(ctx, ro.Spec.Traffic.Host, ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService)
-				ro.Status.Phase = dlv1.PhaseRolledBack
-			} else {
-				lg.Info("Analysis failed, rollback disabled -> marking Failed")
-				ro.Status.Phase = dlv1.PhaseFailed
+			return ctrl.Result{RequeueAfter: wait}, wait <= 0, nil
+		}}
+		if ro.Spec.Schedule != nil {
+			a.plan = []dlv1.PlannedAction{{Type: dlv1.PlanWait, Message: "steps only advance inside the spec.schedule windows"}}
+		}
+		add(a)
+	}
+
+	// 新模板进入 canary、第一步调整权重之前执行 preRollout hook
+	if ro.Status.Phase == dlv1.PhaseProgressing && !rolloutStarted(ro) {
+		add(r.hookGate(tp, ro, dlv1.HookPreRollout))
+	}
+
+	// 发布前的检查都已通过，新模板进入 canary
+	if pending {
+		add(action{write: true, message: "move the canary workload to the new revision", run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			lg := log.FromContext(ctx)
+			lg.Info("Releasing the new revision to the canary workload", "revision", ro.Status.CanaryRevision)
+			if err := r.ensureWorkloads(ctx, ro, wl, false); err != nil {
+				lg.Error(err, "Failed to update the canary workload")
+				return ctrl.Result{}, false, err
 			}
-			return r.updateStatus(ctx, &ro)
+			return ctrl.Result{}, true, nil
+		}})
+	}
+
+	if ro.Spec.Strategy.Type == dlv1.BlueGreen {
+		return append(actions, r.promoteActions(tp, ro, wl, now)...)
+	}
+
+	// Canary；倒序发布时为倒序排列的步骤
+	steps := ro.CanarySteps()
+	idx := ro.Status.StepIndex
+	// 上一步的 hold 未结束时只等待剩余时间，重启或无关事件触发的调和不会提前推进；
+	// 带 SLO 的步骤在 hold 期间继续按间隔检查，hold 结束时再检查一次
+	if held := heldStep(ro); held != nil && len(ro.Spec.Analysis.SLOs) > 0 {
+		add(action{run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			return r.analyzeHold(ctx, tp, ro, wl, held, now)
+		}})
+	}
+	add(action{run: func(ctx context.Context) (ctrl.Result, bool, error) {
+		if wait := holdRemaining(ro, now); wait > 0 {
+			log.FromContext(ctx).Info("Holding before next step", "stepIndex", idx, "remaining", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, false, nil
 		}
+		return ctrl.Result{}, true, nil
+	}})
+	if int(idx) >= len(steps) {
+		return append(actions, r.promoteActions(tp, ro, wl, now)...)
 	}
+	return append(actions, r.stepActions(tp, ro, wl, idx, steps[idx], now)...)
 }
 
-func (r *RolloutReconciler) ensureWorkloads(ctx context.Context, ro *dlv1.Rollout) error {
+// stepActions 下发 idx 步骤的权重并按间隔分析，分析通过后 hold 再进入下一步
+func (r *RolloutReconciler) stepActions(tp traffic.Provider, ro *dlv1.Rollout, wl workload, idx int32, step dlv1.RolloutStep, now time.Time) []action {
+	started := ro.Status.StepStatus(idx) != nil
+	plan := []dlv1.PlannedAction{{Type: dlv1.PlanAnalyze, Message: analysisSummary(ro)}}
+	if step.HoldSeconds > 0 {
+		msg := fmt.Sprintf("hold %s at %d%%", time.Duration(step.HoldSeconds)*time.Second, step.Weight)
+		if len(ro.Spec.Analysis.SLOs) > 0 {
+			msg += fmt.Sprintf(", checking the SLOs every %s", analysisInterval(ro))
+		}
+		plan = append(plan, dlv1.PlannedAction{Type: dlv1.PlanHold, Message: msg})
+	}
+	return []action{{
+		step: &idx, write: true, message: fmt.Sprintf("set the canary weight to %d%%", step.Weight),
+		// 先调整工作负载中新版本的比例，再调整流量权重；重复下发同一权重是幂等的，上次调和在落盘前中断时这里会重做
+		run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			lg := log.FromContext(ctx)
+			lg.Info("Canary step", "index", idx, "weight", step.Weight, "holdSeconds", step.HoldSeconds)
+			if err := wl.SetWeight(ctx, ro, step.Weight); err != nil {
+				lg.Error(err, "Failed to set workload weight")
+				return ctrl.Result{}, false, err
+			}
+			if err := tp.SetWeight(ctx, ro.Spec.Traffic.Host, ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService, step.Weight); err != nil {
+				lg.Error(err, "Failed to set traffic weight")
+				return ctrl.Result{}, false, err
+			}
+			lg.Info("Traffic weight set", "host", ro.Spec.Traffic.Host, "weight", step.Weight)
+			if !started {
+				ro.Status.Steps = append(ro.Status.Steps, dlv1.StepStatus{
+					Index:     idx,
+					Weight:    step.Weight,
+					StartedAt: metav1.NewTime(now),
+				})
+			}
+			ro.Status.Phase = dlv1.PhaseAnalyzing
+			return ctrl.Result{}, true, nil
+		},
+	}, {
+		run: func(context.Context) (ctrl.Result, bool, error) {
+			if !started {
+				observeStepEnd(ro, idx-1, now)
+			}
+			metrics.SetStep(ro.Namespace, ro.Name, idx, step.Weight)
+			return ctrl.Result{}, true, nil
+		},
+	}, {
+		step: &idx, plan: plan,
+		assume: func() { advanceStep(ro, ro.Status.StepStatus(idx), step, now) },
+		run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			res, err := r.analyzeStep(ctx, tp, ro, wl, step, now)
+			return res, false, err
+		},
+	}}
+}
+
+// analyzeStep 每 intervalSeconds 分析一次当前步骤；连续通过 successThreshold 次本步骤通过，连续失败 failureThreshold 次本步骤失败
+func (r *RolloutReconciler) analyzeStep(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, wl workload, step dlv1.RolloutStep, now time.Time) (ctrl.Result, error) {
 	lg := log.FromContext(ctx)
-	for _, track := range []string{"stable", "canary"} {
-		depName := ro.Name + "-" + track
-		svcName := ro.Spec.Traffic.StableService
-		if track == "canary" {
-			svcName = ro.Spec.Traffic.CanaryService
-		}
-
-		// 统一的对象标签（用于 kubectl -l 选择器）
-		objLabels := map[string]string{
-			"app":   ro.Spec.TargetRef.Name,
-			"track": track,
-		}
-		lg.Info("Ensuring workload", "deployment", depName, "service", svcName, "labels", objLabels)
-
-		// Deployment
-		var dep appsv1.Deployment
-		if err := r.Get(ctx, client.ObjectKey{Name: depName, Namespace: ro.Namespace}, &dep); err != nil {
-			lg.Info("Deployment status",
-				"name", dep.Name,
-				"replicas", dep.Status.Replicas,
-				"readyReplicas", dep.Status.ReadyReplicas,
-				"availableReplicas", dep.Status.AvailableReplicas)
-			if apierrors.IsNotFound(err) {
-				replicas := int32(2)
-				newDep := appsv1.Deployment{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      depName,
-						Namespace: ro.Namespace,
-						Labels:    map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
-					},
-					Spec: appsv1.DeploymentSpec{
-						Replicas: &replicas,
-						Selector: &metav1.LabelSelector{
-							MatchLabels: map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
-						},
-						Template: corev1.PodTemplateSpec{
-							ObjectMeta: metav1.ObjectMeta{
-								Labels: map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
-							},
-							Spec: corev1.PodSpec{
-								Containers: []corev1.Container{{
-									Name:  ro.Spec.TargetRef.Name,
-									Image: "nginx:1.25",
-									Ports: []corev1.ContainerPort{{ContainerPort: ro.Spec.TargetRef.Port}},
-								}},
-							},
-						},
-					},
-				}
-				// 设置 OwnerReference，方便级联与事件追踪
-				if err := controllerutil.SetControllerReference(ro, &newDep, r.Scheme); err != nil {
-					return err
-				}
-				log.FromContext(ctx).Info("Creating Deployment", "name", depName, "labels", objLabels)
-				if err := r.Create(ctx, &newDep); err != nil {
-					return err
-				}
-			} else {
-				return err
+	st := ro.Status.StepStatus(ro.Status.StepIndex)
+	interval := analysisInterval(ro)
+	if st.AnalyzedAt != nil {
+		if wait := st.AnalyzedAt.Add(interval).Sub(now); wait > 0 {
+			lg.Info("Waiting for next analysis", "stepIndex", st.Index, "remaining", wait.String())
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	// 调用分析引擎，检查本次 Canary 对应的工作负载是否就绪
+	res, err := r.evaluateStep(ctx, ro, wl, st, now)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	switch {
+	case res.Inconclusive:
+	case res.Passed:
+		st.Successes++
+		st.Failures = 0
+	default:
+		st.Failures++
+		st.Successes = 0
+	}
+	lg.Info("Analysis result", "passed", res.Passed, "inconclusive", res.Inconclusive, "reason", res.Reason, "successes", st.Successes, "failures", st.Failures)
+
+	switch {
+	case st.Successes >= max(ro.Spec.Analysis.SuccessThreshold, 1):
+		lg.Info("Analysis passed, advancing to next step", "nextStepIndex", ro.Status.StepIndex+1)
+		hold := advanceStep(ro, st, step, now)
+		lg.Info("Requeueing after hold seconds", "seconds", step.HoldSeconds)
+		return ctrl.Result{RequeueAfter: hold}, nil
+	case st.Failures >= max(ro.Spec.Analysis.FailureThreshold, 1):
+		return r.handleAnalysisFailure(ctx, tp, ro, res.Reason)
+	default:
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+}
+
+// advanceStep st 步骤分析通过：记录 hold 结束时间并进入下一步，返回 hold 时长
+func advanceStep(ro *dlv1.Rollout, st *dlv1.StepStatus, step dlv1.RolloutStep, now time.Time) time.Duration {
+	hold := time.Duration(step.HoldSeconds) * time.Second
+	holdUntil := metav1.NewTime(now.Add(hold))
+	st.HoldUntil = &holdUntil
+	ro.Status.StepIndex++
+	ro.Status.Phase = dlv1.PhaseProgressing
+	return hold
+}
+
+// promoteActions 执行 prePromotion hook 后把流量全部切到新版本（倒序发布时全部切回目标版本），标记 Succeeded 并执行 postPromotion hook
+func (r *RolloutReconciler) promoteActions(tp traffic.Provider, ro *dlv1.Rollout, wl workload, now time.Time) []action {
+	// 已 Succeeded 的 Rollout 每次调和都会重新确认晋级，最后一步的耗时只记录一次
+	succeeded := ro.Status.Phase == dlv1.PhaseSucceeded
+	msg := "promote the canary to 100%"
+	if ro.Status.Undo != nil {
+		msg = "send all traffic to the target revision"
+	}
+	return []action{r.hookGate(tp, ro, dlv1.HookPrePromotion), {
+		write: true, message: msg,
+		run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			lg := log.FromContext(ctx)
+			lg.Info("Promoting", "strategy", ro.Spec.Strategy.Type, "host", ro.Spec.Traffic.Host, "canaryWeight", ro.FinalWeight())
+			if err := wl.SetWeight(ctx, ro, ro.FinalWeight()); err != nil {
+				lg.Error(err, "Failed to promote workload")
+				return ctrl.Result{}, false, err
 			}
-		} else {
-			// 已存在：确保对象标签齐全
-			if dep.Labels == nil {
-				dep.Labels = map[string]string{}
+			if err := setTrafficWeight(ctx, tp, ro, ro.FinalWeight()); err != nil {
+				lg.Error(err, "Failed to promote traffic")
+				return ctrl.Result{}, false, err
 			}
-			changed := false
-			for k, v := range objLabels {
-				if dep.Labels[k] != v {
-					dep.Labels[k] = v
-					changed = true
-				}
+			lg.Info("Promoted, marking Succeeded")
+			ro.Status.Phase = dlv1.PhaseSucceeded
+			return ctrl.Result{}, true, nil
+		},
+	}, {
+		run: func(context.Context) (ctrl.Result, bool, error) {
+			if !succeeded && ro.Spec.Strategy.Type != dlv1.BlueGreen {
+				observeStepEnd(ro, int32(len(ro.CanarySteps()))-1, now)
 			}
-			// 确保已有 Deployment 的 OwnerReference 指向当前 Rollout
-			if !metav1.IsControlledBy(&dep, ro) {
-				if err := controllerutil.SetControllerReference(ro, &dep, r.Scheme); err != nil {
-					log.FromContext(ctx).Info("Skip setting ownerRef for Deployment (already controlled)", "name", depName, "err", err.Error())
-				} else {
-					changed = true
-				}
+			metrics.SetWeight(ro.Namespace, ro.Name, ro.FinalWeight())
+			return ctrl.Result{}, true, nil
+		},
+	}, r.followUpHooks(ro, dlv1.HookPostPromotion)}
+}
+
+// abortActions 把流量切回 stable（倒序发布时回到 undo 开始时的权重）、进入 RolledBack 并执行 onRollback hook
+func (r *RolloutReconciler) abortActions(tp traffic.Provider, ro *dlv1.Rollout, reason, msg string) []action {
+	return []action{{
+		write: true, message: fmt.Sprintf("abort (%s): send traffic back to stable", reason),
+		run: func(ctx context.Context) (ctrl.Result, bool, error) {
+			if err := r.rollBack(ctx, tp, ro, reason, msg); err != nil {
+				return ctrl.Result{}, false, err
 			}
-			if changed {
-				log.FromContext(ctx).Info("Patching Deployment labels", "name", depName, "labels", dep.Labels)
-				if err := r.Update(ctx, &dep); err != nil {
-					return err
-				}
+			return ctrl.Result{}, true, nil
+		},
+	}, {
+		run: func(context.Context) (ctrl.Result, bool, error) {
+			observeRollback(ro)
+			return ctrl.Result{}, true, nil
+		},
+	}, r.followUpHooks(ro, dlv1.HookOnRollback)}
+}
+
+// handleAnalysisFailure 按 failurePolicy 处理分析失败
+func (r *RolloutReconciler) handleAnalysisFailure(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, reason string) (ctrl.Result, error) {
+	lg := log.FromContext(ctx)
+	policy := ro.Spec.EffectiveFailurePolicy()
+	switch policy {
+	case dlv1.FailurePolicyManual:
+		lg.Info("Analysis failed, failure policy Manual -> holding current weight", "stepIndex", ro.Status.StepIndex)
+		ro.Status.Phase = dlv1.PhasePaused
+	case dlv1.FailurePolicyNone:
+		lg.Info("Analysis failed, failure policy None -> marking Failed")
+		ro.Status.Phase = dlv1.PhaseFailed
+	default:
+		lg.Info("Analysis failed, failure policy Auto -> resetting traffic", "canaryWeight", ro.RollbackWeight())
+		if err := setTrafficWeight(ctx, tp, ro, ro.RollbackWeight()); err != nil {
+			lg.Error(err, "Failed to reset traffic")
+			return ctrl.Result{}, err
+		}
+		ro.Status.Phase = dlv1.PhaseRolledBack
+		observeRollback(ro)
+	}
+	meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+		Type:               dlv1.ConditionAnalysisFailed,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: ro.Generation,
+		Reason:             string(policy),
+		Message:            reason,
+	})
+	if ro.Status.Phase == dlv1.PhaseRolledBack {
+		return r.runFollowUpHooks(ctx, ro, dlv1.HookOnRollback)
+	}
+	return ctrl.Result{}, nil
+}
+
+// evaluateStep 以 st 步骤开始至今为窗口调用分析引擎，记录分析时间与结果指标
+func (r *RolloutReconciler) evaluateStep(ctx context.Context, ro *dlv1.Rollout, wl workload, st *dlv1.StepStatus, now time.Time) (analysis.Result, error) {
+	lg := log.FromContext(ctx)
+	analysisLabels := wl.AnalysisLabels(ro)
+	lg.Info("Evaluating canary readiness", "labels", analysisLabels, "stepIndex", st.Index)
+	res, err := r.Analysis.Evaluate(ctx, analysisSpec(ro, st, now), analysisLabels)
+	if err != nil {
+		lg.Error(err, "Failed to evaluate analysis")
+		metrics.ObserveAnalysis(ro.Namespace, ro.Name, metrics.ResultError)
+		return res, err
+	}
+	analyzedAt := metav1.NewTime(now)
+	st.AnalyzedAt = &analyzedAt
+	switch {
+	case res.Inconclusive:
+		metrics.ObserveAnalysis(ro.Namespace, ro.Name, metrics.ResultInconclusive)
+	case res.Passed:
+		metrics.ObserveAnalysis(ro.Namespace, ro.Name, metrics.ResultPassed)
+	default:
+		metrics.ObserveAnalysis(ro.Namespace, ro.Name, metrics.ResultFailed)
+	}
+	return res, nil
+}
+
+// analyzeHold 在 held 步骤 hold 期间每 intervalSeconds 分析一次，hold 结束时若之后还没分析过再分析一次；
+// 连续失败 failureThreshold 次时回到该步骤并按分析失败处理。hold 结束且不再需要分析时返回 done=true
+func (r *RolloutReconciler) analyzeHold(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, wl workload, st *dlv1.StepStatus, now time.Time) (ctrl.Result, bool, error) {
+	lg := log.FromContext(ctx)
+	interval := analysisInterval(ro)
+	ended := !now.Before(st.HoldUntil.Time)
+	var next time.Duration
+	if st.AnalyzedAt != nil {
+		next = st.AnalyzedAt.Add(interval).Sub(now)
+	}
+	due := next <= 0
+	if ended {
+		due = st.AnalyzedAt == nil || st.AnalyzedAt.Before(st.HoldUntil)
+	}
+	if due {
+		res, err := r.evaluateStep(ctx, ro, wl, st, now)
+		if err != nil {
+			return ctrl.Result{}, false, err
+		}
+		switch {
+		case res.Inconclusive:
+		case res.Passed:
+			st.Failures = 0
+		default:
+			st.Failures++
+		}
+		lg.Info("Analysis result during hold", "stepIndex", st.Index, "passed", res.Passed, "inconclusive", res.Inconclusive, "reason", res.Reason, "failures", st.Failures)
+		if st.Failures >= max(ro.Spec.Analysis.FailureThreshold, 1) {
+			ro.Status.StepIndex = st.Index
+			st.HoldUntil = nil
+			res, err := r.handleAnalysisFailure(ctx, tp, ro, res.Reason)
+			return res, false, err
+		}
+		next = interval
+	}
+	if ended {
+		return ctrl.Result{}, true, nil
+	}
+	wait := min(st.HoldUntil.Sub(now), next)
+	lg.Info("Holding before next step", "stepIndex", ro.Status.StepIndex, "nextAnalysis", next.String(), "remaining", st.HoldUntil.Sub(now).String())
+	return ctrl.Result{RequeueAfter: wait}, false, nil
+}
+
+// analysisInterval 两次分析之间的间隔，未设置时使用 webhook 的默认值
+func analysisInterval(ro *dlv1.Rollout) time.Duration {
+	if ro.Spec.Analysis.IntervalSeconds > 0 {
+		return time.Duration(ro.Spec.Analysis.IntervalSeconds) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// analysisSpec 把 spec.analysis.metrics 和 slos 转换为分析引擎的输入，窗口为 st 步骤开始至今
+func analysisSpec(ro *dlv1.Rollout, st *dlv1.StepStatus, now time.Time) analysis.Spec {
+	// 倒序发布时正在放量的目标版本在 stable Service 后面，{{canaryService}} 指向它
+	stableService, canaryService := ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService
+	if ro.Status.Undo != nil {
+		stableService, canaryService = canaryService, stableService
+	}
+	s := analysis.Spec{
+		Vars: map[string]string{
+			"namespace":     ro.Namespace,
+			"rollout":       ro.Name,
+			"stableService": stableService,
+			"canaryService": canaryService,
+		},
+	}
+	if st != nil {
+		s.Window = now.Sub(st.StartedAt.Time)
+	}
+	for _, m := range ro.Spec.Analysis.Metrics {
+		// 取值已由 webhook 校验
+		threshold, _ := strconv.ParseFloat(m.Threshold, 64)
+		s.Metrics = append(s.Metrics, analysis.Metric{
+			Name:      m.Name,
+			Query:     m.PromQL,
+			Threshold: threshold,
+			Compare:   m.Compare,
+		})
+	}
+	for _, o := range ro.Spec.Analysis.SLOs {
+		// 取值已由 webhook 校验
+		objective, _ := strconv.ParseFloat(o.Objective, 64)
+		maxBurn := o.MaxBurnRate
+		if maxBurn == "" {
+			maxBurn = dlv1.DefaultMaxBurnRate
+		}
+		maxBurnRate, _ := strconv.ParseFloat(maxBurn, 64)
+		s.SLOs = append(s.SLOs, analysis.SLO{
+			Name:        o.Name,
+			Objective:   objective / 100,
+			ErrorQuery:  o.ErrorQuery,
+			TotalQuery:  o.TotalQuery,
+			Threshold:   time.Duration(o.ThresholdMillis) * time.Millisecond,
+			MaxBurnRate: maxBurnRate,
+		})
+	}
+	return s
+}
+
+// restoreDriftedTraffic 比较流量层实际权重与当前步骤的期望权重，不一致时恢复并记录 TrafficDrift
+func (r *RolloutReconciler) restoreDriftedTraffic(ctx context.Context, tp traffic.Provider, base, ro *dlv1.Rollout) (bool, error) {
+	lg := log.FromContext(ctx)
+	expected, ok := expectedWeight(ro)
+	if !ok {
+		return false, nil
+	}
+	t := ro.Spec.Traffic
+	actual, err := tp.CanaryWeight(ctx, t.Host)
+	if err != nil {
+		lg.Error(err, "Failed to read traffic weight")
+		return false, err
+	}
+
+	if actual == expected {
+		if meta.IsStatusConditionTrue(ro.Status.Conditions, dlv1.ConditionTrafficDrift) {
+			meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+				Type:               dlv1.ConditionTrafficDrift,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: ro.Generation,
+				Reason:             "InSync",
+				Message:            fmt.Sprintf("canary weight matches expected %d", expected),
+			})
+		}
+		return false, nil
+	}
+
+	msg := fmt.Sprintf("canary weight was %d, expected %d for step %d; restored", actual, expected, ro.Status.StepIndex)
+	lg.Info("Traffic drift detected, restoring weight", "host", t.Host, "actual", actual, "expected", expected)
+	// 先单独写一次状态：缓存中的 Rollout 过期时这里会冲突，避免按旧步骤把权重改回去
+	meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+		Type:               dlv1.ConditionTrafficDrift,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: ro.Generation,
+		Reason:             "Restored",
+		Message:            msg,
+	})
+	if err := r.patchStatus(ctx, base, ro); err != nil {
+		lg.Error(err, "Failed to patch rollout status")
+		return false, err
+	}
+	ro.DeepCopyInto(base)
+	if err := tp.SetWeight(ctx, t.Host, t.StableService, t.CanaryService, expected); err != nil {
+		lg.Error(err, "Failed to restore traffic weight")
+		return false, err
+	}
+	metrics.ObserveDrift(ro.Namespace, ro.Name)
+	if r.Recorder != nil {
+		r.Recorder.Event(ro, corev1.EventTypeWarning, dlv1.ConditionTrafficDrift, msg)
+	}
+	return true, nil
+}
+
+// observeStepEnd 记录 index 步骤从下发权重到结束（下一步开始或晋级）的耗时，包括分析与 hold
+func observeStepEnd(ro *dlv1.Rollout, index int32, now time.Time) {
+	if st := ro.Status.StepStatus(index); st != nil {
+		metrics.ObserveStepDuration(ro.Namespace, ro.Name, now.Sub(st.StartedAt.Time))
+	}
+}
+
+// holdRemaining 根据状态中记录的 holdUntil 计算上一步还需等待的时间
+func holdRemaining(ro *dlv1.Rollout, now time.Time) time.Duration {
+	st := heldStep(ro)
+	if st == nil {
+		return 0
+	}
+	return st.HoldUntil.Sub(now)
+}
+
+// heldStep 已通过分析、正在 hold 或 hold 刚结束的上一步；下一步开始后返回 nil
+func heldStep(ro *dlv1.Rollout) *dlv1.StepStatus {
+	if ro.Status.Phase != dlv1.PhaseProgressing || ro.Status.StepIndex == 0 {
+		return nil
+	}
+	st := ro.Status.StepStatus(ro.Status.StepIndex - 1)
+	if st == nil || st.HoldUntil == nil {
+		return nil
+	}
+	return st
+}
+
+// expectedWeight 当前步骤应当生效的金丝雀权重；流量不处于切分状态时返回 false
+func expectedWeight(ro *dlv1.Rollout) (int32, bool) {
+	if ro.Spec.Strategy.Type == dlv1.BlueGreen {
+		return 0, false
+	}
+	steps := ro.CanarySteps()
+	idx := int(ro.Status.StepIndex)
+	switch ro.Status.Phase {
+	case dlv1.PhaseAnalyzing, dlv1.PhasePaused:
+		if idx < len(steps) {
+			return steps[idx].Weight, true
+		}
+	case dlv1.PhaseProgressing:
+		// 上一步分析通过后处于 hold 阶段，权重仍是上一步的；
+		// 没有 hold 记录说明是人工跳过的步骤（promote），此时不做比较
+		if idx > 0 && idx <= len(steps) {
+			if st := ro.Status.StepStatus(ro.Status.StepIndex - 1); st != nil && st.HoldUntil != nil {
+				return st.Weight, true
 			}
 		}
+	}
+	return 0, false
+}
 
-		// Service
-		var svc corev1.Service
-		if err := r.Get(ctx, client.ObjectKey{Name: svcName, Namespace: ro.Namespace}, &svc); err != nil {
-			if apierrors.IsNotFound(err) {
-				newSvc := corev1.Service{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      svcName,
-						Namespace: ro.Namespace,
-						Labels:    map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
-					},
-					Spec: corev1.ServiceSpec{
-						Selector: map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
-						Ports: []corev1.ServicePort{{
-							Port:       ro.Spec.TargetRef.Port,
-							TargetPort: intstr.FromInt(int(ro.Spec.TargetRef.Port)),
-						}},
-					},
-				}
-				if err := controllerutil.SetControllerReference(ro, &newSvc, r.Scheme); err != nil {
-					return err
-				}
-				log.FromContext(ctx).Info("Creating Service", "name", svcName, "labels", objLabels)
-				if err := r.Create(ctx, &newSvc); err != nil {
-					return err
-				}
-			} else {
-				return err
+// driftedRolloutsForIngress 把 provider 管理的 Ingress 变化映射到权重已偏离的 Rollout，
+// 控制器自己下发权重引起的变化不会触发调和
+func (r *RolloutReconciler) driftedRolloutsForIngress(ctx context.Context, obj client.Object) []reconcile.Request {
+	lg := log.FromContext(ctx)
+	ing, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" {
+			continue
+		}
+		// ingress 与 Rollout 位于同一 namespace
+		var list dlv1.RolloutList
+		if err := r.List(ctx, &list, client.InNamespace(ing.Namespace), client.MatchingFields{trafficHostIndex: rule.Host}); err != nil {
+			lg.Error(err, "Failed to list rollouts for ingress", "ingress", ing.Name, "host", rule.Host)
+			continue
+		}
+		for i := range list.Items {
+			ro := &list.Items[i]
+			expected, ok := expectedWeight(ro)
+			if !ok {
+				continue
 			}
-		} else {
-			// 已存在：确保对象标签齐全
-			if svc.Labels == nil {
-				svc.Labels = map[string]string{}
+			tp, err := r.trafficProvider(ctx, ro)
+			if err != nil {
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ro)})
+				continue
 			}
-			changed := false
-			for k, v := range objLabels {
-				if svc.Labels[k] != v {
-					svc.Labels[k] = v
-					changed = true
-				}
+			actual, err := tp.CanaryWeight(ctx, rule.Host)
+			if err != nil || actual != expected {
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ro)})
 			}
-			// 确保已有 Service 的 OwnerReference 指向当前 Rollout
-			if !metav1.IsControlledBy(&svc, ro) {
-				if err := controllerutil.SetControllerReference(ro, &svc, r.Scheme); err != nil {
-					log.FromContext(ctx).Info("Skip setting ownerRef for Service (already controlled)", "name", svcName, "err", err.Error())
-				} else {
-					changed = true
-				}
+		}
+	}
+	return reqs
+}
+
+// finalize 按 traffic.onDelete 收回流量，删除 provider 创建的资源后再移除 finalizer
+func (r *RolloutReconciler) finalize(ctx context.Context, ro *dlv1.Rollout) (ctrl.Result, error) {
+	lg := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(ro, trafficCleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	tp, err := r.trafficProvider(ctx, ro)
+	if err != nil {
+		lg.Error(err, "Failed to resolve traffic provider")
+		return ctrl.Result{}, err
+	}
+
+	t := ro.Spec.Traffic
+	// 只有流量仍处于切分状态时才需要先切换；已成功或已回滚的发布流量已经稳定
+	if ro.Status.Phase.InProgress() || ro.Status.Phase == dlv1.PhaseFailed {
+		if t.OnDelete == dlv1.TrafficTargetCanary {
+			lg.Info("Rollout deleted, promoting traffic to canary before cleanup", "host", t.Host)
+			if err := tp.Promote(ctx, t.Host, t.StableService, t.CanaryService); err != nil {
+				lg.Error(err, "Failed to promote traffic")
+				return ctrl.Result{}, err
 			}
-			if changed {
-				log.FromContext(ctx).Info("Patching Service labels", "name", svcName, "labels", svc.Labels)
-				if err := r.Update(ctx, &svc); err != nil {
-					return err
-				}
+		} else {
+			lg.Info("Rollout deleted, resetting traffic to stable before cleanup", "host", t.Host)
+			if err := tp.Reset(ctx, t.Host, t.StableService, t.CanaryService); err != nil {
+				lg.Error(err, "Failed to reset traffic")
+				return ctrl.Result{}, err
 			}
 		}
 	}
+	if err := tp.Cleanup(ctx, t.Host); err != nil {
+		lg.Error(err, "Failed to clean up traffic resources")
+		return ctrl.Result{}, err
+	}
+
+	metrics.Forget(ro.Namespace, ro.Name)
+	controllerutil.RemoveFinalizer(ro, trafficCleanupFinalizer)
+	if err := r.Update(ctx, ro); err != nil {
+		lg.Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	lg.Info("Traffic resources cleaned up, finalizer removed")
+	return ctrl.Result{}, nil
+}
+
+// abort 响应依赖回滚或 Abort 策略的 hook 失败，中止发布并执行 onRollback hook
+func (r *RolloutReconciler) abort(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, reason, msg string) (ctrl.Result, error) {
+	if err := r.rollBack(ctx, tp, ro, reason, msg); err != nil {
+		return ctrl.Result{}, err
+	}
+	observeRollback(ro)
+	return r.runFollowUpHooks(ctx, ro, dlv1.HookOnRollback)
+}
+
+// rollBack 把流量切回 stable（倒序发布时回到 undo 开始时的权重），进入 RolledBack 并记录 Aborted 条件
+func (r *RolloutReconciler) rollBack(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, reason, msg string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Rollout aborted, resetting traffic", "reason", reason, "phase", ro.Status.Phase, "stepIndex", ro.Status.StepIndex, "canaryWeight", ro.RollbackWeight())
+	if err := setTrafficWeight(ctx, tp, ro, ro.RollbackWeight()); err != nil {
+		lg.Error(err, "Failed to reset traffic")
+		return err
+	}
+	ro.Status.Phase = dlv1.PhaseRolledBack
+	meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+		Type:               dlv1.ConditionAborted,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: ro.Generation,
+		Reason:             reason,
+		Message:            msg,
+	})
 	return nil
 }
 
-func (r *RolloutReconciler) updateStatus(ctx context.Context, ro *dlv1.Rollout) (ctrl.Result, error) {
+// observeRollback 记录一次回滚及回滚后的权重
+func observeRollback(ro *dlv1.Rollout) {
+	metrics.ObserveRollback(ro.Namespace, ro.Name)
+	metrics.SetWeight(ro.Namespace, ro.Name, ro.RollbackWeight())
+}
+
+// notifyPhaseChange 异步发送阶段变化通知，不阻塞调和
+func (r *RolloutReconciler) notifyPhaseChange(ctx context.Context, ro *dlv1.Rollout, oldPhase dlv1.RolloutPhase) {
+	if r.Notifier == nil {
+		return
+	}
 	lg := log.FromContext(ctx)
-	lg.Info("Updating rollout status", "phase", ro.Status.Phase, "stepIndex", ro.Status.StepIndex)
+	cfgs, err := r.notificationConfigs(ctx, ro)
+	if err != nil {
+		lg.Error(err, "Failed to load notification config")
+	}
+	if len(cfgs) == 0 {
+		return
+	}
+	ev := notify.Event{
+		Namespace: ro.Namespace,
+		Name:      ro.Name,
+		OldPhase:  string(oldPhase),
+		Phase:     string(ro.Status.Phase),
+		StepIndex: ro.Status.StepIndex,
+		Weight:    currentWeight(ro),
+		Message:   phaseMessage(ro),
+		Time:      time.Now(),
+	}
+	go func() {
+		nctx, cancel := context.WithTimeout(log.IntoContext(context.Background(), lg), 2*time.Minute)
+		defer cancel()
+		if err := r.Notifier.Dispatch(nctx, cfgs, ev); err != nil {
+			lg.Error(err, "Failed to deliver phase notification", "phase", ev.Phase)
+		}
+	}()
+}
 
-	if err := r.Status().Update(ctx, ro); err != nil {
-		lg.Error(err, "Failed to update rollout status")
-		return ctrl.Result{}, err
+// phaseMessage 发布停下（回滚、失败或暂停）时的原因，取自 Aborted 或 AnalysisFailed 条件；其他阶段为空
+func phaseMessage(ro *dlv1.Rollout) string {
+	switch ro.Status.Phase {
+	case dlv1.PhaseRolledBack, dlv1.PhaseFailed, dlv1.PhasePaused:
+	default:
+		return ""
 	}
-	return ctrl.Result{}, nil
+	for _, t := range []string{dlv1.ConditionAborted, dlv1.ConditionAnalysisFailed} {
+		if c := meta.FindStatusCondition(ro.Status.Conditions, t); c != nil && c.Status == metav1.ConditionTrue {
+			return c.Message
+		}
+	}
+	return ""
+}
+
+// notificationConfigs 合并集群级 ConfigMap 与 Rollout 自身声明的通知目标
+func (r *RolloutReconciler) notificationConfigs(ctx context.Context, ro *dlv1.Rollout) ([]notify.Config, error) {
+	var cfgs []notify.Config
+	if r.NotificationConfigMap.Name != "" {
+		var cm corev1.ConfigMap
+		if err := r.Get(ctx, r.NotificationConfigMap, &cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return rolloutNotificationConfigs(ro), err
+			}
+		} else {
+			clusterCfgs, err := notify.FromConfigMap(&cm)
+			if err != nil {
+				return rolloutNotificationConfigs(ro), err
+			}
+			cfgs = append(cfgs, clusterCfgs...)
+		}
+	}
+	return append(cfgs, rolloutNotificationConfigs(ro)...), nil
+}
+
+func rolloutNotificationConfigs(ro *dlv1.Rollout) []notify.Config {
+	cfgs := make([]notify.Config, 0, len(ro.Spec.Notifications))
+	for _, n := range ro.Spec.Notifications {
+		phases := make([]string, 0, len(n.Phases))
+		for _, p := range n.Phases {
+			phases = append(phases, string(p))
+		}
+		cfgs = append(cfgs, notify.Config{
+			Type:     n.Type,
+			URL:      n.URL,
+			Headers:  n.Headers,
+			Template: n.Template,
+			Phases:   phases,
+		})
+	}
+	return cfgs
+}
+
+// trafficProvider 按 Rollout 所在 namespace 与 spec.traffic 返回流量层实现
+func (r *RolloutReconciler) trafficProvider(ctx context.Context, ro *dlv1.Rollout) (traffic.Provider, error) {
+	// provider 与控制器共用同一个 client，计划模式下流量层的写操作同样只被记录
+	opts := traffic.Options{Provider: ro.Spec.Traffic.Provider, Client: r.Client}
+	if ss := ro.Spec.Traffic.StickySession; ss != nil {
+		opts.StickySession = &traffic.StickySession{CookieName: ss.CookieName, MaxAgeSeconds: ss.MaxAgeSeconds}
+	}
+	if ro.Spec.Traffic.Provider == dlv1.ProviderReplicaRatio {
+		// 与 deploymentWorkload 创建的 Deployment 同名
+		opts.ReplicaRatio = &traffic.ReplicaRatioOptions{
+			StableDeployment: ro.Name + "-stable",
+			CanaryDeployment: ro.Name + "-canary",
+			Replicas:         ro.Spec.Traffic.Replicas,
+		}
+	}
+	return r.Traffic.For(ctx, ro.Namespace, opts)
+}
+
+// currentWeight 根据状态推算当前金丝雀权重
+func currentWeight(ro *dlv1.Rollout) int32 {
+	switch ro.Status.Phase {
+	case dlv1.PhaseSucceeded:
+		return ro.FinalWeight()
+	case dlv1.PhaseRolledBack, "":
+		return ro.RollbackWeight()
+	}
+	if w, ok := expectedWeight(ro); ok {
+		return w
+	}
+	if !rolloutStarted(ro) {
+		return ro.RollbackWeight()
+	}
+	steps := ro.CanarySteps()
+	idx := int(ro.Status.StepIndex)
+	if idx >= len(steps) {
+		return ro.FinalWeight()
+	}
+	return steps[idx].Weight
+}
+
+// setTrafficWeight 下发 canary 权重；0 与 100 分别把流量全部切回 stable、全部切到 canary
+func setTrafficWeight(ctx context.Context, tp traffic.Provider, ro *dlv1.Rollout, weight int32) error {
+	t := ro.Spec.Traffic
+	switch weight {
+	case 0:
+		return tp.Reset(ctx, t.Host, t.StableService, t.CanaryService)
+	case 100:
+		return tp.Promote(ctx, t.Host, t.StableService, t.CanaryService)
+	}
+	return tp.SetWeight(ctx, t.Host, t.StableService, t.CanaryService, weight)
+}
+
+// podTemplate 返回 stable/canary 的 Pod 模板：优先使用 spec.template，并补齐选择器需要的标签
+func podTemplate(ro *dlv1.Rollout, track string) corev1.PodTemplateSpec {
+	if ro.Spec.Template == nil {
+		return corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:  ro.Spec.TargetRef.Name,
+					Image: "nginx:1.25",
+					Ports: []corev1.ContainerPort{{ContainerPort: ro.Spec.TargetRef.Port}},
+				}},
+			},
+		}
+	}
+	tpl := *ro.Spec.Template.DeepCopy()
+	if tpl.Labels == nil {
+		tpl.Labels = map[string]string{}
+	}
+	tpl.Labels["app"] = ro.Spec.TargetRef.Name
+	tpl.Labels["track"] = track
+	return tpl
+}
+
+// patchStatus 用带 resourceVersion 的 merge patch 写回 ro 相对 base 的状态变化。
+// 冲突时若最新对象的状态与 base 相同（只是 metadata/spec 被改过），在最新对象上重试；
+// 否则说明状态已被他人推进，交给下一次调和重新计算
+func (r *RolloutReconciler) patchStatus(ctx context.Context, base, ro *dlv1.Rollout) error {
+	if equality.Semantic.DeepEqual(base.Status, ro.Status) {
+		return nil
+	}
+	lg := log.FromContext(ctx)
+	lg.Info("Patching rollout status", "phase", ro.Status.Phase, "stepIndex", ro.Status.StepIndex)
+
+	desired := ro.Status.DeepCopy()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := r.Status().Patch(ctx, ro, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		var latest dlv1.Rollout
+		if getErr := r.Get(ctx, client.ObjectKeyFromObject(ro), &latest); getErr != nil {
+			return getErr
+		}
+		if !equality.Semantic.DeepEqual(latest.Status, base.Status) {
+			return err
+		}
+		latest.DeepCopyInto(base)
+		latest.DeepCopyInto(ro)
+		desired.DeepCopyInto(&ro.Status)
+		return err
+	})
 }
 
 func (r *RolloutReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &dlv1.Rollout{}, trafficHostIndex, func(obj client.Object) []string {
+		return []string{obj.(*dlv1.Rollout).Spec.Traffic.Host}
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &dlv1.Rollout{}, dependsOnIndex, func(obj client.Object) []string {
+		var keys []string
+		for _, key := range dependencyKeys(obj.(*dlv1.Rollout)) {
+			keys = append(keys, key.String())
+		}
+		return keys
+	}); err != nil {
+		return err
+	}
+	managedByProvider, err := predicate.LabelSelectorPredicate(metav1.LabelSelector{
+		MatchLabels: map[string]string{traffic.ManagedByLabel: traffic.ManagedByValue},
+	})
+	if err != nil {
+		return err
+	}
+	b := ctrl.NewControllerManagedBy(mgr)
+	if r.NamespaceSelector != nil && !r.NamespaceSelector.Empty() {
+		b = b.Watches(&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.rolloutsInNamespace),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}))
+	}
+	return b.
 		For(&dlv1.Rollout{}).
+		Watches(&dlv1.Rollout{}, handler.EnqueueRequestsFromMapFunc(r.dependentRollouts)).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
+		Owns(&batchv1.Job{}).
+		Watches(&networkingv1.Ingress{},
+			handler.EnqueueRequestsFromMapFunc(r.driftedRolloutsForIngress),
+			builder.WithPredicates(managedByProvider)).
 		Complete(r)
 }
@@ -18,67 +18,1133 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	deliveryv1alpha1 "github.com/ormasia/rollout-operator/api/v1alpha1"
+	deliveryv1beta1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/analysis"
+	"github.com/ormasia/rollout-operator/pkg/traffic"
 )
 
+// stubEngine 返回固定分析结果，并记录最近一次调用的标签
+type stubEngine struct {
+	result analysis.Result
+	labels map[string]string
+}
+
+func (e *stubEngine) Evaluate(_ context.Context, _ analysis.Spec, labels map[string]string) (analysis.Result, error) {
+	e.labels = labels
+	return e.result, nil
+}
+
 var _ = Describe("Rollout Controller", func() {
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
+		const host = "demo.example.com"
 
 		ctx := context.Background()
 
 		typeNamespacedName := types.NamespacedName{
 			Name:      resourceName,
-			Namespace: "default", // TODO(user):Modify as needed
+			Namespace: "default",
+		}
+		var controllerReconciler *RolloutReconciler
+		var recorder *record.FakeRecorder
+
+		reconcileOnce := func() reconcile.Result {
+			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return res
+		}
+		ingressExists := func(name string) bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &networkingv1.Ingress{})
+			if errors.IsNotFound(err) {
+				return false
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return true
 		}
-		rollout := &deliveryv1alpha1.Rollout{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind Rollout")
-			err := k8sClient.Get(ctx, typeNamespacedName, rollout)
-			if err != nil && errors.IsNotFound(err) {
-				resource := &deliveryv1alpha1.Rollout{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
+			resource := &deliveryv1beta1.Rollout{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: deliveryv1beta1.RolloutSpec{
+					TargetRef: deliveryv1beta1.TargetRef{Kind: "Deployment", Name: "demo", Port: 8080},
+					Strategy: deliveryv1beta1.RolloutStrategy{
+						Type:  deliveryv1beta1.Canary,
+						Steps: []deliveryv1beta1.RolloutStep{{Weight: 20, HoldSeconds: 60}, {Weight: 100}},
 					},
-					// TODO(user): Specify other spec details if needed.
-				}
-				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+					Analysis: deliveryv1beta1.AnalysisSpec{
+						IntervalSeconds:  30,
+						SuccessThreshold: 1,
+						FailureThreshold: 1,
+						Metrics: []deliveryv1beta1.MetricCheck{
+							{Name: "error-rate", PromQL: "vector(0)", Threshold: "0.01", Compare: deliveryv1beta1.CompareLT},
+						},
+					},
+					Traffic: deliveryv1beta1.TrafficSpec{
+						Provider:      "NginxIngress",
+						Host:          host,
+						StableService: "demo-stable",
+						CanaryService: "demo-canary",
+						OnDelete:      deliveryv1beta1.TrafficTargetStable,
+					},
+					FailurePolicy: deliveryv1beta1.FailurePolicyAuto,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			recorder = record.NewFakeRecorder(10)
+			controllerReconciler = &RolloutReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Traffic:  &traffic.NginxFactory{Client: k8sClient},
+				Analysis: &stubEngine{result: analysis.Result{Passed: true}},
+				Recorder: recorder,
 			}
 		})
 
 		AfterEach(func() {
-			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &deliveryv1alpha1.Rollout{}
+			resource := &deliveryv1beta1.Rollout{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			if errors.IsNotFound(err) {
+				return
+			}
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Cleanup the specific resource instance Rollout")
+			controllerutil.RemoveFinalizer(resource, trafficCleanupFinalizer)
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
 		})
+
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
-			controllerReconciler := &RolloutReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+			reconcileOnce()
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Finalizers).To(ContainElement(trafficCleanupFinalizer))
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseProgressing))
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			Expect(ingressExists(host + "-canary")).To(BeTrue())
+		})
+
+		It("should only record a plan in dry-run mode", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.DryRun = true
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Finalizers).NotTo(ContainElement(trafficCleanupFinalizer))
+			Expect(ro.Status.Phase).To(BeEmpty())
+			Expect(ro.Status.Revisions).To(BeEmpty())
+			Expect(ingressExists(host + "-stable")).To(BeFalse())
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-canary", Namespace: "default"}, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(ro.Status.Plan).NotTo(BeNil())
+			var steps []string
+			for _, a := range ro.Status.Plan.Actions {
+				step := "-"
+				if a.Step != nil {
+					step = fmt.Sprint(*a.Step)
+				}
+				steps = append(steps, fmt.Sprintf("%s %s %s", step, a.Type, a.Target))
 			}
+			Expect(steps).To(Equal([]string{
+				"- Create ControllerRevision/" + ro.Status.Plan.Revision,
+				"- Create Deployment/" + resourceName + "-stable",
+				"- Create Service/demo-stable",
+				"- Create Deployment/" + resourceName + "-canary",
+				"- Create Service/demo-canary",
+				"0 Create Ingress/" + host + "-stable",
+				"0 Create Ingress/" + host + "-canary",
+				"0 Analyze ",
+				"0 Hold ",
+				"1 Update Ingress/" + host + "-canary",
+				"1 Analyze ",
+				"- Update Ingress/" + host + "-stable",
+				"- Delete Ingress/" + host + "-canary",
+			}))
+			generatedAt := ro.Status.Plan.GeneratedAt
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
-			})
-			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			By("keeping the plan stable across reconciles")
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Plan.GeneratedAt).To(Equal(generatedAt))
+
+			By("executing once dry-run is turned off")
+			ro.Spec.DryRun = false
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Plan).To(BeNil())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseProgressing))
+			Expect(ingressExists(host + "-canary")).To(BeTrue())
+		})
+
+		It("should gate the first step on a pre-rollout job hook", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Hooks = &deliveryv1beta1.RolloutHooks{PreRollout: []deliveryv1beta1.RolloutHook{{
+				Name: "migrate",
+				Job: &batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "migrate", Image: "registry.local/migrate:v2"}}},
+				}}},
+				TimeoutSeconds: 600,
+			}}}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(Equal(hookPollInterval))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			st := ro.Status.HookStatus("migrate")
+			Expect(st).NotTo(BeNil())
+			Expect(st.Phase).To(Equal(deliveryv1beta1.HookRunning))
+			Expect(st.Job).To(HavePrefix(resourceName + "-migrate-"))
+			Expect(ro.Status.StepStatus(0)).To(BeNil())
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: st.Job, Namespace: "default"}, job)).To(Succeed())
+			Expect(metav1.IsControlledBy(job, ro)).To(BeTrue())
+			Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(600)))
+			Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+
+			By("Completing the job")
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: now}}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.HookStatus("migrate").Phase).To(Equal(deliveryv1beta1.HookSucceeded))
+			Expect(ro.Status.StepStatus(0)).NotTo(BeNil())
+			Expect(ingressExists(host + "-canary")).To(BeTrue())
+		})
+
+		It("should run the pre-rollout hook of a new revision before the canary is updated", func() {
+			image := func() string {
+				GinkgoHelper()
+				dep := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-canary", Namespace: "default"}, dep)).To(Succeed())
+				return dep.Spec.Template.Spec.Containers[0].Image
+			}
+			reconcileOnce()
+
+			By("Shipping a new template together with a migration")
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			ro.Spec.Hooks = &deliveryv1beta1.RolloutHooks{PreRollout: []deliveryv1beta1.RolloutHook{{
+				Name: "migrate",
+				Job: &batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "migrate", Image: "registry.local/migrate:v2"}}},
+				}}},
+			}}}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			st := ro.Status.HookStatus("migrate")
+			Expect(st).NotTo(BeNil())
+			Expect(st.Phase).To(Equal(deliveryv1beta1.HookRunning))
+			Expect(image()).To(Equal("nginx:1.25"))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+
+			By("Completing the migration")
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: st.Job, Namespace: "default"}, job)).To(Succeed())
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: now}}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+			reconcileOnce()
+			Expect(image()).To(Equal("demo:v2"))
+			Expect(ingressExists(host + "-canary")).To(BeTrue())
+		})
+
+		It("should roll back when a pre-promotion hook fails and run the rollback hooks", func() {
+			var calls []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				if r.URL.Path == "/smoke" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			DeferCleanup(srv.Close)
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Strategy.Steps = []deliveryv1beta1.RolloutStep{{Weight: 100}}
+			ro.Spec.Hooks = &deliveryv1beta1.RolloutHooks{
+				PrePromotion: []deliveryv1beta1.RolloutHook{{Name: "smoke", HTTP: &deliveryv1beta1.HTTPHook{URL: srv.URL + "/smoke"}}},
+				OnRollback:   []deliveryv1beta1.RolloutHook{{Name: "page", HTTP: &deliveryv1beta1.HTTPHook{URL: srv.URL + "/page"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			Expect(calls).To(BeEmpty())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(calls).To(Equal([]string{"POST /smoke", "POST /page"}))
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+			Expect(ro.Status.HookStatus("smoke").Phase).To(Equal(deliveryv1beta1.HookFailed))
+			Expect(ro.Status.HookStatus("page").Phase).To(Equal(deliveryv1beta1.HookSucceeded))
+			Expect(meta.IsStatusConditionTrue(ro.Status.Conditions, deliveryv1beta1.ConditionHookFailed)).To(BeTrue())
+			Expect(meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionAborted).Reason).To(Equal(deliveryv1beta1.ConditionHookFailed))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+
+			By("Not calling the hooks again on later reconciles")
+			reconcileOnce()
+			Expect(calls).To(HaveLen(2))
+		})
+
+		It("should retry an HTTP hook that cannot be reached until it answers", func() {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			DeferCleanup(srv.Close)
+			down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			url := down.URL
+			down.Close()
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Hooks = &deliveryv1beta1.RolloutHooks{
+				PreRollout: []deliveryv1beta1.RolloutHook{{Name: "notify", HTTP: &deliveryv1beta1.HTTPHook{URL: url + "/notify"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(res.RequeueAfter).To(BeNumerically("<=", hookPollInterval))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			st := ro.Status.HookStatus("notify")
+			Expect(st).NotTo(BeNil())
+			Expect(st.Phase).To(Equal(deliveryv1beta1.HookRunning))
+			Expect(st.Message).NotTo(BeEmpty())
+			Expect(ro.Status.StepStatus(0)).To(BeNil())
+
+			By("Calling the hook again once it is reachable")
+			ro.Spec.Hooks.PreRollout[0].HTTP.URL = srv.URL + "/notify"
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.HookStatus("notify").Phase).To(Equal(deliveryv1beta1.HookSucceeded))
+			Expect(ro.Status.StepStatus(0)).NotTo(BeNil())
+		})
+
+		It("should analyze every interval until the success threshold is reached", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Analysis.SuccessThreshold = 2
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			analyzedBefore := func(d time.Duration) {
+				Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+				at := metav1.NewTime(time.Now().Add(-d))
+				ro.Status.StepStatus(0).AnalyzedAt = &at
+				Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			}
+
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseAnalyzing))
+			Expect(ro.Status.StepIndex).To(BeZero())
+			Expect(ro.Status.StepStatus(0).Successes).To(Equal(int32(1)))
+
+			By("Not analyzing again before the interval has passed")
+			res = reconcileOnce()
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(res.RequeueAfter).To(BeNumerically("<=", 30*time.Second))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepStatus(0).Successes).To(Equal(int32(1)))
+
+			By("Resetting the count after a failed analysis")
+			analyzedBefore(31 * time.Second)
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Passed: false, Reason: "not ready"}}
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseAnalyzing))
+			Expect(ro.Status.StepStatus(0).Successes).To(BeZero())
+			Expect(ro.Status.StepStatus(0).Failures).To(Equal(int32(1)))
+
+			By("Advancing after two consecutive successes")
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Passed: true}}
+			analyzedBefore(31 * time.Second)
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(BeZero())
+			analyzedBefore(31 * time.Second)
+			res = reconcileOnce()
+			Expect(res.RequeueAfter).To(Equal(60 * time.Second))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			Expect(ro.Status.StepStatus(0).Successes).To(Equal(int32(2)))
+		})
+
+		It("should fail a step only after the failure threshold is reached", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Analysis.FailureThreshold = 2
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Passed: false, Reason: "not ready"}}
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseAnalyzing))
+			Expect(ingressExists(host + "-canary")).To(BeTrue())
+
+			at := metav1.NewTime(time.Now().Add(-31 * time.Second))
+			ro.Status.StepStatus(0).AnalyzedAt = &at
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+			Expect(meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionAnalysisFailed).Message).To(Equal("not ready"))
+		})
+
+		It("should not count an inconclusive analysis", func() {
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Inconclusive: true, Reason: "SLO window 0s is shorter than 1m0s"}}
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseAnalyzing))
+			st := ro.Status.StepStatus(0)
+			Expect(st.AnalyzedAt).NotTo(BeNil())
+			Expect(st.Successes).To(BeZero())
+			Expect(st.Failures).To(BeZero())
+		})
+
+		It("should keep checking the SLOs during a hold and roll back when they fail", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Analysis.SLOs = []deliveryv1beta1.SLOCheck{{
+				Name: "availability", Type: deliveryv1beta1.SLOAvailability, Objective: "99.9", MaxBurnRate: "2",
+				ErrorQuery: "sum(errors)", TotalQuery: "sum(requests)",
+			}}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(Equal(60 * time.Second))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+
+			By("Not analyzing again before the interval has passed")
+			res = reconcileOnce()
+			Expect(res.RequeueAfter).To(BeNumerically("<=", 30*time.Second))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseProgressing))
+
+			By("Failing the step when the SLOs fail during the hold")
+			at := metav1.NewTime(time.Now().Add(-31 * time.Second))
+			ro.Status.StepStatus(0).AnalyzedAt = &at
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Passed: false, Reason: "SLO availability burn rate 5.00 exceeds 2"}}
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+			Expect(ro.Status.StepIndex).To(BeZero())
+			Expect(meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionAnalysisFailed).Message).To(ContainSubstring("burn rate 5.00"))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+		})
+
+		It("should keep holding a step across spurious reconciles", func() {
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(Equal(60 * time.Second))
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			st := ro.Status.StepStatus(0)
+			Expect(st).NotTo(BeNil())
+			Expect(st.Weight).To(Equal(int32(20)))
+			Expect(st.HoldUntil).NotTo(BeNil())
+
+			By("Reconciling again before the hold expires")
+			res = reconcileOnce()
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(res.RequeueAfter).To(BeNumerically("<=", 60*time.Second))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			Expect(ro.Status.StepStatus(1)).To(BeNil())
+
+			By("Reconciling once the recorded hold has expired")
+			expired := metav1.NewTime(time.Now().Add(-time.Second))
+			ro.Status.StepStatus(0).HoldUntil = &expired
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(2)))
+			Expect(ro.Status.StepStatus(1)).NotTo(BeNil())
+		})
+
+		It("should describe why the rollout stopped in phase notifications", func() {
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Passed: false, Reason: "not ready"}}
+			reconcileOnce()
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+			Expect(phaseMessage(ro)).To(Equal("not ready"))
+
+			ro.Status.Phase = deliveryv1beta1.PhaseProgressing
+			Expect(phaseMessage(ro)).To(BeEmpty())
+		})
+
+		It("should pass spec.analysis.metrics to the analysis engine", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(analysisSpec(ro, nil, time.Now()).Metrics).To(Equal([]analysis.Metric{
+				{Name: "error-rate", Query: "vector(0)", Threshold: 0.01, Compare: deliveryv1beta1.CompareLT},
+			}))
+		})
+
+		It("should reset traffic when an abort is requested", func() {
+			reconcileOnce()
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Status.Abort = true
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+			Expect(meta.IsStatusConditionTrue(ro.Status.Conditions, deliveryv1beta1.ConditionAborted)).To(BeTrue())
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+		})
+
+		It("should not advance while spec.paused is set", func() {
+			reconcileOnce()
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Paused = true
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			expired := metav1.NewTime(time.Now().Add(-time.Second))
+			ro.Status.StepStatus(0).HoldUntil = &expired
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseProgressing))
+		})
+
+		It("should hold the current step outside the rollout window", func() {
+			reconcileOnce()
+
+			By("Declaring a blackout around today")
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			now := time.Now().UTC()
+			ro.Spec.Schedule = &deliveryv1beta1.RolloutSchedule{
+				Blackouts: []string{now.AddDate(0, 0, -1).Format("2006-01-02") + "/" + now.AddDate(0, 0, 1).Format("2006-01-02")},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			expired := metav1.NewTime(time.Now().Add(-time.Second))
+			ro.Status.StepStatus(0).HoldUntil = &expired
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			cond := meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionWaiting)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal("Blackout"))
+			Expect(recorder.Events).To(Receive(ContainSubstring("blackout date")))
+
+			By("Lifting the blackout")
+			ro.Spec.Schedule = nil
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(2)))
+			Expect(meta.IsStatusConditionFalse(ro.Status.Conditions, deliveryv1beta1.ConditionWaiting)).To(BeTrue())
+		})
+
+		It("should not release a new revision to the canary outside the rollout window", func() {
+			image := func() string {
+				GinkgoHelper()
+				dep := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-canary", Namespace: "default"}, dep)).To(Succeed())
+				return dep.Spec.Template.Spec.Containers[0].Image
+			}
+			reconcileOnce()
+
+			By("Changing the template during a blackout")
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			now := time.Now().UTC()
+			ro.Spec.Schedule = &deliveryv1beta1.RolloutSchedule{
+				Blackouts: []string{now.AddDate(0, 0, -1).Format("2006-01-02") + "/" + now.AddDate(0, 0, 1).Format("2006-01-02")},
+			}
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			res := reconcileOnce()
+			Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(BeEmpty())
+			Expect(image()).To(Equal("nginx:1.25"))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+
+			By("Lifting the blackout")
+			ro.Spec.Schedule = nil
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(HaveLen(1))
+			Expect(image()).To(Equal("demo:v2"))
+		})
+
+		It("should wait for dependencies and abort when one rolls back", func() {
+			release := map[string]string{deliveryv1beta1.ReleaseLabel: "2025.03"}
+			backend := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, backend)).To(Succeed())
+			backend = &deliveryv1beta1.Rollout{
+				ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default", Labels: map[string]string{deliveryv1beta1.ReleaseLabel: "2025.02"}},
+				Spec:       *backend.Spec.DeepCopy(),
+			}
+			backend.Spec.Traffic.Host = "backend.example.com"
+			Expect(k8sClient.Create(ctx, backend)).To(Succeed())
+			DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, backend))).To(Succeed()) })
+			backend.Status.Phase = deliveryv1beta1.PhaseSucceeded
+			Expect(k8sClient.Status().Update(ctx, backend)).To(Succeed())
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Labels = release
+			ro.Spec.DependsOn = []deliveryv1beta1.RolloutDependency{{Name: "backend"}}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			By("Waiting while the dependency has only succeeded for an older release")
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(BeEmpty())
+			cond := meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionDependenciesReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(ContainSubstring(`default/backend (release "2025.02")`))
+
+			By("Starting once the dependency succeeded for the same release")
+			backend.Labels = release
+			Expect(k8sClient.Update(ctx, backend)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+			Expect(meta.IsStatusConditionTrue(ro.Status.Conditions, deliveryv1beta1.ConditionDependenciesReady)).To(BeTrue())
+
+			By("Aborting when the dependency rolls back")
+			backend.Status.Phase = deliveryv1beta1.PhaseRolledBack
+			Expect(k8sClient.Status().Update(ctx, backend)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+			cond = meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionAborted)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal("DependencyRolledBack"))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+		})
+
+		It("should read dependencies through DependencyReader and recheck while waiting", func() {
+			auth := &deliveryv1beta1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "identity"}}
+			auth.Status.Phase = deliveryv1beta1.PhaseProgressing
+			controllerReconciler.DependencyReader = fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(auth).Build()
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.DependsOn = []deliveryv1beta1.RolloutDependency{{Name: "auth", Namespace: "identity"}}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+
+			Expect(reconcileOnce().RequeueAfter).To(Equal(dependencyRecheckInterval))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(BeEmpty())
+			cond := meta.FindStatusCondition(ro.Status.Conditions, deliveryv1beta1.ConditionDependenciesReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Message).To(Equal("waiting for identity/auth (Progressing)"))
+		})
+
+		It("should keep a new revision out of the canary while dependencies are pending", func() {
+			image := func() string {
+				GinkgoHelper()
+				dep := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-canary", Namespace: "default"}, dep)).To(Succeed())
+				return dep.Spec.Template.Spec.Containers[0].Image
+			}
+			reconcileOnce()
+
+			backend := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, backend)).To(Succeed())
+			backend = &deliveryv1beta1.Rollout{
+				ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default"},
+				Spec:       *backend.Spec.DeepCopy(),
+			}
+			backend.Spec.Traffic.Host = "backend.example.com"
+			Expect(k8sClient.Create(ctx, backend)).To(Succeed())
+			DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, backend))).To(Succeed()) })
+			backend.Status.Phase = deliveryv1beta1.PhaseProgressing
+			Expect(k8sClient.Status().Update(ctx, backend)).To(Succeed())
+
+			By("Changing the template while the dependency is still rolling out")
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.DependsOn = []deliveryv1beta1.RolloutDependency{{Name: "backend"}}
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(BeEmpty())
+			Expect(meta.IsStatusConditionFalse(ro.Status.Conditions, deliveryv1beta1.ConditionDependenciesReady)).To(BeTrue())
+			Expect(image()).To(Equal("nginx:1.25"))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+
+			By("Releasing once the dependency succeeded")
+			backend.Status.Phase = deliveryv1beta1.PhaseSucceeded
+			Expect(k8sClient.Status().Update(ctx, backend)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(HaveLen(1))
+			Expect(image()).To(Equal("demo:v2"))
+		})
+
+		It("should restore a canary weight edited by hand", func() {
+			reconcileOnce()
+
+			By("Editing the canary ingress weight out of band")
+			ing := &networkingv1.Ingress{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host + "-canary", Namespace: "default"}, ing)).To(Succeed())
+			ing.Annotations["nginx.ingress.kubernetes.io/canary-weight"] = "50"
+			Expect(k8sClient.Update(ctx, ing)).To(Succeed())
+
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host + "-canary", Namespace: "default"}, ing)).To(Succeed())
+			Expect(ing.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/canary-weight", "20"))
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)), "restoring drift must not advance the step")
+			Expect(meta.IsStatusConditionTrue(ro.Status.Conditions, deliveryv1beta1.ConditionTrafficDrift)).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring("expected 20")))
+		})
+
+		It("should release a changed template as a new revision", func() {
+			image := func(track string) string {
+				GinkgoHelper()
+				dep := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-" + track, Namespace: "default"}, dep)).To(Succeed())
+				return dep.Spec.Template.Spec.Containers[0].Image
+			}
+			reconcileOnce()
+
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			first := ro.Status.CanaryRevision
+			Expect(first).NotTo(BeEmpty())
+			Expect(ro.Status.StableRevision).To(Equal(first))
+			Expect(ro.Status.Revisions).To(HaveLen(1))
+
+			By("Changing the pod template mid-canary")
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.CanaryRevision).NotTo(Equal(first))
+			Expect(ro.Status.StableRevision).To(Equal(first))
+			Expect(ro.Status.Revisions).To(HaveLen(2))
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)), "a new revision restarts from step 0")
+			Expect(ro.Status.Steps).To(HaveLen(1))
+			Expect(image("canary")).To(Equal("demo:v2"))
+			Expect(image("stable")).To(Equal("nginx:1.25"))
+			cr := &appsv1.ControllerRevision{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ro.Status.CanaryRevision, Namespace: "default"}, cr)).To(Succeed())
+			Expect(cr.Revision).To(Equal(int64(2)))
+
+			By("Finishing the canary")
+			expired := metav1.NewTime(time.Now().Add(-time.Second))
+			ro.Status.StepStatus(0).HoldUntil = &expired
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseSucceeded))
+			Expect(ro.Status.StableRevision).To(Equal(ro.Status.CanaryRevision))
+
+			reconcileOnce()
+			Expect(image("stable")).To(Equal("demo:v2"))
+		})
+
+		It("should walk the canary steps in reverse when undoing to an older revision", func() {
+			image := func(track string) string {
+				GinkgoHelper()
+				dep := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-" + track, Namespace: "default"}, dep)).To(Succeed())
+				return dep.Spec.Template.Spec.Containers[0].Image
+			}
+			canaryWeight := func() string {
+				GinkgoHelper()
+				ing := &networkingv1.Ingress{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host + "-canary", Namespace: "default"}, ing)).To(Succeed())
+				return ing.Annotations["nginx.ingress.kubernetes.io/canary-weight"]
+			}
+			finish := func() {
+				GinkgoHelper()
+				ro := &deliveryv1beta1.Rollout{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+				expired := metav1.NewTime(time.Now().Add(-time.Second))
+				ro.Status.StepStatus(0).HoldUntil = &expired
+				Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+				reconcileOnce()
+				reconcileOnce()
+				Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+				Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseSucceeded))
+			}
+
+			By("Releasing demo:v2 on top of the first revision")
+			reconcileOnce()
+			finish()
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			first := ro.Status.CanaryRevision
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			finish()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			second := ro.Status.CanaryRevision
+
+			By("Undoing to the first revision")
+			ro.Spec.Template = nil
+			ro.Annotations = map[string]string{deliveryv1beta1.UndoAnnotation: first}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.CanaryRevision).To(Equal(first))
+			Expect(ro.Status.Undo).To(Equal(&deliveryv1beta1.UndoStatus{FromRevision: second, FromWeight: 100}))
+			Expect(ro.CanarySteps()).To(Equal([]deliveryv1beta1.RolloutStep{{Weight: 20, HoldSeconds: 60}}))
+			Expect(image("stable")).To(Equal("nginx:1.25"), "the target revision goes to the stable workload")
+			Expect(image("canary")).To(Equal("demo:v2"), "the current revision stays on the canary")
+			Expect(canaryWeight()).To(Equal("20"))
+
+			By("Sending all traffic to the target revision after the last reverse step")
+			finish()
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.StableRevision).To(Equal(first))
+			reconcileOnce()
+			Expect(image("canary")).To(Equal("nginx:1.25"))
+		})
+
+		It("should send traffic back to stable before a new revision starts", func() {
+			stableBackend := func() string {
+				GinkgoHelper()
+				ing := &networkingv1.Ingress{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host + "-stable", Namespace: "default"}, ing)).To(Succeed())
+				return ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name
+			}
+
+			By("Finishing a first release")
+			reconcileOnce()
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			expired := metav1.NewTime(time.Now().Add(-time.Second))
+			ro.Status.StepStatus(0).HoldUntil = &expired
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseSucceeded))
+			Expect(stableBackend()).To(Equal("demo-canary"))
+
+			By("Changing the template while spec.paused keeps step 0 from starting")
+			ro.Spec.Paused = true
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Steps).To(BeEmpty())
+			Expect(stableBackend()).To(Equal("demo-stable"))
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+		})
+
+		It("should prune revisions beyond the history limit", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.RevisionHistoryLimit = ptr.To(int32(2))
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+
+			for _, tag := range []string{"v2", "v3", "v4"} {
+				Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+				ro.Spec.Template = &corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:" + tag}}},
+				}
+				Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+				reconcileOnce()
+			}
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			names := []string{}
+			for _, rec := range ro.Status.Revisions {
+				names = append(names, rec.Name)
+			}
+			// stable 版本（首个模板）始终保留
+			Expect(names).To(ConsistOf(ro.Status.StableRevision, ro.Status.CanaryRevision))
+			Expect(ro.Status.Revisions[1].Revision).To(Equal(int64(4)))
+		})
+
+		It("should place only canary pods on the canary node pool", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Placement = &deliveryv1beta1.CanaryPlacement{
+				SpreadAcross: []string{"topology.kubernetes.io/zone"},
+				SpreadMode:   deliveryv1beta1.SpreadRequired,
+				NodeSelector: map[string]string{"pool": "canary"},
+				Tolerations:  []corev1.Toleration{{Key: "canary", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+
+			canary := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-canary", Namespace: "default"}, canary)).To(Succeed())
+			spec := canary.Spec.Template.Spec
+			Expect(spec.NodeSelector).To(HaveKeyWithValue("pool", "canary"))
+			Expect(spec.Tolerations).To(HaveLen(1))
+			Expect(spec.TopologySpreadConstraints).To(HaveLen(1))
+			Expect(spec.TopologySpreadConstraints[0].TopologyKey).To(Equal("topology.kubernetes.io/zone"))
+			Expect(spec.TopologySpreadConstraints[0].WhenUnsatisfiable).To(Equal(corev1.DoNotSchedule))
+
+			stable := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-stable", Namespace: "default"}, stable)).To(Succeed())
+			Expect(stable.Spec.Template.Spec.NodeSelector).To(BeEmpty())
+			Expect(stable.Spec.Template.Spec.TopologySpreadConstraints).To(BeEmpty())
+		})
+
+		It("should canary a StatefulSet through its update partition", func() {
+			partition := func() int32 {
+				GinkgoHelper()
+				sts := &appsv1.StatefulSet{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, sts)).To(Succeed())
+				return *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+			}
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.TargetRef.Kind = deliveryv1beta1.KindStatefulSet
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+
+			Expect(partition()).To(Equal(int32(3)), "20% of 4 replicas rounds up to one pod")
+			Expect(controllerReconciler.Analysis.(*stubEngine).labels).To(HaveKeyWithValue("statefulset", resourceName))
+			svc := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "demo-canary", Namespace: "default"}, svc)).To(Succeed())
+			Expect(svc.Spec.Selector).To(HaveKeyWithValue("app", "demo"))
+			Expect(svc.Spec.Selector).NotTo(HaveKey("track"))
+
+			By("Releasing a new template")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			sts := &appsv1.StatefulSet{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, sts)).To(Succeed())
+			Expect(sts.Spec.Template.Spec.Containers[0].Image).To(Equal("demo:v2"))
+			Expect(sts.Spec.Template.Labels).NotTo(HaveKey("track"))
+			Expect(partition()).To(Equal(int32(3)))
+
+			By("Finishing the canary")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			expired := metav1.NewTime(time.Now().Add(-time.Second))
+			ro.Status.StepStatus(0).HoldUntil = &expired
+			Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseSucceeded))
+			Expect(partition()).To(BeZero())
+		})
+
+		It("should revert a StatefulSet to the stable template after a rollback", func() {
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.TargetRef.Kind = deliveryv1beta1.KindStatefulSet
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			reconcileOnce()
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			ro.Spec.Template = &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v2"}}},
+			}
+			Expect(k8sClient.Update(ctx, ro)).To(Succeed())
+			controllerReconciler.Analysis = &stubEngine{result: analysis.Result{Passed: false, Reason: "not ready"}}
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseRolledBack))
+
+			reconcileOnce()
+			sts := &appsv1.StatefulSet{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, sts)).To(Succeed())
+			Expect(sts.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.25"))
+			Expect(*sts.Spec.UpdateStrategy.RollingUpdate.Partition).To(BeZero())
+		})
+
+		It("should only reconcile rollouts in namespaces matching the selector", func() {
+			controllerReconciler.NamespaceSelector = labels.SelectorFromSet(labels.Set{"rollouts.example.com/enabled": "true"})
+			reconcileOnce()
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Finalizers).To(BeEmpty())
+			Expect(ro.Status.Phase).To(BeEmpty())
+
+			By("Labeling the namespace")
+			ns := &corev1.Namespace{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, ns)
+			if errors.IsNotFound(err) {
+				ns.Name = "default"
+				ns.Labels = map[string]string{"rollouts.example.com/enabled": "true"}
+				Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+				ns.Labels["rollouts.example.com/enabled"] = "true"
+				Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+				DeferCleanup(func() {
+					Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, ns)).To(Succeed())
+					delete(ns.Labels, "rollouts.example.com/enabled")
+					Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+				})
+			}
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.Status.Phase).To(Equal(deliveryv1beta1.PhaseProgressing))
+		})
+
+		It("should apply per-namespace traffic settings from the ConfigMap", func() {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "traffic-config", Namespace: "default"},
+				Data: map[string]string{traffic.ConfigMapKey: `
+default:
+  ingressClassName: nginx-public
+namespaces:
+  default:
+    ingressClassName: nginx-internal
+    annotations:
+      example.com/team: payments
+`},
+			}
+			Expect(k8sClient.Create(ctx, cm)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, cm)).To(Succeed()) })
+			controllerReconciler.Traffic = &traffic.NginxFactory{Client: k8sClient, ConfigMap: client.ObjectKeyFromObject(cm)}
+			reconcileOnce()
+
+			ing := &networkingv1.Ingress{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: host + "-canary", Namespace: "default"}, ing)).To(Succeed())
+			Expect(*ing.Spec.IngressClassName).To(Equal("nginx-internal"))
+			Expect(ing.Annotations).To(HaveKeyWithValue("example.com/team", "payments"))
+			Expect(ing.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/canary-weight", "20"))
+		})
+
+		It("should retry status patches that only conflict on metadata", func() {
+			base := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, base)).To(Succeed())
+			ro := base.DeepCopy()
+			ro.Status.Phase = deliveryv1beta1.PhaseProgressing
+
+			By("Changing labels behind the reconciler's back")
+			other := base.DeepCopy()
+			other.Labels = map[string]string{"team": "payments"}
+			Expect(k8sClient.Update(ctx, other)).To(Succeed())
+
+			Expect(controllerReconciler.patchStatus(ctx, base, ro)).To(Succeed())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, other)).To(Succeed())
+			Expect(other.Status.Phase).To(Equal(deliveryv1beta1.PhaseProgressing))
+			Expect(other.Labels).To(HaveKeyWithValue("team", "payments"))
+		})
+
+		It("should not overwrite status written concurrently", func() {
+			base := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, base)).To(Succeed())
+			ro := base.DeepCopy()
+			ro.Status.Phase = deliveryv1beta1.PhaseProgressing
+
+			other := base.DeepCopy()
+			other.Status.Phase = deliveryv1beta1.PhasePaused
+			Expect(k8sClient.Status().Update(ctx, other)).To(Succeed())
+
+			err := controllerReconciler.patchStatus(ctx, base, ro)
+			Expect(errors.IsConflict(err)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, other)).To(Succeed())
+			Expect(other.Status.Phase).To(Equal(deliveryv1beta1.PhasePaused))
+		})
+
+		It("should clean up ingresses before releasing a deleted rollout", func() {
+			reconcileOnce()
+			Expect(ingressExists(host + "-stable")).To(BeTrue())
+			Expect(ingressExists(host + "-canary")).To(BeTrue())
+
+			By("Deleting the rollout mid-canary")
+			ro := &deliveryv1beta1.Rollout{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, ro)).To(Succeed())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, ro)).To(Succeed())
+			Expect(ro.DeletionTimestamp).NotTo(BeNil())
+
+			reconcileOnce()
+			Expect(ingressExists(host + "-stable")).To(BeFalse())
+			Expect(ingressExists(host + "-canary")).To(BeFalse())
+			err := k8sClient.Get(ctx, typeNamespacedName, ro)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
 		})
 	})
 })
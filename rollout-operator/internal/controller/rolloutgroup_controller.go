@@ -0,0 +1,394 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+// ConditionMembersReady spec 中列出的 Rollout 是否都存在且未被其他 RolloutGroup 占用
+const ConditionMembersReady = "MembersReady"
+
+// ConditionGroupRolledBack 有成员回滚或失败，整组已回滚
+const ConditionGroupRolledBack = "RolledBack"
+
+// groupReleaseFinalizer 保证 RolloutGroup 删除前先解除对成员的管理
+const groupReleaseFinalizer = "delivery.example.com/group-release"
+
+// missingMemberRecheckInterval 成员缺失时的重新检查间隔，新建的 Rollout 还没有分组标签，不会触发调和
+const missingMemberRecheckInterval = 30 * time.Second
+
+// RolloutGroupReconciler 按波次推进一组 Rollout：后一波通过 dependsOn 等待前一波，任一成员失败时整组回滚
+type RolloutGroupReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Recorder 为空时不记录事件
+	Recorder record.EventRecorder
+}
+
+// groupMember 一个成员及其所在波次；Rollout 不存在或被其他分组占用时 ro 为 nil
+type groupMember struct {
+	name string
+	wave int
+	ro   *dlv1.Rollout
+}
+
+// +kubebuilder:rbac:groups=delivery.example.com,resources=rolloutgroups,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=delivery.example.com,resources=rolloutgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=delivery.example.com,resources=rolloutgroups/finalizers,verbs=update
+
+func (r *RolloutGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, retErr error) {
+	lg := log.FromContext(ctx)
+
+	var g dlv1.RolloutGroup
+	if err := r.Get(ctx, req.NamespacedName, &g); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !g.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, &g)
+	}
+	if controllerutil.AddFinalizer(&g, groupReleaseFinalizer) {
+		if err := r.Update(ctx, &g); err != nil {
+			lg.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	base := g.DeepCopy()
+	defer func() {
+		if equality.Semantic.DeepEqual(base.Status, g.Status) {
+			return
+		}
+		if err := r.Status().Patch(ctx, &g, client.MergeFrom(base)); err != nil {
+			lg.Error(err, "Failed to patch rollout group status")
+			if retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	// 新的发布批次：重新记录各成员的基线版本
+	if g.Status.Release != g.Spec.Release {
+		lg.Info("Starting group release", "release", g.Spec.Release)
+		now := metav1.Now()
+		g.Status = dlv1.RolloutGroupStatus{Release: g.Spec.Release, StartedAt: &now, Phase: dlv1.GroupProgressing}
+	}
+
+	members, missing, err := r.loadMembers(ctx, &g)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.updateMemberStatuses(&g, members)
+
+	if g.Status.Phase != dlv1.GroupRolledBack {
+		if failed := failedMember(&g, members); failed != nil {
+			msg := fmt.Sprintf("rollout %s is %s, rolling back the group", failed.Name, failed.Status.Phase)
+			lg.Info("Group member failed, rolling back", "member", failed.Name, "phase", failed.Status.Phase)
+			g.Status.Phase = dlv1.GroupRolledBack
+			meta.SetStatusCondition(&g.Status.Conditions, metav1.Condition{
+				Type:               ConditionGroupRolledBack,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: g.Generation,
+				Reason:             "MemberFailed",
+				Message:            msg,
+			})
+			if r.Recorder != nil {
+				r.Recorder.Event(&g, corev1.EventTypeWarning, ConditionGroupRolledBack, msg)
+			}
+		}
+	}
+
+	for _, m := range members {
+		if m.ro == nil {
+			continue
+		}
+		if err := r.ensureMember(ctx, &g, m); err != nil {
+			lg.Error(err, "Failed to update group member", "member", m.name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if g.Status.Phase == dlv1.GroupRolledBack {
+		return ctrl.Result{}, r.rollbackMembers(ctx, &g, members)
+	}
+
+	g.Status.CurrentWave = int32(len(g.Spec.Waves) - 1)
+	done := len(missing) == 0
+	for _, m := range members {
+		if m.ro == nil || m.ro.Status.Phase != dlv1.PhaseSucceeded {
+			g.Status.CurrentWave = min(g.Status.CurrentWave, int32(m.wave))
+			done = false
+		}
+	}
+	if done {
+		g.Status.Phase = dlv1.GroupSucceeded
+	} else {
+		g.Status.Phase = dlv1.GroupProgressing
+	}
+	if len(missing) > 0 {
+		return ctrl.Result{RequeueAfter: missingMemberRecheckInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// loadMembers 按波次顺序读取成员，更新 MembersReady 条件，返回缺失或被占用的成员说明
+func (r *RolloutGroupReconciler) loadMembers(ctx context.Context, g *dlv1.RolloutGroup) ([]groupMember, []string, error) {
+	var members []groupMember
+	var missing []string
+	for i, w := range g.Spec.Waves {
+		for _, name := range w.Rollouts {
+			m := groupMember{name: name, wave: i}
+			var ro dlv1.Rollout
+			err := r.Get(ctx, client.ObjectKey{Namespace: g.Namespace, Name: name}, &ro)
+			switch {
+			case apierrors.IsNotFound(err):
+				missing = append(missing, name+" (not found)")
+			case err != nil:
+				return nil, nil, err
+			case ro.Labels[dlv1.GroupLabel] != "" && ro.Labels[dlv1.GroupLabel] != g.Name:
+				missing = append(missing, fmt.Sprintf("%s (member of group %s)", name, ro.Labels[dlv1.GroupLabel]))
+			default:
+				m.ro = &ro
+			}
+			members = append(members, m)
+		}
+	}
+	if len(missing) > 0 {
+		meta.SetStatusCondition(&g.Status.Conditions, metav1.Condition{
+			Type:               ConditionMembersReady,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: g.Generation,
+			Reason:             "MemberUnavailable",
+			Message:            strings.Join(missing, ", "),
+		})
+	} else {
+		meta.SetStatusCondition(&g.Status.Conditions, metav1.Condition{
+			Type:               ConditionMembersReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: g.Generation,
+			Reason:             "AllMembersFound",
+			Message:            fmt.Sprintf("%d rollouts in %d waves", len(members), len(g.Spec.Waves)),
+		})
+	}
+	return members, missing, nil
+}
+
+// updateMemberStatuses 汇总成员阶段与分析结果；基线版本只在成员首次出现在本轮发布时记录
+func (r *RolloutGroupReconciler) updateMemberStatuses(g *dlv1.RolloutGroup, members []groupMember) {
+	statuses := make([]dlv1.GroupMemberStatus, 0, len(members))
+	var failures []string
+	for _, m := range members {
+		st := dlv1.GroupMemberStatus{Name: m.name, Wave: int32(m.wave)}
+		prev := g.Status.Member(m.name)
+		if prev != nil {
+			st.BaselineRevision = prev.BaselineRevision
+		}
+		if m.ro != nil {
+			if prev == nil {
+				st.BaselineRevision = m.ro.Status.StableRevision
+			}
+			st.Phase = m.ro.Status.Phase
+			st.StepIndex = m.ro.Status.StepIndex
+			if c := meta.FindStatusCondition(m.ro.Status.Conditions, dlv1.ConditionAnalysisFailed); c != nil && c.Status == metav1.ConditionTrue {
+				st.AnalysisMessage = c.Message
+				failures = append(failures, m.name+": "+c.Message)
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	g.Status.Members = statuses
+
+	if len(failures) > 0 {
+		meta.SetStatusCondition(&g.Status.Conditions, metav1.Condition{
+			Type:               dlv1.ConditionAnalysisFailed,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: g.Generation,
+			Reason:             "MemberAnalysisFailed",
+			Message:            strings.Join(failures, "; "),
+		})
+	} else if meta.FindStatusCondition(g.Status.Conditions, dlv1.ConditionAnalysisFailed) != nil {
+		meta.SetStatusCondition(&g.Status.Conditions, metav1.Condition{
+			Type:               dlv1.ConditionAnalysisFailed,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: g.Generation,
+			Reason:             "Passing",
+			Message:            "no member analysis is failing",
+		})
+	}
+}
+
+// failedMember 返回本轮发布中回滚或失败的成员；
+// 以 AnalysisFailed/Aborted 条件的时间判断，上一轮遗留的 RolledBack 不会让新一轮立刻回滚
+func failedMember(g *dlv1.RolloutGroup, members []groupMember) *dlv1.Rollout {
+	for _, m := range members {
+		if m.ro == nil {
+			continue
+		}
+		if m.ro.Status.Phase != dlv1.PhaseRolledBack && m.ro.Status.Phase != dlv1.PhaseFailed {
+			continue
+		}
+		for _, t := range []string{dlv1.ConditionAnalysisFailed, dlv1.ConditionAborted} {
+			c := meta.FindStatusCondition(m.ro.Status.Conditions, t)
+			if c != nil && c.Status == metav1.ConditionTrue && g.Status.StartedAt != nil && !c.LastTransitionTime.Before(g.Status.StartedAt) {
+				return m.ro
+			}
+		}
+	}
+	return nil
+}
+
+// ensureMember 给成员打上分组与批次标签，并让它依赖上一波的全部成员；整组回滚后不再设置依赖
+func (r *RolloutGroupReconciler) ensureMember(ctx context.Context, g *dlv1.RolloutGroup, m groupMember) error {
+	var deps []dlv1.RolloutDependency
+	if m.wave > 0 && g.Status.Phase != dlv1.GroupRolledBack {
+		for _, name := range g.Spec.Waves[m.wave-1].Rollouts {
+			deps = append(deps, dlv1.RolloutDependency{Name: name})
+		}
+	}
+	ro := m.ro
+	if ro.Labels[dlv1.GroupLabel] == g.Name && ro.Labels[dlv1.ReleaseLabel] == g.Spec.Release &&
+		equality.Semantic.DeepEqual(ro.Spec.DependsOn, deps) {
+		return nil
+	}
+	base := ro.DeepCopy()
+	if ro.Labels == nil {
+		ro.Labels = map[string]string{}
+	}
+	ro.Labels[dlv1.GroupLabel] = g.Name
+	ro.Labels[dlv1.ReleaseLabel] = g.Spec.Release
+	ro.Spec.DependsOn = deps
+	return r.Patch(ctx, ro, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+}
+
+// rollbackMembers 中止本轮仍在进行的成员，把本轮已完成的成员恢复到基线版本（与 kubectl rollout undo 相同，经由金丝雀步骤发布）
+func (r *RolloutGroupReconciler) rollbackMembers(ctx context.Context, g *dlv1.RolloutGroup, members []groupMember) error {
+	lg := log.FromContext(ctx)
+	for _, m := range members {
+		ro := m.ro
+		if ro == nil {
+			continue
+		}
+		st := g.Status.Member(m.name)
+		// 已经处于基线版本（未参与本轮或已恢复）的成员不需要处理
+		if st == nil || ro.Status.CanaryRevision == st.BaselineRevision {
+			continue
+		}
+		switch {
+		case (ro.Status.Phase.InProgress() || ro.Status.Phase == dlv1.PhaseFailed) && !ro.Status.Abort:
+			lg.Info("Aborting group member", "member", m.name, "phase", ro.Status.Phase)
+			base := ro.DeepCopy()
+			ro.Status.Abort = true
+			if err := r.Status().Patch(ctx, ro, client.MergeFrom(base)); err != nil {
+				return err
+			}
+		case ro.Status.Phase == dlv1.PhaseSucceeded && st.BaselineRevision != "":
+			tpl, err := r.revisionTemplate(ctx, ro.Namespace, st.BaselineRevision)
+			if err != nil {
+				return err
+			}
+			if equality.Semantic.DeepEqual(ro.Spec.Template, tpl) {
+				continue
+			}
+			lg.Info("Restoring group member to its baseline revision", "member", m.name, "revision", st.BaselineRevision)
+			base := ro.DeepCopy()
+			ro.Spec.Template = tpl
+			if err := r.Patch(ctx, ro, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// revisionTemplate 读取 ControllerRevision 中保存的 Pod 模板
+func (r *RolloutGroupReconciler) revisionTemplate(ctx context.Context, namespace, name string) (*corev1.PodTemplateSpec, error) {
+	var cr appsv1.ControllerRevision
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &cr); err != nil {
+		return nil, fmt.Errorf("load revision %s: %w", name, err)
+	}
+	var tpl corev1.PodTemplateSpec
+	if err := json.Unmarshal(cr.Data.Raw, &tpl); err != nil {
+		return nil, fmt.Errorf("decode revision %s: %w", name, err)
+	}
+	return &tpl, nil
+}
+
+// finalize 删除前移除成员上的分组标签和由分组设置的依赖，成员 Rollout 本身保留
+func (r *RolloutGroupReconciler) finalize(ctx context.Context, g *dlv1.RolloutGroup) (ctrl.Result, error) {
+	lg := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(g, groupReleaseFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	var list dlv1.RolloutList
+	if err := r.List(ctx, &list, client.InNamespace(g.Namespace), client.MatchingLabels{dlv1.GroupLabel: g.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range list.Items {
+		ro := &list.Items[i]
+		base := ro.DeepCopy()
+		delete(ro.Labels, dlv1.GroupLabel)
+		ro.Spec.DependsOn = nil
+		if err := r.Patch(ctx, ro, client.MergeFrom(base)); err != nil {
+			lg.Error(err, "Failed to release group member", "member", ro.Name)
+			return ctrl.Result{}, err
+		}
+	}
+	controllerutil.RemoveFinalizer(g, groupReleaseFinalizer)
+	if err := r.Update(ctx, g); err != nil {
+		return ctrl.Result{}, err
+	}
+	lg.Info("Group members released, finalizer removed", "members", len(list.Items))
+	return ctrl.Result{}, nil
+}
+
+// groupForRollout 成员状态变化时调和所属的 RolloutGroup
+func (r *RolloutGroupReconciler) groupForRollout(_ context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[dlv1.GroupLabel]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: obj.GetNamespace(), Name: name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RolloutGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dlv1.RolloutGroup{}).
+		Watches(&dlv1.Rollout{}, handler.EnqueueRequestsFromMapFunc(r.groupForRollout)).
+		Complete(r)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	deliveryv1beta1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+var _ = Describe("RolloutGroup Controller", func() {
+	ctx := context.Background()
+	groupKey := types.NamespacedName{Name: "checkout", Namespace: "default"}
+	var reconciler *RolloutGroupReconciler
+
+	memberRollout := func(name string) *deliveryv1beta1.Rollout {
+		return &deliveryv1beta1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: deliveryv1beta1.RolloutSpec{
+				TargetRef: deliveryv1beta1.TargetRef{Kind: deliveryv1beta1.KindDeployment, Name: name, Port: 8080},
+				Strategy: deliveryv1beta1.RolloutStrategy{
+					Type:  deliveryv1beta1.Canary,
+					Steps: []deliveryv1beta1.RolloutStep{{Weight: 50}, {Weight: 100}},
+				},
+				Analysis: deliveryv1beta1.AnalysisSpec{
+					IntervalSeconds: 30, SuccessThreshold: 1, FailureThreshold: 1,
+					Metrics: []deliveryv1beta1.MetricCheck{{Name: "ok", PromQL: "vector(0)", Threshold: "1", Compare: deliveryv1beta1.CompareLT}},
+				},
+				Traffic: deliveryv1beta1.TrafficSpec{
+					Provider: "NginxIngress", Host: name + ".example.com",
+					StableService: name + "-stable", CanaryService: name + "-canary",
+				},
+			},
+		}
+	}
+	getRollout := func(name string) *deliveryv1beta1.Rollout {
+		ro := &deliveryv1beta1.Rollout{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, ro)).To(Succeed())
+		return ro
+	}
+	setStatus := func(name string, mutate func(*deliveryv1beta1.RolloutStatus)) {
+		ro := getRollout(name)
+		mutate(&ro.Status)
+		Expect(k8sClient.Status().Update(ctx, ro)).To(Succeed())
+	}
+	reconcileGroup := func() *deliveryv1beta1.RolloutGroup {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: groupKey})
+		Expect(err).NotTo(HaveOccurred())
+		g := &deliveryv1beta1.RolloutGroup{}
+		Expect(k8sClient.Get(ctx, groupKey, g)).To(Succeed())
+		return g
+	}
+
+	BeforeEach(func() {
+		for _, name := range []string{"backend", "frontend", "search"} {
+			ro := memberRollout(name)
+			Expect(k8sClient.Create(ctx, ro)).To(Succeed())
+			DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, ro))).To(Succeed()) })
+		}
+		setStatus("backend", func(s *deliveryv1beta1.RolloutStatus) {
+			s.Phase = deliveryv1beta1.PhaseProgressing
+			s.StableRevision = "backend-old"
+			s.CanaryRevision = "backend-new"
+		})
+
+		g := &deliveryv1beta1.RolloutGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: groupKey.Name, Namespace: groupKey.Namespace},
+			Spec: deliveryv1beta1.RolloutGroupSpec{
+				Release: "2025.03",
+				Waves: []deliveryv1beta1.RolloutWave{
+					{Rollouts: []string{"backend"}},
+					{Rollouts: []string{"frontend", "search"}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, g)).To(Succeed())
+		DeferCleanup(func() {
+			g := &deliveryv1beta1.RolloutGroup{}
+			if err := k8sClient.Get(ctx, groupKey, g); err != nil {
+				return
+			}
+			controllerutil.RemoveFinalizer(g, groupReleaseFinalizer)
+			Expect(k8sClient.Update(ctx, g)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, g))).To(Succeed())
+		})
+
+		reconciler = &RolloutGroupReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+	})
+
+	It("should chain waves through dependsOn and track progress", func() {
+		g := reconcileGroup()
+		Expect(g.Status.Phase).To(Equal(deliveryv1beta1.GroupProgressing))
+		Expect(g.Status.CurrentWave).To(Equal(int32(0)))
+		Expect(g.Status.Member("backend").BaselineRevision).To(Equal("backend-old"))
+		Expect(meta.IsStatusConditionTrue(g.Status.Conditions, ConditionMembersReady)).To(BeTrue())
+
+		backend := getRollout("backend")
+		Expect(backend.Labels).To(HaveKeyWithValue(deliveryv1beta1.GroupLabel, "checkout"))
+		Expect(backend.Labels).To(HaveKeyWithValue(deliveryv1beta1.ReleaseLabel, "2025.03"))
+		Expect(backend.Spec.DependsOn).To(BeEmpty())
+		Expect(getRollout("frontend").Spec.DependsOn).To(Equal([]deliveryv1beta1.RolloutDependency{{Name: "backend"}}))
+
+		By("Moving to the next wave once the first succeeded")
+		setStatus("backend", func(s *deliveryv1beta1.RolloutStatus) { s.Phase = deliveryv1beta1.PhaseSucceeded })
+		g = reconcileGroup()
+		Expect(g.Status.CurrentWave).To(Equal(int32(1)))
+
+		By("Succeeding once every member succeeded")
+		for _, name := range []string{"frontend", "search"} {
+			setStatus(name, func(s *deliveryv1beta1.RolloutStatus) { s.Phase = deliveryv1beta1.PhaseSucceeded })
+		}
+		g = reconcileGroup()
+		Expect(g.Status.Phase).To(Equal(deliveryv1beta1.GroupSucceeded))
+	})
+
+	It("should roll every member back when one fails", func() {
+		reconcileGroup()
+
+		By("Recording the baseline template of the first wave")
+		raw, err := json.Marshal(corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "backend"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "backend:1.0"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		cr := &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend-old", Namespace: "default"},
+			Data:       runtime.RawExtension{Raw: raw},
+			Revision:   1,
+		}
+		Expect(k8sClient.Create(ctx, cr)).To(Succeed())
+		DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, cr))).To(Succeed()) })
+
+		setStatus("backend", func(s *deliveryv1beta1.RolloutStatus) { s.Phase = deliveryv1beta1.PhaseSucceeded })
+		setStatus("search", func(s *deliveryv1beta1.RolloutStatus) {
+			s.Phase = deliveryv1beta1.PhaseAnalyzing
+			s.CanaryRevision = "search-new"
+		})
+		setStatus("frontend", func(s *deliveryv1beta1.RolloutStatus) {
+			s.Phase = deliveryv1beta1.PhaseRolledBack
+			s.CanaryRevision = "frontend-new"
+			meta.SetStatusCondition(&s.Conditions, metav1.Condition{
+				Type: deliveryv1beta1.ConditionAnalysisFailed, Status: metav1.ConditionTrue, Reason: "Auto", Message: "error-rate 0.2 >= 0.01",
+			})
+		})
+
+		g := reconcileGroup()
+		Expect(g.Status.Phase).To(Equal(deliveryv1beta1.GroupRolledBack))
+		Expect(meta.IsStatusConditionTrue(g.Status.Conditions, ConditionGroupRolledBack)).To(BeTrue())
+		Expect(meta.FindStatusCondition(g.Status.Conditions, deliveryv1beta1.ConditionAnalysisFailed).Message).To(ContainSubstring("frontend: error-rate"))
+		Expect(g.Status.Member("frontend").AnalysisMessage).To(ContainSubstring("error-rate"))
+
+		search := getRollout("search")
+		Expect(search.Status.Abort).To(BeTrue())
+		Expect(search.Spec.DependsOn).To(BeEmpty())
+
+		backend := getRollout("backend")
+		Expect(backend.Spec.Template).NotTo(BeNil())
+		Expect(backend.Spec.Template.Spec.Containers[0].Image).To(Equal("backend:1.0"))
+
+		By("Leaving the group rolled back until the release changes")
+		g = reconcileGroup()
+		Expect(g.Status.Phase).To(Equal(deliveryv1beta1.GroupRolledBack))
+		g.Spec.Release = "2025.04"
+		Expect(k8sClient.Update(ctx, g)).To(Succeed())
+		g = reconcileGroup()
+		Expect(g.Status.Phase).To(Equal(deliveryv1beta1.GroupProgressing))
+		Expect(getRollout("frontend").Spec.DependsOn).To(Equal([]deliveryv1beta1.RolloutDependency{{Name: "backend"}}))
+	})
+
+	It("should release its members when deleted", func() {
+		reconcileGroup()
+		g := &deliveryv1beta1.RolloutGroup{}
+		Expect(k8sClient.Get(ctx, groupKey, g)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, g)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: groupKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		frontend := getRollout("frontend")
+		Expect(frontend.Labels).NotTo(HaveKey(deliveryv1beta1.GroupLabel))
+		Expect(frontend.Spec.DependsOn).To(BeEmpty())
+	})
+})
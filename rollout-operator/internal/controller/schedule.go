@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/schedule"
+)
+
+// scheduleRecheckInterval 找不到下一个窗口时（如封禁日期覆盖了之后所有窗口）重新检查的间隔
+const scheduleRecheckInterval = time.Hour
+
+// scheduleFor 将 spec.schedule 转换为 schedule.Schedule；未设置时返回 nil
+func scheduleFor(ro *dlv1.Rollout) (*schedule.Schedule, error) {
+	spec := ro.Spec.Schedule
+	if spec == nil {
+		return nil, nil
+	}
+	s := &schedule.Schedule{Location: time.UTC}
+	if spec.TimeZone != "" {
+		loc, err := time.LoadLocation(spec.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("time zone %q: %w", spec.TimeZone, err)
+		}
+		s.Location = loc
+	}
+	for _, w := range spec.Windows {
+		c, err := schedule.ParseCron(w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", w.Start, err)
+		}
+		s.Windows = append(s.Windows, schedule.Window{Start: c, Duration: w.Duration.Duration})
+	}
+	for _, b := range spec.Blackouts {
+		blackout, err := schedule.ParseBlackout(b)
+		if err != nil {
+			return nil, err
+		}
+		s.Blackouts = append(s.Blackouts, blackout)
+	}
+	return s, nil
+}
+
+// waitForSchedule 判断现在是否允许推进发布；不允许时设置 Waiting 条件并返回到下一个窗口的等待时间
+func (r *RolloutReconciler) waitForSchedule(ctx context.Context, ro *dlv1.Rollout, now time.Time) (time.Duration, error) {
+	lg := log.FromContext(ctx)
+	s, err := scheduleFor(ro)
+	if err != nil {
+		return 0, err
+	}
+	allowed, reason := true, schedule.Reason("")
+	if s != nil {
+		allowed, reason = s.Allowed(now)
+	}
+	if allowed {
+		if meta.IsStatusConditionTrue(ro.Status.Conditions, dlv1.ConditionWaiting) {
+			lg.Info("Rollout window open, resuming")
+			meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+				Type:               dlv1.ConditionWaiting,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: ro.Generation,
+				Reason:             "InWindow",
+				Message:            "rollout window open",
+			})
+		}
+		return 0, nil
+	}
+
+	wait := scheduleRecheckInterval
+	msg := "no upcoming rollout window"
+	if next, ok := s.NextAllowed(now); ok {
+		wait = next.Sub(now)
+		msg = fmt.Sprintf("holding at step %d until %s", ro.Status.StepIndex, next.Format(time.RFC3339))
+	}
+	if reason == schedule.ReasonBlackout {
+		msg = "blackout date, " + msg
+	} else {
+		msg = "outside rollout window, " + msg
+	}
+	lg.Info("Outside rollout window, holding current step", "reason", reason, "stepIndex", ro.Status.StepIndex, "wait", wait.String())
+	if !meta.IsStatusConditionTrue(ro.Status.Conditions, dlv1.ConditionWaiting) && r.Recorder != nil {
+		r.Recorder.Event(ro, corev1.EventTypeNormal, dlv1.ConditionWaiting, msg)
+	}
+	meta.SetStatusCondition(&ro.Status.Conditions, metav1.Condition{
+		Type:               dlv1.ConditionWaiting,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: ro.Generation,
+		Reason:             string(reason),
+		Message:            msg,
+	})
+	return wait, nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/traffic"
+)
+
+// workload 按 targetRef.kind 管理 stable/canary 两个版本的 Pod 及对应的 Service
+type workload interface {
+	// Ensure 确保工作负载与 stable/canary Service 存在，且 Pod 模板与两个版本一致
+	Ensure(ctx context.Context, ro *dlv1.Rollout, stable, canary corev1.PodTemplateSpec) error
+	// SetWeight 在工作负载层面体现金丝雀比例；只靠流量层分流的实现直接返回
+	SetWeight(ctx context.Context, ro *dlv1.Rollout, weight int32) error
+	// AnalysisLabels 分析引擎检查金丝雀就绪时使用的标签
+	AnalysisLabels(ro *dlv1.Rollout) map[string]string
+}
+
+// workloadFor 根据 targetRef.kind 选择工作负载实现
+func (r *RolloutReconciler) workloadFor(ro *dlv1.Rollout) (workload, error) {
+	switch ro.Spec.TargetRef.Kind {
+	case dlv1.KindDeployment:
+		return &deploymentWorkload{Client: r.Client, Scheme: r.Scheme}, nil
+	case dlv1.KindStatefulSet:
+		return &statefulSetWorkload{Client: r.Client, Scheme: r.Scheme}, nil
+	}
+	return nil, fmt.Errorf("unsupported targetRef kind %q", ro.Spec.TargetRef.Kind)
+}
+
+// ensureWorkloads 按当前版本计算 stable/canary 模板，交给对应的工作负载实现。
+// holdCanary 时 canary 继续使用 stable 版本的模板，新版本等发布前的检查全部通过后再下发
+func (r *RolloutReconciler) ensureWorkloads(ctx context.Context, ro *dlv1.Rollout, wl workload, holdCanary bool) error {
+	if ro.Status.Undo != nil {
+		return r.ensureUndoWorkloads(ctx, ro, wl, holdCanary)
+	}
+	stable, err := r.workloadTemplate(ctx, ro, "stable")
+	if err != nil {
+		return err
+	}
+	var canary corev1.PodTemplateSpec
+	if holdCanary {
+		canary, err = r.revisionPodTemplate(ctx, ro, ro.Status.StableRevision, "canary")
+	} else {
+		canary, err = r.workloadTemplate(ctx, ro, "canary")
+	}
+	if err != nil {
+		return err
+	}
+	return wl.Ensure(ctx, ro, stable, applyPlacement(canary, ro.Spec.Placement))
+}
+
+// ensureUndoWorkloads 倒序发布时目标版本放到 stable，canary 保留 undo 开始时的版本直到发布成功；
+// hold 时 stable 继续使用原 stable 版本，目标版本等发布前的检查全部通过后再下发
+func (r *RolloutReconciler) ensureUndoWorkloads(ctx context.Context, ro *dlv1.Rollout, wl workload, hold bool) error {
+	stable, canary := podTemplate(ro, "stable"), podTemplate(ro, "canary")
+	var err error
+	if hold {
+		if stable, err = r.revisionPodTemplate(ctx, ro, ro.Status.StableRevision, "stable"); err != nil {
+			return err
+		}
+	}
+	if ro.Status.Phase != dlv1.PhaseSucceeded {
+		if canary, err = r.revisionPodTemplate(ctx, ro, ro.Status.Undo.FromRevision, "canary"); err != nil {
+			return err
+		}
+	}
+	return wl.Ensure(ctx, ro, stable, applyPlacement(canary, ro.Spec.Placement))
+}
+
+// applyPlacement 把 spec.placement 注入 canary 的 Pod 模板：按拓扑键打散并可选固定到 canary 节点池。
+// 模板中已有的同一拓扑键约束保持不变
+func applyPlacement(tpl corev1.PodTemplateSpec, p *dlv1.CanaryPlacement) corev1.PodTemplateSpec {
+	if p == nil {
+		return tpl
+	}
+	tpl = *tpl.DeepCopy()
+	when := corev1.ScheduleAnyway
+	if p.SpreadMode == dlv1.SpreadRequired {
+		when = corev1.DoNotSchedule
+	}
+	for _, key := range p.SpreadAcross {
+		if hasSpreadConstraint(tpl.Spec.TopologySpreadConstraints, key) {
+			continue
+		}
+		// 同一版本的 canary Pod 之间打散：Deployment 按 track 区分，StatefulSet 按 controller-revision-hash 区分；
+		// Pod 上不存在的键会被调度器忽略
+		tpl.Spec.TopologySpreadConstraints = append(tpl.Spec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       key,
+			WhenUnsatisfiable: when,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": tpl.Labels["app"]}},
+			MatchLabelKeys:    []string{"track", appsv1.ControllerRevisionHashLabelKey},
+		})
+	}
+	if len(p.NodeSelector) > 0 && tpl.Spec.NodeSelector == nil {
+		tpl.Spec.NodeSelector = map[string]string{}
+	}
+	for k, v := range p.NodeSelector {
+		tpl.Spec.NodeSelector[k] = v
+	}
+	for _, t := range p.Tolerations {
+		if !hasToleration(tpl.Spec.Tolerations, t) {
+			tpl.Spec.Tolerations = append(tpl.Spec.Tolerations, t)
+		}
+	}
+	return tpl
+}
+
+func hasSpreadConstraint(cs []corev1.TopologySpreadConstraint, key string) bool {
+	for _, c := range cs {
+		if c.TopologyKey == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasToleration(ts []corev1.Toleration, t corev1.Toleration) bool {
+	for i := range ts {
+		if equality.Semantic.DeepEqual(ts[i], t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureService 确保 Service 存在、由 Rollout 控制且选择器与 selector 一致
+func ensureService(ctx context.Context, c client.Client, scheme *runtime.Scheme, ro *dlv1.Rollout, name, track string, selector map[string]string) error {
+	lg := log.FromContext(ctx)
+	// 统一的对象标签（用于 kubectl -l 选择器）
+	objLabels := map[string]string{
+		"app":                  ro.Spec.TargetRef.Name,
+		"track":                track,
+		traffic.ManagedByLabel: traffic.ManagedByValue,
+	}
+	var svc corev1.Service
+	if err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: ro.Namespace}, &svc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		newSvc := corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ro.Namespace,
+				Labels:    objLabels,
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: selector,
+				Ports: []corev1.ServicePort{{
+					Port:       ro.Spec.TargetRef.Port,
+					TargetPort: intstr.FromInt(int(ro.Spec.TargetRef.Port)),
+				}},
+			},
+		}
+		if err := controllerutil.SetControllerReference(ro, &newSvc, scheme); err != nil {
+			return err
+		}
+		lg.Info("Creating Service", "name", name, "labels", objLabels, "selector", selector)
+		return c.Create(ctx, &newSvc)
+	}
+
+	// 已存在：确保对象标签齐全
+	if svc.Labels == nil {
+		svc.Labels = map[string]string{}
+	}
+	changed := false
+	for k, v := range objLabels {
+		if svc.Labels[k] != v {
+			svc.Labels[k] = v
+			changed = true
+		}
+	}
+	if !equality.Semantic.DeepEqual(svc.Spec.Selector, selector) {
+		svc.Spec.Selector = selector
+		changed = true
+	}
+	// 确保已有 Service 的 OwnerReference 指向当前 Rollout
+	if !metav1.IsControlledBy(&svc, ro) {
+		if err := controllerutil.SetControllerReference(ro, &svc, scheme); err != nil {
+			lg.Info("Skip setting ownerRef for Service (already controlled)", "name", name, "err", err.Error())
+		} else {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	lg.Info("Updating Service", "name", name, "labels", svc.Labels, "selector", svc.Spec.Selector)
+	return c.Update(ctx, &svc)
+}
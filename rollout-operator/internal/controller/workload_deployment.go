@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/traffic"
+)
+
+// deploymentWorkload stable/canary 各一个 Deployment，金丝雀比例完全由流量层控制
+type deploymentWorkload struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (w *deploymentWorkload) Ensure(ctx context.Context, ro *dlv1.Rollout, stable, canary corev1.PodTemplateSpec) error {
+	for _, track := range []string{"stable", "canary"} {
+		svcName, template := ro.Spec.Traffic.StableService, stable
+		if track == "canary" {
+			svcName, template = ro.Spec.Traffic.CanaryService, canary
+		}
+		if err := w.ensureDeployment(ctx, ro, track, template); err != nil {
+			return err
+		}
+		selector := map[string]string{"app": ro.Spec.TargetRef.Name, "track": track}
+		if err := ensureService(ctx, w.Client, w.Scheme, ro, svcName, track, selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *deploymentWorkload) ensureDeployment(ctx context.Context, ro *dlv1.Rollout, track string, template corev1.PodTemplateSpec) error {
+	lg := log.FromContext(ctx)
+	depName := ro.Name + "-" + track
+	// 统一的对象标签（用于 kubectl -l 选择器）
+	objLabels := map[string]string{
+		"app":                  ro.Spec.TargetRef.Name,
+		"track":                track,
+		traffic.ManagedByLabel: traffic.ManagedByValue,
+	}
+	lg.Info("Ensuring workload", "deployment", depName, "labels", objLabels)
+
+	var dep appsv1.Deployment
+	if err := w.Get(ctx, client.ObjectKey{Name: depName, Namespace: ro.Namespace}, &dep); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		replicas := int32(2)
+		newDep := appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      depName,
+				Namespace: ro.Namespace,
+				Labels:    objLabels,
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": ro.Spec.TargetRef.Name, "track": track},
+				},
+				Template: template,
+			},
+		}
+		// 设置 OwnerReference，方便级联与事件追踪
+		if err := controllerutil.SetControllerReference(ro, &newDep, w.Scheme); err != nil {
+			return err
+		}
+		lg.Info("Creating Deployment", "name", depName, "labels", objLabels)
+		return w.Create(ctx, &newDep)
+	}
+
+	// 已存在：确保对象标签齐全
+	if dep.Labels == nil {
+		dep.Labels = map[string]string{}
+	}
+	changed := false
+	for k, v := range objLabels {
+		if dep.Labels[k] != v {
+			dep.Labels[k] = v
+			changed = true
+		}
+	}
+	// 模板与当前版本不一致时更新（新版本进入 canary、发布成功后 stable 跟进）
+	if !equality.Semantic.DeepDerivative(template, dep.Spec.Template) {
+		lg.Info("Updating Deployment pod template", "name", depName, "canaryRevision", ro.Status.CanaryRevision, "stableRevision", ro.Status.StableRevision)
+		dep.Spec.Template = template
+		changed = true
+	}
+	// 确保已有 Deployment 的 OwnerReference 指向当前 Rollout
+	if !metav1.IsControlledBy(&dep, ro) {
+		if err := controllerutil.SetControllerReference(ro, &dep, w.Scheme); err != nil {
+			lg.Info("Skip setting ownerRef for Deployment (already controlled)", "name", depName, "err", err.Error())
+		} else {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	lg.Info("Updating Deployment", "name", depName, "labels", dep.Labels)
+	return w.Update(ctx, &dep)
+}
+
+func (w *deploymentWorkload) SetWeight(context.Context, *dlv1.Rollout, int32) error {
+	return nil
+}
+
+// AnalysisLabels 倒序发布时正在放量的目标版本在 stable Deployment 上
+func (w *deploymentWorkload) AnalysisLabels(ro *dlv1.Rollout) map[string]string {
+	track := "canary"
+	if ro.Status.Undo != nil {
+		track = "stable"
+	}
+	return map[string]string{
+		"app":        ro.Spec.TargetRef.Name,
+		"deployment": ro.Name + "-" + track,
+		"namespace":  ro.Namespace,
+	}
+}
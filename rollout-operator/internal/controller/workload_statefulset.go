@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+	"github.com/ormasia/rollout-operator/pkg/traffic"
+)
+
+// statefulSetReplicas 新建 StatefulSet 的副本数；partition 按副本分批，4 个副本对应 25% 的粒度
+const statefulSetReplicas = int32(4)
+
+// statefulSetWorkload 单个 StatefulSet：用 updateStrategy.rollingUpdate.partition 控制升级到新版本的 Pod 数，
+// stable/canary Service 按 controller-revision-hash 分别选中旧版本与新版本的 Pod
+type statefulSetWorkload struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (w *statefulSetWorkload) Ensure(ctx context.Context, ro *dlv1.Rollout, stable, canary corev1.PodTemplateSpec) error {
+	lg := log.FromContext(ctx)
+	// 回滚后模板改回 stable 版本，partition 归零让已升级的 Pod 全部回到旧版本
+	rolledBack := ro.Status.Phase == dlv1.PhaseRolledBack
+	template := canary
+	if rolledBack {
+		template = stable
+	}
+	template = *template.DeepCopy()
+	delete(template.Labels, "track")
+	objLabels := map[string]string{"app": ro.Spec.TargetRef.Name, traffic.ManagedByLabel: traffic.ManagedByValue}
+	lg.Info("Ensuring workload", "statefulset", ro.Name, "labels", objLabels)
+
+	var sts appsv1.StatefulSet
+	err := w.Get(ctx, client.ObjectKey{Name: ro.Name, Namespace: ro.Namespace}, &sts)
+	switch {
+	case apierrors.IsNotFound(err):
+		replicas, partition := statefulSetReplicas, int32(0)
+		sts = appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ro.Name,
+				Namespace: ro.Namespace,
+				Labels:    objLabels,
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:    &replicas,
+				ServiceName: ro.Spec.Traffic.StableService,
+				Selector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": ro.Spec.TargetRef.Name}},
+				Template:    template,
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+					Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+				},
+			},
+		}
+		if err := controllerutil.SetControllerReference(ro, &sts, w.Scheme); err != nil {
+			return err
+		}
+		lg.Info("Creating StatefulSet", "name", ro.Name, "labels", objLabels)
+		if err := w.Create(ctx, &sts); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		if err := w.updateStatefulSet(ctx, ro, &sts, objLabels, template, rolledBack); err != nil {
+			return err
+		}
+	}
+
+	// 新建或升级完成时 currentRevision 与 updateRevision 相同，两个 Service 选中全部 Pod
+	for _, svc := range []struct{ name, track, revision string }{
+		{ro.Spec.Traffic.StableService, "stable", sts.Status.CurrentRevision},
+		{ro.Spec.Traffic.CanaryService, "canary", sts.Status.UpdateRevision},
+	} {
+		selector := map[string]string{"app": ro.Spec.TargetRef.Name}
+		if svc.revision != "" {
+			selector[appsv1.ControllerRevisionHashLabelKey] = svc.revision
+		}
+		if err := ensureService(ctx, w.Client, w.Scheme, ro, svc.name, svc.track, selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateStatefulSet 同步标签与 OwnerReference；模板变化时同时设置 partition，
+// 新版本先不升级任何 Pod（回滚时升级全部），由后续 SetWeight 逐步放开
+func (w *statefulSetWorkload) updateStatefulSet(ctx context.Context, ro *dlv1.Rollout, sts *appsv1.StatefulSet, objLabels map[string]string, template corev1.PodTemplateSpec, rolledBack bool) error {
+	lg := log.FromContext(ctx)
+	if sts.Labels == nil {
+		sts.Labels = map[string]string{}
+	}
+	changed := false
+	for k, v := range objLabels {
+		if sts.Labels[k] != v {
+			sts.Labels[k] = v
+			changed = true
+		}
+	}
+	if !equality.Semantic.DeepDerivative(template, sts.Spec.Template) {
+		partition := stsReplicas(sts)
+		if rolledBack {
+			partition = 0
+		}
+		lg.Info("Updating StatefulSet pod template", "name", sts.Name, "partition", partition, "canaryRevision", ro.Status.CanaryRevision, "stableRevision", ro.Status.StableRevision)
+		sts.Spec.Template = template
+		setPartition(sts, partition)
+		changed = true
+	}
+	if !metav1.IsControlledBy(sts, ro) {
+		if err := controllerutil.SetControllerReference(ro, sts, w.Scheme); err != nil {
+			lg.Info("Skip setting ownerRef for StatefulSet (already controlled)", "name", sts.Name, "err", err.Error())
+		} else {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return w.Update(ctx, sts)
+}
+
+// SetWeight 按权重计算需要升级的 Pod 数（向上取整），partition 为其余 Pod 数
+func (w *statefulSetWorkload) SetWeight(ctx context.Context, ro *dlv1.Rollout, weight int32) error {
+	var sts appsv1.StatefulSet
+	if err := w.Get(ctx, client.ObjectKey{Name: ro.Name, Namespace: ro.Namespace}, &sts); err != nil {
+		return err
+	}
+	replicas := stsReplicas(&sts)
+	partition := replicas - (replicas*weight+99)/100
+	if partition < 0 {
+		partition = 0
+	}
+	if p := sts.Spec.UpdateStrategy.RollingUpdate; p != nil && p.Partition != nil && *p.Partition == partition {
+		return nil
+	}
+	log.FromContext(ctx).Info("Setting StatefulSet partition", "name", sts.Name, "weight", weight, "partition", partition, "replicas", replicas)
+	setPartition(&sts, partition)
+	return w.Update(ctx, &sts)
+}
+
+func (w *statefulSetWorkload) AnalysisLabels(ro *dlv1.Rollout) map[string]string {
+	return map[string]string{
+		"app":         ro.Spec.TargetRef.Name,
+		"statefulset": ro.Name,
+		"namespace":   ro.Namespace,
+	}
+}
+
+func stsReplicas(sts *appsv1.StatefulSet) int32 {
+	if sts.Spec.Replicas == nil {
+		return 1
+	}
+	return *sts.Spec.Replicas
+}
+
+func setPartition(sts *appsv1.StatefulSet, partition int32) {
+	sts.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	if sts.Spec.UpdateStrategy.RollingUpdate == nil {
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+	}
+	sts.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+}
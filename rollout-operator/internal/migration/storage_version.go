@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+// RolloutCRDName Rollout CRD 的名称
+const RolloutCRDName = "rollouts.delivery.example.com"
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=get;update;patch
+
+// StorageVersionMigrator 把仍以旧版本存储在 etcd 中的 Rollout 按当前存储版本重写一遍，
+// 全部重写成功后才将 CRD 的 status.storedVersions 收敛为存储版本，之后旧版本才能从 CRD 中安全移除。
+// 重写只是一次不改内容的 Update：先以 dry-run 提交，准入 webhook 会修改内容的对象不重写，进行中的发布不受影响。
+type StorageVersionMigrator struct {
+	Client client.Client
+	// Reader 直接读 API server，避免为一次性迁移建立 CRD informer
+	Reader client.Reader
+}
+
+// NeedLeaderElection 只在 leader 上执行迁移
+func (m *StorageVersionMigrator) NeedLeaderElection() bool { return true }
+
+// Start 实现 manager.Runnable；迁移失败只记录日志，不影响 manager 运行，storedVersions 保持不变
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	lg := log.FromContext(ctx).WithName("storage-version-migrator")
+	if err := m.Migrate(log.IntoContext(ctx, lg)); err != nil {
+		lg.Error(err, "Failed to migrate Rollout storage version")
+	}
+	return nil
+}
+
+// Migrate 执行一次迁移
+func (m *StorageVersionMigrator) Migrate(ctx context.Context) error {
+	lg := log.FromContext(ctx)
+	storage := dlv1.GroupVersion.Version
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := m.Reader.Get(ctx, client.ObjectKey{Name: RolloutCRDName}, &crd); err != nil {
+		return fmt.Errorf("get CRD %s: %w", RolloutCRDName, err)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storage {
+		lg.Info("Rollout storage version already up to date", "version", storage)
+		return nil
+	}
+	lg.Info("Migrating Rollouts to storage version", "from", crd.Status.StoredVersions, "to", storage)
+
+	var list dlv1.RolloutList
+	if err := m.Reader.List(ctx, &list); err != nil {
+		return fmt.Errorf("list rollouts: %w", err)
+	}
+	failed := 0
+	for i := range list.Items {
+		key := client.ObjectKeyFromObject(&list.Items[i])
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			return m.rewrite(ctx, key)
+		})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			lg.Error(err, "Failed to rewrite Rollout in storage version", "rollout", key)
+			failed++
+			continue
+		}
+		lg.Info("Rewrote Rollout in storage version", "rollout", key)
+	}
+	// 还有对象以旧版本存储时 storedVersions 保持不变，下次启动时重试
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rollouts could not be rewritten; storedVersions left as %v", failed, len(list.Items), crd.Status.StoredVersions)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := m.Reader.Get(ctx, client.ObjectKey{Name: RolloutCRDName}, &crd); err != nil {
+			return err
+		}
+		crd.Status.StoredVersions = []string{storage}
+		return m.Client.Status().Update(ctx, &crd)
+	})
+}
+
+// rewrite 原样 Update 一个 Rollout；dry-run 的结果与读到的内容不一致（被 webhook 修改）时不提交
+func (m *StorageVersionMigrator) rewrite(ctx context.Context, key client.ObjectKey) error {
+	var ro dlv1.Rollout
+	if err := m.Reader.Get(ctx, key, &ro); err != nil {
+		return err
+	}
+	dry := ro.DeepCopy()
+	if err := m.Client.Update(ctx, dry, client.DryRunAll); err != nil {
+		return err
+	}
+	if !equality.Semantic.DeepEqual(dry.Spec, ro.Spec) ||
+		!equality.Semantic.DeepEqual(dry.Labels, ro.Labels) ||
+		!equality.Semantic.DeepEqual(dry.Annotations, ro.Annotations) ||
+		!equality.Semantic.DeepEqual(dry.Finalizers, ro.Finalizers) {
+		return fmt.Errorf("admission webhooks would change the rollout on update; fix its spec so it passes them unchanged")
+	}
+	return m.Client.Update(ctx, &ro)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+var _ = Describe("StorageVersionMigrator", func() {
+	ctx := context.Background()
+
+	newClient := func(funcs *interceptor.Funcs, storedVersions ...string) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(dlv1.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: RolloutCRDName},
+			Status:     apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+		}
+		b := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(crd).
+			WithObjects(crd,
+				&dlv1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+				&dlv1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "team"}},
+			)
+		if funcs != nil {
+			b = b.WithInterceptorFuncs(*funcs)
+		}
+		return b.Build()
+	}
+
+	resourceVersions := func(c client.Client) map[string]string {
+		var list dlv1.RolloutList
+		Expect(c.List(ctx, &list)).To(Succeed())
+		out := map[string]string{}
+		for _, ro := range list.Items {
+			out[ro.Namespace+"/"+ro.Name] = ro.ResourceVersion
+		}
+		return out
+	}
+
+	It("rewrites every rollout and trims storedVersions", func() {
+		c := newClient(nil, "v1alpha1", "v1beta1")
+		before := resourceVersions(c)
+
+		m := &StorageVersionMigrator{Client: c, Reader: c}
+		Expect(m.Migrate(ctx)).To(Succeed())
+
+		after := resourceVersions(c)
+		Expect(after).To(HaveLen(2))
+		for k, rv := range after {
+			Expect(rv).NotTo(Equal(before[k]), "rollout %s was not rewritten", k)
+		}
+		var crd apiextensionsv1.CustomResourceDefinition
+		Expect(c.Get(ctx, client.ObjectKey{Name: RolloutCRDName}, &crd)).To(Succeed())
+		Expect(crd.Status.StoredVersions).To(Equal([]string{"v1beta1"}))
+	})
+
+	storedVersions := func(c client.Client) []string {
+		var crd apiextensionsv1.CustomResourceDefinition
+		Expect(c.Get(ctx, client.ObjectKey{Name: RolloutCRDName}, &crd)).To(Succeed())
+		return crd.Status.StoredVersions
+	}
+
+	It("keeps storedVersions when a rollout is rejected by the webhooks", func() {
+		c := newClient(&interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if obj.GetName() == "b" {
+					return apierrors.NewInvalid(dlv1.GroupVersion.WithKind("Rollout").GroupKind(), "b", field.ErrorList{
+						field.Invalid(field.NewPath("spec", "analysis", "successThreshold"), 0, "must be >= 1"),
+					})
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}, "v1alpha1", "v1beta1")
+		before := resourceVersions(c)
+
+		m := &StorageVersionMigrator{Client: c, Reader: c}
+		Expect(m.Migrate(ctx)).To(MatchError(ContainSubstring("1 of 2 rollouts could not be rewritten")))
+		after := resourceVersions(c)
+		Expect(after["default/a"]).NotTo(Equal(before["default/a"]))
+		Expect(after["team/b"]).To(Equal(before["team/b"]))
+		Expect(storedVersions(c)).To(Equal([]string{"v1alpha1", "v1beta1"}))
+	})
+
+	It("does not rewrite a rollout that the webhooks would change", func() {
+		c := newClient(&interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				// 模拟 defaulting webhook 补全字段
+				if ro, ok := obj.(*dlv1.Rollout); ok && ro.Spec.Strategy.Type == "" {
+					ro.Spec.Strategy.Type = dlv1.Canary
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}, "v1alpha1", "v1beta1")
+		before := resourceVersions(c)
+
+		m := &StorageVersionMigrator{Client: c, Reader: c}
+		Expect(m.Migrate(ctx)).To(MatchError(ContainSubstring("2 of 2 rollouts could not be rewritten")))
+		Expect(resourceVersions(c)).To(Equal(before))
+		var ro dlv1.Rollout
+		Expect(c.Get(ctx, client.ObjectKey{Name: "a", Namespace: "default"}, &ro)).To(Succeed())
+		Expect(ro.Spec.Strategy.Type).To(BeEmpty())
+		Expect(storedVersions(c)).To(Equal([]string{"v1alpha1", "v1beta1"}))
+	})
+
+	It("does nothing once only the storage version is stored", func() {
+		c := newClient(nil, "v1beta1")
+		before := resourceVersions(c)
+
+		m := &StorageVersionMigrator{Client: c, Reader: c}
+		Expect(m.Migrate(ctx)).To(Succeed())
+		Expect(resourceVersions(c)).To(Equal(before))
+	})
+})
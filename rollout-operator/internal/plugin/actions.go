@@ -0,0 +1,222 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+func newPromoteCommand(o *Options) *cobra.Command {
+	var full bool
+	cmd := &cobra.Command{
+		Use:   "promote NAME",
+		Short: "Skip the current hold, or accept a paused step; with --full jump straight to 100%",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mutate := promote
+			if full {
+				mutate = promoteFull
+			}
+			msg, err := o.updateStatus(cmd.Context(), args[0], mutate)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "rollout %q %s\n", args[0], msg)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&full, "full", false, "skip all remaining steps and analysis and promote the canary to 100%")
+	return cmd
+}
+
+func newAbortCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "abort NAME",
+		Short: "Send all traffic back to stable and mark the Rollout RolledBack",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg, err := o.updateStatus(cmd.Context(), args[0], abort)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "rollout %q %s\n", args[0], msg)
+			return nil
+		},
+	}
+}
+
+func newRetryCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry NAME",
+		Short: "Restart a rolled back or failed Rollout from its first step",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg, err := o.updateStatus(cmd.Context(), args[0], retryRollout)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "rollout %q %s\n", args[0], msg)
+			return nil
+		},
+	}
+}
+
+// newPauseCommand pause=true 为 pause，false 为 resume
+func newPauseCommand(o *Options, pause bool) *cobra.Command {
+	use, short := "pause NAME", "Stop the Rollout from advancing to the next step"
+	if !pause {
+		use, short = "resume NAME", "Continue a paused Rollout; re-runs the analysis of a step paused by failurePolicy Manual"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg, err := o.setPaused(cmd.Context(), args[0], pause)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "rollout %q %s\n", args[0], msg)
+			return nil
+		},
+	}
+}
+
+// statusMutation 在最新的 Rollout 上修改状态，返回给用户的提示；返回错误表示当前状态不允许该操作
+type statusMutation func(ro *dlv1.Rollout, now metav1.Time) (string, error)
+
+// updateStatus 以带 resourceVersion 的 merge patch 写状态，与控制器并发写入冲突时重新读取后重试
+func (o *Options) updateStatus(ctx context.Context, name string, mutate statusMutation) (string, error) {
+	var msg string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ro, err := o.get(ctx, name)
+		if err != nil {
+			return err
+		}
+		base := ro.DeepCopy()
+		if msg, err = mutate(ro, metav1.NewTime(o.Now())); err != nil {
+			return err
+		}
+		return o.Client.Status().Patch(ctx, ro, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+	return msg, err
+}
+
+func promote(ro *dlv1.Rollout, now metav1.Time) (string, error) {
+	if ro.Spec.Paused {
+		return "", fmt.Errorf("rollout %q is paused; run resume first", ro.Name)
+	}
+	switch ro.Status.Phase {
+	case dlv1.PhasePaused:
+		// 接受分析失败的当前步骤，进入下一步
+		ro.Status.StepIndex++
+		ro.Status.Phase = dlv1.PhaseProgressing
+		meta.RemoveStatusCondition(&ro.Status.Conditions, dlv1.ConditionAnalysisFailed)
+		return fmt.Sprintf("promoted past failed step %d", ro.Status.StepIndex-1), nil
+	case dlv1.PhaseProgressing:
+		if ro.Status.StepIndex > 0 {
+			if st := ro.Status.StepStatus(ro.Status.StepIndex - 1); st != nil && st.HoldUntil != nil && now.Before(st.HoldUntil) {
+				st.HoldUntil = now.DeepCopy()
+				return fmt.Sprintf("hold of step %d skipped", st.Index), nil
+			}
+		}
+		return "", fmt.Errorf("rollout %q has no hold to skip at step %d", ro.Name, ro.Status.StepIndex)
+	}
+	return "", fmt.Errorf("rollout %q cannot be promoted in phase %s", ro.Name, orNone(string(ro.Status.Phase)))
+}
+
+func promoteFull(ro *dlv1.Rollout, now metav1.Time) (string, error) {
+	if !ro.Status.Phase.InProgress() {
+		return "", fmt.Errorf("rollout %q cannot be promoted in phase %s", ro.Name, orNone(string(ro.Status.Phase)))
+	}
+	last := int32(len(ro.CanarySteps()))
+	// 最后一步若已通过分析仍在 hold，也一并跳过
+	if st := ro.Status.StepStatus(last - 1); st != nil && st.HoldUntil != nil && now.Before(st.HoldUntil) {
+		st.HoldUntil = now.DeepCopy()
+	}
+	ro.Status.StepIndex = last
+	ro.Status.Phase = dlv1.PhaseProgressing
+	meta.RemoveStatusCondition(&ro.Status.Conditions, dlv1.ConditionAnalysisFailed)
+	return "fully promoted", nil
+}
+
+func abort(ro *dlv1.Rollout, _ metav1.Time) (string, error) {
+	if !ro.Status.Phase.InProgress() && ro.Status.Phase != dlv1.PhaseFailed {
+		return "", fmt.Errorf("rollout %q cannot be aborted in phase %s", ro.Name, orNone(string(ro.Status.Phase)))
+	}
+	ro.Status.Abort = true
+	return "abort requested", nil
+}
+
+func retryRollout(ro *dlv1.Rollout, _ metav1.Time) (string, error) {
+	if ro.Status.Phase != dlv1.PhaseRolledBack && ro.Status.Phase != dlv1.PhaseFailed {
+		return "", fmt.Errorf("rollout %q cannot be retried in phase %s", ro.Name, orNone(string(ro.Status.Phase)))
+	}
+	ro.Status.Phase = ""
+	ro.Status.StepIndex = 0
+	ro.Status.Steps = nil
+	ro.Status.Hooks = nil
+	ro.Status.Abort = false
+	for _, t := range []string{dlv1.ConditionAnalysisFailed, dlv1.ConditionAborted, dlv1.ConditionTrafficDrift, dlv1.ConditionHookFailed} {
+		meta.RemoveStatusCondition(&ro.Status.Conditions, t)
+	}
+	return "restarted from step 0", nil
+}
+
+// setPaused 修改 spec.paused；resume 同时让 failurePolicy Manual 暂停的步骤重新分析
+func (o *Options) setPaused(ctx context.Context, name string, pause bool) (string, error) {
+	ro, err := o.get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if ro.Spec.Paused != pause {
+		base := ro.DeepCopy()
+		ro.Spec.Paused = pause
+		if err := o.Client.Patch(ctx, ro, client.MergeFrom(base)); err != nil {
+			return "", err
+		}
+	}
+	if pause {
+		return "paused", nil
+	}
+	if ro.Status.Phase != dlv1.PhasePaused {
+		return "resumed", nil
+	}
+	return o.updateStatus(ctx, name, func(ro *dlv1.Rollout, _ metav1.Time) (string, error) {
+		if ro.Status.Phase != dlv1.PhasePaused {
+			return "resumed", nil
+		}
+		// 保持当前权重重新分析当前步骤，连续通过与失败的次数重新计数
+		ro.Status.Phase = dlv1.PhaseAnalyzing
+		if st := ro.Status.StepStatus(ro.Status.StepIndex); st != nil {
+			st.AnalyzedAt = nil
+			st.Successes = 0
+			st.Failures = 0
+		}
+		meta.RemoveStatusCondition(&ro.Status.Conditions, dlv1.ConditionAnalysisFailed)
+		return fmt.Sprintf("resumed, re-analyzing step %d", ro.Status.StepIndex), nil
+	})
+}
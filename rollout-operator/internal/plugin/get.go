@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+func newGetCommand(o *Options) *cobra.Command {
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "get NAME",
+		Short: "Show the current step, canary weight and analysis state of a Rollout",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if follow {
+				return o.watch(cmd.Context(), cmd.OutOrStdout(), args[0])
+			}
+			ro, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return renderRollout(cmd.OutOrStdout(), ro, o.Now())
+		},
+	}
+	cmd.Flags().BoolVarP(&follow, "watch", "w", false, "keep printing the Rollout on every change until it finishes")
+	return cmd
+}
+
+func newWatchCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch NAME",
+		Short: "Print the Rollout on every change until it succeeds, fails or rolls back",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.watch(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+// watch 先建立 watch 再输出当前状态，之后每次变化重新输出，进入终态后返回
+func (o *Options) watch(ctx context.Context, w io.Writer, name string) error {
+	var list dlv1.RolloutList
+	wi, err := o.Client.Watch(ctx, &list, client.InNamespace(o.Namespace),
+		client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", name)})
+	if err != nil {
+		return err
+	}
+	defer wi.Stop()
+
+	ro, err := o.get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := renderRollout(w, ro, o.Now()); err != nil {
+		return err
+	}
+	if finished(ro) {
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-wi.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch ev.Type {
+			case watch.Deleted:
+				return fmt.Errorf("rollout %s/%s was deleted", o.Namespace, name)
+			case watch.Added, watch.Modified:
+			default:
+				continue
+			}
+			ro, ok := ev.Object.(*dlv1.Rollout)
+			if !ok || ro.Name != name {
+				continue
+			}
+			fmt.Fprintln(w, "---")
+			if err := renderRollout(w, ro, o.Now()); err != nil {
+				return err
+			}
+			if finished(ro) {
+				return nil
+			}
+		}
+	}
+}
+
+func finished(ro *dlv1.Rollout) bool {
+	switch ro.Status.Phase {
+	case dlv1.PhaseSucceeded, dlv1.PhaseFailed, dlv1.PhaseRolledBack:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+func newHistoryCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history NAME",
+		Short: "Show the recorded revisions and the timeline of steps and conditions of a Rollout",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ro, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return renderHistory(cmd.OutOrStdout(), ro)
+		},
+	}
+}
+
+type historyEntry struct {
+	at    time.Time
+	event string
+}
+
+// renderHistory 按时间顺序列出步骤开始、分析通过与条件变化
+func renderHistory(w io.Writer, ro *dlv1.Rollout) error {
+	var entries []historyEntry
+	for _, st := range ro.Status.Steps {
+		entries = append(entries, historyEntry{st.StartedAt.Time, fmt.Sprintf("step %d started at weight %d", st.Index, st.Weight)})
+		if st.HoldUntil != nil {
+			entries = append(entries, historyEntry{passedAt(ro, st), fmt.Sprintf("step %d passed analysis, hold until %s", st.Index, formatTime(st.HoldUntil.Time))})
+		}
+	}
+	for _, c := range ro.Status.Conditions {
+		entries = append(entries, historyEntry{c.LastTransitionTime.Time, fmt.Sprintf("%s=%s (%s): %s", c.Type, c.Status, c.Reason, c.Message)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+
+	if len(entries) == 0 && len(ro.Status.Revisions) == 0 {
+		_, err := fmt.Fprintf(w, "No history recorded for rollout %q\n", ro.Name)
+		return err
+	}
+	if len(ro.Status.Revisions) > 0 {
+		if err := renderRevisions(w, ro); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		fmt.Fprintln(w)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tEVENT")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\n", formatTime(e.at), e.event)
+	}
+	return tw.Flush()
+}
+
+// renderRevisions 按序号列出保留的版本，标出当前的 stable/canary 版本
+func renderRevisions(w io.Writer, ro *dlv1.Rollout) error {
+	recs := append([]dlv1.RevisionRecord(nil), ro.Status.Revisions...)
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Revision < recs[j].Revision })
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tNAME\tCREATED\tPHASE\tCURRENT")
+	for _, rec := range recs {
+		var current []string
+		if rec.Name == ro.Status.StableRevision {
+			current = append(current, "stable")
+		}
+		if rec.Name == ro.Status.CanaryRevision {
+			current = append(current, "canary")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", rec.Revision, rec.Name, formatTime(rec.CreatedAt.Time),
+			orNone(string(rec.Phase)), orDash(strings.Join(current, ",")))
+	}
+	return tw.Flush()
+}
+
+// passedAt 分析通过的时间：hold 结束时间减去该步骤的 holdSeconds
+func passedAt(ro *dlv1.Rollout, st dlv1.StepStatus) time.Time {
+	hold := time.Duration(0)
+	if steps := ro.CanarySteps(); int(st.Index) < len(steps) {
+		hold = time.Duration(steps[st.Index].HoldSeconds) * time.Second
+	}
+	return st.HoldUntil.Add(-hold)
+}
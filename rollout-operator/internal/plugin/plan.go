@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+func newPlanCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "plan NAME",
+		Short: "Show the actions the controller computed for a Rollout in dry-run mode",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ro, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return renderPlan(cmd.OutOrStdout(), ro)
+		},
+	}
+}
+
+// renderPlan 按顺序列出 status.plan 中的动作
+func renderPlan(w io.Writer, ro *dlv1.Rollout) error {
+	plan := ro.Status.Plan
+	if plan == nil {
+		if !ro.Spec.DryRun {
+			_, err := fmt.Fprintf(w, "Rollout %q is not in dry-run mode; set spec.dryRun to true to compute a plan\n", ro.Name)
+			return err
+		}
+		_, err := fmt.Fprintf(w, "No plan computed yet for rollout %q\n", ro.Name)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Revision:\t%s\n", plan.Revision)
+	fmt.Fprintf(tw, "Generated:\t%s\n", formatTime(plan.GeneratedAt.Time))
+	if !ro.Spec.DryRun {
+		fmt.Fprintf(tw, "Dry Run:\toff, the plan is being executed\n")
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(plan.Actions) == 0 {
+		_, err := fmt.Fprintln(w, "\nNothing to do")
+		return err
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tACTION\tTARGET\tDETAIL")
+	for _, a := range plan.Actions {
+		step := "-"
+		if a.Step != nil {
+			step = fmt.Sprint(*a.Step)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", step, a.Type, orDash(a.Target), a.Message)
+	}
+	return tw.Flush()
+}
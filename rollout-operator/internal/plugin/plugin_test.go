@@ -0,0 +1,502 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// expectGolden 比较输出与 testdata 下的 golden 文件，-update 时改为重写
+func expectGolden(name, got string) {
+	GinkgoHelper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		Expect(os.WriteFile(path, []byte(got), 0o644)).To(Succeed())
+	}
+	want, err := os.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(got).To(Equal(string(want)))
+}
+
+// syncBuffer watch 在后台写输出，测试同时读取
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+var (
+	t0  = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	now = t0.Add(90 * time.Second)
+)
+
+func mt(d time.Duration) metav1.Time { return metav1.NewTime(t0.Add(d)) }
+
+func mtp(d time.Duration) *metav1.Time {
+	t := mt(d)
+	return &t
+}
+
+// progressingRollout 第 0 步已通过分析，正在 hold；now 时还剩 30s
+func progressingRollout() *dlv1.Rollout {
+	return &dlv1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec: dlv1.RolloutSpec{
+			TargetRef: dlv1.TargetRef{Kind: "Deployment", Name: "demo", Port: 8080},
+			Strategy: dlv1.RolloutStrategy{
+				Type:  dlv1.Canary,
+				Steps: []dlv1.RolloutStep{{Weight: 10, HoldSeconds: 60}, {Weight: 50, HoldSeconds: 120}, {Weight: 100}},
+			},
+			Analysis: dlv1.AnalysisSpec{
+				IntervalSeconds:  30,
+				SuccessThreshold: 2,
+				FailureThreshold: 2,
+				Metrics: []dlv1.MetricCheck{
+					{Name: "error-rate", PromQL: `sum(rate(http_requests_total{code=~"5.."}[1m]))`, Threshold: "0.01", Compare: dlv1.CompareLT},
+				},
+			},
+			Traffic: dlv1.TrafficSpec{
+				Provider:      "NginxIngress",
+				Host:          "demo.example.com",
+				StableService: "demo-stable",
+				CanaryService: "demo-canary",
+			},
+			FailurePolicy: dlv1.FailurePolicyManual,
+		},
+		Status: dlv1.RolloutStatus{
+			Phase:     dlv1.PhaseProgressing,
+			StepIndex: 1,
+			Steps: []dlv1.StepStatus{
+				{Index: 0, Weight: 10, StartedAt: mt(0), HoldUntil: mtp(2 * time.Minute)},
+			},
+		},
+	}
+}
+
+// pausedRollout 第 1 步分析失败，failurePolicy Manual 暂停在 50%
+func pausedRollout() *dlv1.Rollout {
+	ro := progressingRollout()
+	ro.Status.Phase = dlv1.PhasePaused
+	ro.Status.Steps[0].HoldUntil = mtp(90 * time.Second)
+	ro.Status.Steps = append(ro.Status.Steps, dlv1.StepStatus{Index: 1, Weight: 50, StartedAt: mt(90 * time.Second)})
+	ro.Status.Conditions = []metav1.Condition{{
+		Type:               dlv1.ConditionAnalysisFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Manual",
+		Message:            "error-rate 0.05 >= 0.01",
+		LastTransitionTime: mt(110 * time.Second),
+	}}
+	return ro
+}
+
+// hookedRollout 在 progressingRollout 基础上声明了三个 hook，preRollout 已执行完
+func hookedRollout() *dlv1.Rollout {
+	ro := progressingRollout()
+	ro.Spec.Hooks = &dlv1.RolloutHooks{
+		PreRollout: []dlv1.RolloutHook{{
+			Name: "migrate",
+			Job: &batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "migrate", Image: "registry.local/migrate:v2"}}},
+			}}},
+		}},
+		PostPromotion: []dlv1.RolloutHook{{Name: "warm-cache", HTTP: &dlv1.HTTPHook{URL: "http://cache.default.svc/warm"}}},
+		OnRollback:    []dlv1.RolloutHook{{Name: "page", HTTP: &dlv1.HTTPHook{URL: "http://pager.default.svc/rollback"}}},
+	}
+	ro.Status.Hooks = []dlv1.HookStatus{{
+		Name:       "migrate",
+		Point:      dlv1.HookPreRollout,
+		Phase:      dlv1.HookSucceeded,
+		Job:        "demo-migrate-7c9b6",
+		StartedAt:  mt(-time.Minute),
+		FinishedAt: mtp(-10 * time.Second),
+		Message:    "job demo-migrate-7c9b6 completed",
+	}}
+	return ro
+}
+
+// sloRollout 在 pausedRollout 基础上声明了两个 SLO，第 1 步因延迟 SLO 燃烧过快暂停
+func sloRollout() *dlv1.Rollout {
+	ro := pausedRollout()
+	ro.Spec.Analysis.SLOs = []dlv1.SLOCheck{
+		{Name: "availability", Type: dlv1.SLOAvailability, Objective: "99.9", MaxBurnRate: "2",
+			ErrorQuery: `sum(increase(http_requests_total{service="{{canaryService}}",code=~"5.."}[{{window}}]))`,
+			TotalQuery: `sum(increase(http_requests_total{service="{{canaryService}}"}[{{window}}]))`},
+		{Name: "p99", Type: dlv1.SLOLatency, Objective: "99", ThresholdMillis: 300, MaxBurnRate: "1",
+			ErrorQuery: `sum(increase(http_request_duration_seconds_count{service="{{canaryService}}"}[{{window}}])) - sum(increase(http_request_duration_seconds_bucket{service="{{canaryService}}",le="{{threshold}}"}[{{window}}]))`,
+			TotalQuery: `sum(increase(http_requests_total{service="{{canaryService}}"}[{{window}}]))`},
+	}
+	ro.Status.Conditions[0].Message = "SLO p99 burn rate 1.50 exceeds 1 over the last 1m0s (3 of 200 requests bad, objective 99%)"
+	return ro
+}
+
+// revision 保存指定镜像的 Pod 模板快照
+func revision(name string, number int64, image string) *appsv1.ControllerRevision {
+	raw, err := json.Marshal(corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "demo"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: image}}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       runtime.RawExtension{Raw: raw},
+		Revision:   number,
+	}
+}
+
+// revisedRollout 在 pausedRollout 基础上记录了三个版本：v2 为 stable，v3 正在发布
+func revisedRollout() *dlv1.Rollout {
+	ro := pausedRollout()
+	ro.Status.StableRevision = "demo-5d4f8"
+	ro.Status.CanaryRevision = "demo-7c9b6"
+	ro.Status.Revisions = []dlv1.RevisionRecord{
+		{Name: "demo-7c9b6", Revision: 3, CreatedAt: mt(0), Phase: dlv1.PhasePaused},
+		{Name: "demo-66b7d", Revision: 1, CreatedAt: mt(-48 * time.Hour), Phase: dlv1.PhaseSucceeded},
+		{Name: "demo-5d4f8", Revision: 2, CreatedAt: mt(-24 * time.Hour), Phase: dlv1.PhaseSucceeded},
+	}
+	return ro
+}
+
+// plannedRollout 处于 dry-run 模式，控制器已算出发布计划
+func plannedRollout() *dlv1.Rollout {
+	ro := progressingRollout()
+	ro.Spec.DryRun = true
+	ro.Spec.Strategy.Steps = []dlv1.RolloutStep{{Weight: 20, HoldSeconds: 60}, {Weight: 100}}
+	ro.Status = dlv1.RolloutStatus{}
+	step := func(i int32) *int32 { return &i }
+	analyze := "check canary readiness every 30s; pass after 2 consecutive successes, fail after 2 consecutive failures (failurePolicy Manual)"
+	ro.Status.Plan = &dlv1.RolloutPlan{
+		Revision:    "demo-7c9b6",
+		GeneratedAt: mt(0),
+		Actions: []dlv1.PlannedAction{
+			{Type: dlv1.PlanCreate, Target: "ControllerRevision/demo-7c9b6", Message: "record the pod template as a revision"},
+			{Type: dlv1.PlanCreate, Target: "Deployment/demo-stable", Message: "prepare the stable and canary workloads"},
+			{Type: dlv1.PlanCreate, Target: "Deployment/demo-canary", Message: "prepare the stable and canary workloads"},
+			{Type: dlv1.PlanCreate, Target: "Ingress/demo.example.com-canary", Step: step(0), Message: "set the canary weight to 20%"},
+			{Type: dlv1.PlanAnalyze, Step: step(0), Message: analyze},
+			{Type: dlv1.PlanHold, Step: step(0), Message: "hold 1m0s at 20%"},
+			{Type: dlv1.PlanUpdate, Target: "Ingress/demo.example.com-canary", Step: step(1), Message: "set the canary weight to 100%"},
+			{Type: dlv1.PlanAnalyze, Step: step(1), Message: analyze},
+			{Type: dlv1.PlanUpdate, Target: "Ingress/demo.example.com-stable", Message: "promote the canary to 100%"},
+			{Type: dlv1.PlanDelete, Target: "Ingress/demo.example.com-canary", Message: "promote the canary to 100%"},
+		},
+	}
+	return ro
+}
+
+var _ = Describe("kubectl-rollout", func() {
+	var (
+		ctx context.Context
+		c   client.WithWatch
+	)
+	key := types.NamespacedName{Namespace: "default", Name: "demo"}
+
+	setup := func(objs ...client.Object) {
+		c = fake.NewClientBuilder().
+			WithScheme(Scheme()).
+			WithStatusSubresource(&dlv1.Rollout{}).
+			WithObjects(objs...).
+			Build()
+	}
+	runTo := func(out *syncBuffer, args ...string) error {
+		cmd := NewRootCommand(&Options{
+			Namespace: "default",
+			Client:    c,
+			Out:       out,
+			Now:       func() time.Time { return now },
+		})
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+	run := func(args ...string) (string, error) {
+		out := &syncBuffer{}
+		err := runTo(out, args...)
+		return out.String(), err
+	}
+	current := func() *dlv1.Rollout {
+		GinkgoHelper()
+		var ro dlv1.Rollout
+		Expect(c.Get(ctx, key, &ro)).To(Succeed())
+		return &ro
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Context("get", func() {
+		It("prints a rollout holding between steps", func() {
+			setup(progressingRollout())
+			out, err := run("get", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("get_progressing.golden", out)
+		})
+
+		It("prints a rollout paused after a failed analysis", func() {
+			setup(pausedRollout())
+			out, err := run("get", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("get_paused.golden", out)
+		})
+
+		It("lists the hooks and their results", func() {
+			setup(hookedRollout())
+			out, err := run("get", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("get_hooks.golden", out)
+		})
+
+		It("lists the SLO checks", func() {
+			setup(sloRollout())
+			out, err := run("get", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("get_slos.golden", out)
+		})
+
+		It("returns an error for an unknown rollout", func() {
+			setup(progressingRollout())
+			_, err := run("get", "missing")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Context("watch", func() {
+		It("prints every change until the rollout finishes", func() {
+			setup(progressingRollout())
+			out := &syncBuffer{}
+			done := make(chan error, 1)
+			go func() { done <- runTo(out, "get", "demo", "--watch") }()
+			Eventually(out.String).Should(ContainSubstring("Phase:"))
+
+			ro := current()
+			ro.Status.Phase = dlv1.PhaseSucceeded
+			ro.Status.StepIndex = 3
+			Expect(c.Status().Update(ctx, ro)).To(Succeed())
+
+			Eventually(done).Should(Receive(BeNil()))
+			expectGolden("watch.golden", out.String())
+		})
+	})
+
+	Context("history", func() {
+		It("lists step and condition transitions in order", func() {
+			setup(pausedRollout())
+			out, err := run("history", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("history.golden", out)
+		})
+
+		It("lists the recorded revisions before the timeline", func() {
+			setup(revisedRollout())
+			out, err := run("history", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("history_revisions.golden", out)
+		})
+	})
+
+	Context("plan", func() {
+		It("lists the planned actions in order", func() {
+			setup(plannedRollout())
+			out, err := run("plan", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			expectGolden("plan.golden", out)
+		})
+
+		It("explains how to get a plan outside dry-run mode", func() {
+			setup(progressingRollout())
+			out, err := run("plan", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("set spec.dryRun to true"))
+		})
+	})
+
+	Context("undo", func() {
+		var revisions []client.Object
+		BeforeEach(func() {
+			revisions = []client.Object{
+				revisedRollout(),
+				revision("demo-66b7d", 1, "demo:v1"),
+				revision("demo-5d4f8", 2, "demo:v2"),
+				revision("demo-7c9b6", 3, "demo:v3"),
+			}
+		})
+
+		It("restores the template of the stable revision by default", func() {
+			setup(revisions...)
+			out, err := run("undo", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("rollout \"demo\" rolling back to revision 2 (demo-5d4f8) through the canary steps in reverse\n"))
+			ro := current()
+			Expect(ro.Spec.Template).NotTo(BeNil())
+			Expect(ro.Spec.Template.Spec.Containers[0].Image).To(Equal("demo:v2"))
+			Expect(ro.Annotations).To(HaveKeyWithValue(dlv1.UndoAnnotation, "demo-5d4f8"))
+		})
+
+		It("accepts a revision number or name", func() {
+			setup(revisions...)
+			_, err := run("undo", "demo", "--to-revision=1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current().Spec.Template.Spec.Containers[0].Image).To(Equal("demo:v1"))
+
+			_, err = run("undo", "demo", "--to-revision=demo-5d4f8")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current().Spec.Template.Spec.Containers[0].Image).To(Equal("demo:v2"))
+		})
+
+		It("refuses the current or an unknown revision", func() {
+			setup(revisions...)
+			_, err := run("undo", "demo", "--to-revision=3")
+			Expect(err).To(MatchError(ContainSubstring("already the current revision")))
+			_, err = run("undo", "demo", "--to-revision=9")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+		})
+
+		It("fails without a previous revision", func() {
+			setup(pausedRollout())
+			_, err := run("undo", "demo")
+			Expect(err).To(MatchError(ContainSubstring("no previous successful revision")))
+		})
+	})
+
+	Context("promote", func() {
+		It("skips the remaining hold", func() {
+			setup(progressingRollout())
+			out, err := run("promote", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("rollout \"demo\" hold of step 0 skipped\n"))
+			Expect(current().Status.StepStatus(0).HoldUntil.Time).To(BeTemporally("==", now))
+		})
+
+		It("accepts a step paused by a failed analysis", func() {
+			setup(pausedRollout())
+			_, err := run("promote", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			ro := current()
+			Expect(ro.Status.Phase).To(Equal(dlv1.PhaseProgressing))
+			Expect(ro.Status.StepIndex).To(Equal(int32(2)))
+			Expect(ro.Status.Conditions).To(BeEmpty())
+		})
+
+		It("jumps to the end with --full", func() {
+			setup(pausedRollout())
+			_, err := run("promote", "demo", "--full")
+			Expect(err).NotTo(HaveOccurred())
+			ro := current()
+			Expect(ro.Status.Phase).To(Equal(dlv1.PhaseProgressing))
+			Expect(ro.Status.StepIndex).To(Equal(int32(3)))
+		})
+
+		It("refuses while spec.paused is set", func() {
+			ro := progressingRollout()
+			ro.Spec.Paused = true
+			setup(ro)
+			_, err := run("promote", "demo")
+			Expect(err).To(MatchError(ContainSubstring("run resume first")))
+		})
+	})
+
+	Context("abort and retry", func() {
+		It("requests an abort of an in-flight rollout", func() {
+			setup(progressingRollout())
+			_, err := run("abort", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current().Status.Abort).To(BeTrue())
+		})
+
+		It("refuses to abort a finished rollout", func() {
+			ro := progressingRollout()
+			ro.Status.Phase = dlv1.PhaseSucceeded
+			setup(ro)
+			_, err := run("abort", "demo")
+			Expect(err).To(MatchError(ContainSubstring("cannot be aborted in phase Succeeded")))
+		})
+
+		It("restarts a rolled back rollout from the first step", func() {
+			ro := pausedRollout()
+			ro.Status.Phase = dlv1.PhaseRolledBack
+			ro.Status.Abort = true
+			ro.Status.Hooks = hookedRollout().Status.Hooks
+			setup(ro)
+			out, err := run("retry", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(out)).To(Equal("rollout \"demo\" restarted from step 0"))
+			ro = current()
+			Expect(ro.Status.Phase).To(BeEmpty())
+			Expect(ro.Status.StepIndex).To(BeZero())
+			Expect(ro.Status.Steps).To(BeEmpty())
+			Expect(ro.Status.Abort).To(BeFalse())
+			Expect(ro.Status.Hooks).To(BeEmpty())
+			Expect(ro.Status.Conditions).To(BeEmpty())
+		})
+	})
+
+	Context("pause and resume", func() {
+		It("toggles spec.paused", func() {
+			setup(progressingRollout())
+			_, err := run("pause", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current().Spec.Paused).To(BeTrue())
+
+			_, err = run("resume", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current().Spec.Paused).To(BeFalse())
+		})
+
+		It("re-runs the analysis of a step paused by failurePolicy Manual", func() {
+			setup(pausedRollout())
+			out, err := run("resume", "demo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("re-analyzing step 1"))
+			ro := current()
+			Expect(ro.Status.Phase).To(Equal(dlv1.PhaseAnalyzing))
+			Expect(ro.Status.StepIndex).To(Equal(int32(1)))
+		})
+	})
+})
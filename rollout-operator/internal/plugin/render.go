@@ -0,0 +1,246 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+// 步骤在表格中的状态
+const (
+	stepPending   = "Pending"
+	stepRunning   = "Running"
+	stepHolding   = "Holding"
+	stepCompleted = "Completed"
+	stepFailed    = "Failed"
+	stepSkipped   = "Skipped"
+)
+
+// renderRollout 输出 Rollout 概要、步骤表、分析指标与条件
+func renderRollout(w io.Writer, ro *dlv1.Rollout, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", ro.Name)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", ro.Namespace)
+	fmt.Fprintf(tw, "Strategy:\t%s\n", ro.Spec.Strategy.Type)
+	fmt.Fprintf(tw, "Phase:\t%s\n", orNone(string(ro.Status.Phase)))
+	fmt.Fprintf(tw, "Paused:\t%t\n", ro.Spec.Paused)
+	if ro.Status.Abort {
+		fmt.Fprintf(tw, "Abort:\trequested\n")
+	}
+	if ro.Spec.Strategy.Type != dlv1.BlueGreen {
+		fmt.Fprintf(tw, "Step:\t%d/%d\n", ro.Status.StepIndex, len(ro.CanarySteps()))
+	}
+	fmt.Fprintf(tw, "Canary Weight:\t%d\n", canaryWeight(ro))
+	fmt.Fprintf(tw, "Host:\t%s\n", ro.Spec.Traffic.Host)
+	fmt.Fprintf(tw, "Services:\t%s (stable), %s (canary)\n", ro.Spec.Traffic.StableService, ro.Spec.Traffic.CanaryService)
+	if ro.Status.CanaryRevision != "" {
+		fmt.Fprintf(tw, "Revisions:\t%s (stable), %s (canary)\n", orNone(ro.Status.StableRevision), ro.Status.CanaryRevision)
+	}
+	if u := ro.Status.Undo; u != nil {
+		fmt.Fprintf(tw, "Undo:\t%s on the stable workload, %s on the canary stepping down from %d%%\n", ro.Status.CanaryRevision, u.FromRevision, u.FromWeight)
+	}
+	if len(ro.Spec.DependsOn) > 0 {
+		deps := make([]string, 0, len(ro.Spec.DependsOn))
+		for _, d := range ro.Spec.DependsOn {
+			if d.Namespace != "" && d.Namespace != ro.Namespace {
+				deps = append(deps, d.Namespace+"/"+d.Name)
+			} else {
+				deps = append(deps, d.Name)
+			}
+		}
+		fmt.Fprintf(tw, "Depends On:\t%s\n", strings.Join(deps, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if steps := ro.CanarySteps(); len(steps) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "STEP\tWEIGHT\tHOLD\tSTATUS\tREMAINING")
+		for i, step := range steps {
+			status, remaining := stepState(ro, int32(i), now)
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\n", i, step.Weight,
+				time.Duration(step.HoldSeconds)*time.Second, status, remaining)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := renderHooks(w, ro); err != nil {
+		return err
+	}
+
+	if len(ro.Spec.Analysis.Metrics) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "METRIC\tCOMPARE\tTHRESHOLD\tQUERY")
+		for _, m := range ro.Spec.Analysis.Metrics {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m.Name, m.Compare, m.Threshold, m.PromQL)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(ro.Spec.Analysis.SLOs) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SLO\tTYPE\tOBJECTIVE\tTHRESHOLD\tMAX BURN RATE")
+		for _, o := range ro.Spec.Analysis.SLOs {
+			threshold := "-"
+			if o.ThresholdMillis > 0 {
+				threshold = (time.Duration(o.ThresholdMillis) * time.Millisecond).String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s%%\t%s\t%s\n", o.Name, o.Type, o.Objective, threshold, o.MaxBurnRate)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(ro.Status.Conditions) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CONDITION\tSTATUS\tREASON\tSINCE\tMESSAGE")
+		for _, c := range ro.Status.Conditions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason,
+				formatTime(c.LastTransitionTime.Time), c.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hookPoints 按发布过程中的先后顺序列出 hook
+var hookPoints = []dlv1.HookPoint{dlv1.HookPreRollout, dlv1.HookPrePromotion, dlv1.HookPostPromotion, dlv1.HookOnRollback}
+
+// renderHooks 输出 spec.hooks 中每个 hook 在本次发布中的结果，尚未开始的为 Pending
+func renderHooks(w io.Writer, ro *dlv1.Rollout) error {
+	if ro.Spec.Hooks == nil {
+		return nil
+	}
+	var rows []string
+	for _, point := range hookPoints {
+		for _, h := range ro.Spec.Hooks.At(point) {
+			kind := "Job"
+			if h.HTTP != nil {
+				kind = "HTTP"
+			}
+			phase, finished, msg := "Pending", "-", "-"
+			if st := ro.Status.HookStatus(h.Name); st != nil {
+				phase, msg = string(st.Phase), orDash(st.Message)
+				if st.FinishedAt != nil {
+					finished = formatTime(st.FinishedAt.Time)
+				}
+			}
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\n", h.Name, point, kind, phase, finished, msg))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOOK\tPOINT\tTYPE\tSTATUS\tFINISHED\tMESSAGE")
+	for _, row := range rows {
+		fmt.Fprint(tw, row)
+	}
+	return tw.Flush()
+}
+
+// stepState 根据状态中的步骤记录推算步骤在表格中的状态与剩余 hold 时间
+func stepState(ro *dlv1.Rollout, index int32, now time.Time) (string, string) {
+	if ro.Status.Phase == dlv1.PhaseSucceeded {
+		return stepCompleted, "-"
+	}
+	st := ro.Status.StepStatus(index)
+	if st == nil {
+		if index < ro.Status.StepIndex {
+			return stepSkipped, "-"
+		}
+		return stepPending, "-"
+	}
+	if st.HoldUntil != nil {
+		if index == ro.Status.StepIndex-1 && ro.Status.Phase == dlv1.PhaseProgressing && now.Before(st.HoldUntil.Time) {
+			return stepHolding, st.HoldUntil.Sub(now).Truncate(time.Second).String()
+		}
+		return stepCompleted, "-"
+	}
+	if index < ro.Status.StepIndex {
+		// 分析未通过但被人工 promote 越过
+		return stepSkipped, "-"
+	}
+	switch ro.Status.Phase {
+	case dlv1.PhasePaused, dlv1.PhaseFailed, dlv1.PhaseRolledBack:
+		return stepFailed, "-"
+	}
+	return stepRunning, "-"
+}
+
+// canaryWeight 根据状态推算流量层当前的金丝雀权重
+func canaryWeight(ro *dlv1.Rollout) int32 {
+	switch ro.Status.Phase {
+	case dlv1.PhaseSucceeded:
+		return ro.FinalWeight()
+	case dlv1.PhaseRolledBack, "":
+		return ro.RollbackWeight()
+	}
+	steps := ro.CanarySteps()
+	idx := int(ro.Status.StepIndex)
+	if ro.Status.Phase == dlv1.PhaseProgressing {
+		// hold 阶段权重仍是上一步的
+		idx--
+	}
+	if idx < 0 || len(steps) == 0 {
+		return ro.RollbackWeight()
+	}
+	if idx >= len(steps) {
+		return steps[len(steps)-1].Weight
+	}
+	return steps[idx].Weight
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func orNone(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugin 实现 kubectl-rollout 插件的各个子命令
+package plugin
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+// Options 子命令共享的参数与依赖；测试中直接注入 fake client 与固定时间
+type Options struct {
+	Namespace string
+	Client    client.WithWatch
+	Out       io.Writer
+	// Now 返回当前时间，为空时使用 time.Now
+	Now func() time.Time
+
+	kubeconfig string
+}
+
+// NewRootCommand 构造 kubectl-rollout 根命令
+func NewRootCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "kubectl-rollout",
+		Short:         "Inspect and control Rollouts managed by rollout-operator",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return o.complete()
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "namespace of the Rollout; defaults to the kubeconfig context namespace")
+	cmd.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	if o.Out != nil {
+		cmd.SetOut(o.Out)
+	}
+
+	cmd.AddCommand(
+		newGetCommand(o),
+		newWatchCommand(o),
+		newPromoteCommand(o),
+		newAbortCommand(o),
+		newRetryCommand(o),
+		newPauseCommand(o, true),
+		newPauseCommand(o, false),
+		newHistoryCommand(o),
+		newUndoCommand(o),
+		newPlanCommand(o),
+	)
+	return cmd
+}
+
+// complete 补齐未注入的依赖：按 kubeconfig 构造 client，并取上下文中的默认 namespace
+func (o *Options) complete() error {
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.Client != nil && o.Namespace != "" {
+		return nil
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+	if o.Namespace == "" {
+		ns, _, err := cc.Namespace()
+		if err != nil {
+			return err
+		}
+		o.Namespace = ns
+	}
+	if o.Client != nil {
+		return nil
+	}
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.Client, err = client.NewWithWatch(cfg, client.Options{Scheme: Scheme()})
+	return err
+}
+
+// Scheme 插件使用的 scheme：内置类型加上 v1beta1 Rollout
+func Scheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = dlv1.AddToScheme(s)
+	return s
+}
+
+func (o *Options) get(ctx context.Context, name string) (*dlv1.Rollout, error) {
+	var ro dlv1.Rollout
+	if err := o.Client.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: name}, &ro); err != nil {
+		return nil, err
+	}
+	return &ro, nil
+}
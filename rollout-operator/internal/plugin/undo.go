@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dlv1 "github.com/ormasia/rollout-operator/api/v1beta1"
+)
+
+func newUndoCommand(o *Options) *cobra.Command {
+	var to string
+	cmd := &cobra.Command{
+		Use:   "undo NAME",
+		Short: "Roll back to a recorded revision; the controller walks the canary steps in reverse",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg, err := o.undo(cmd.Context(), args[0], to)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "rollout %q %s\n", args[0], msg)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&to, "to-revision", "", "revision number or ControllerRevision name to roll back to; defaults to the stable revision")
+	return cmd
+}
+
+// undo 把 spec.template 改回目标版本的快照并写入 UndoAnnotation；控制器把目标版本放到 stable 工作负载上，
+// 沿 canary 步骤倒序把当前版本的流量降到 0
+func (o *Options) undo(ctx context.Context, name, to string) (string, error) {
+	ro, err := o.get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	rec, err := undoTarget(ro, to)
+	if err != nil {
+		return "", err
+	}
+	var cr appsv1.ControllerRevision
+	if err := o.Client.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: rec.Name}, &cr); err != nil {
+		return "", err
+	}
+	var tpl corev1.PodTemplateSpec
+	if err := json.Unmarshal(cr.Data.Raw, &tpl); err != nil {
+		return "", fmt.Errorf("decode revision %s: %w", rec.Name, err)
+	}
+	base := ro.DeepCopy()
+	ro.Spec.Template = &tpl
+	if ro.Annotations == nil {
+		ro.Annotations = map[string]string{}
+	}
+	ro.Annotations[dlv1.UndoAnnotation] = rec.Name
+	if err := o.Client.Patch(ctx, ro, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+		return "", err
+	}
+	if ro.Spec.TargetRef.Kind == dlv1.KindStatefulSet {
+		return fmt.Sprintf("rolling back to revision %d (%s) through the canary steps", rec.Revision, rec.Name), nil
+	}
+	return fmt.Sprintf("rolling back to revision %d (%s) through the canary steps in reverse", rec.Revision, rec.Name), nil
+}
+
+// undoTarget 按序号或名称查找目标版本；未指定时取 stable 版本，
+// 当前版本已经成为 stable 时取之前最新一个发布成功的版本
+func undoTarget(ro *dlv1.Rollout, to string) (dlv1.RevisionRecord, error) {
+	recs := append([]dlv1.RevisionRecord(nil), ro.Status.Revisions...)
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Revision > recs[j].Revision })
+	if to == "" {
+		for _, rec := range recs {
+			if rec.Name == ro.Status.StableRevision && rec.Name != ro.Status.CanaryRevision {
+				return rec, nil
+			}
+		}
+		for _, rec := range recs {
+			if rec.Name != ro.Status.CanaryRevision && rec.Phase == dlv1.PhaseSucceeded {
+				return rec, nil
+			}
+		}
+		return dlv1.RevisionRecord{}, fmt.Errorf("rollout %q has no previous successful revision to roll back to", ro.Name)
+	}
+	n, numErr := strconv.ParseInt(to, 10, 64)
+	for _, rec := range recs {
+		if rec.Name == to || (numErr == nil && rec.Revision == n) {
+			if rec.Name == ro.Status.CanaryRevision {
+				return dlv1.RevisionRecord{}, fmt.Errorf("revision %s is already the current revision of rollout %q", to, ro.Name)
+			}
+			return rec, nil
+		}
+	}
+	return dlv1.RevisionRecord{}, fmt.Errorf("revision %s not found in the history of rollout %q", to, ro.Name)
+}
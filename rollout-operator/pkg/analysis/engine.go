@@ -1,12 +1,43 @@
 package analysis
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-type Spec struct { /* 占位：间隔/阈值/metrics 等，先不用 */
+// Spec 一次分析的输入；间隔、阈值等由控制器处理
+type Spec struct {
+	// 本步骤的分析窗口：步骤开始至今的时长
+	Window  time.Duration
+	Metrics []Metric
+	SLOs    []SLO
+	// 查询模板中 {{key}} 占位的取值
+	Vars map[string]string
 }
+
+// Metric 一个阈值检查：Query 的结果须满足 Compare（LT 小于、GT 大于）Threshold
+type Metric struct {
+	Name      string
+	Query     string
+	Threshold float64
+	Compare   string
+}
+
+// SLO 一个错误预算检查，Objective 为 (0,1) 之间的比例
+type SLO struct {
+	Name        string
+	Objective   float64
+	ErrorQuery  string
+	TotalQuery  string
+	Threshold   time.Duration
+	MaxBurnRate float64
+}
+
 type Result struct {
 	Passed bool
-	Reason string
+	// 数据不足以判断（如窗口太短、没有流量）：既不算通过也不算失败，由控制器在下一个间隔重新分析
+	Inconclusive bool
+	Reason       string
 }
 type Engine interface {
 	Evaluate(ctx context.Context, s Spec, labels map[string]string) (Result, error)
@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PrometheusClient 调用 Prometheus 兼容的 /api/v1/query 接口（Prometheus、Thanos、VictoriaMetrics 等）
+type PrometheusClient struct {
+	// 如 http://prometheus.monitoring.svc:9090
+	Address string
+	// 为空时使用 http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query 执行即时查询，结果须为单个值（scalar 或只有一条序列的 vector）；
+// 没有序列或结果为 NaN 时 ok 为 false
+func (c *PrometheusClient) Query(ctx context.Context, query string) (value float64, ok bool, err error) {
+	u := strings.TrimSuffix(c.Address, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, false, err
+	}
+
+	var pr promResponse
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return 0, false, fmt.Errorf("query %q: HTTP %d: %s", query, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if pr.Status != "success" {
+		return 0, false, fmt.Errorf("query %q: %s: %s", query, pr.ErrorType, pr.Error)
+	}
+
+	var sample []interface{}
+	switch pr.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(pr.Data.Result, &sample); err != nil {
+			return 0, false, fmt.Errorf("query %q: %w", query, err)
+		}
+	case "vector":
+		var series []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(pr.Data.Result, &series); err != nil {
+			return 0, false, fmt.Errorf("query %q: %w", query, err)
+		}
+		if len(series) == 0 {
+			return 0, false, nil
+		}
+		if len(series) > 1 {
+			return 0, false, fmt.Errorf("query %q returned %d series, expected 1", query, len(series))
+		}
+		sample = series[0].Value
+	default:
+		return 0, false, fmt.Errorf("query %q returned a %s, expected a scalar or vector", query, pr.Data.ResultType)
+	}
+
+	// 样本格式为 [<unix 时间>, "<值>"]
+	if len(sample) != 2 {
+		return 0, false, fmt.Errorf("query %q returned a malformed sample", query)
+	}
+	s, isString := sample[1].(string)
+	if !isString {
+		return 0, false, fmt.Errorf("query %q returned a malformed sample", query)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("query %q: %w", query, err)
+	}
+	if math.IsNaN(v) {
+		return 0, false, nil
+	}
+	return v, true, nil
+}
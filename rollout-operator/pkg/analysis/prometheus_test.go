@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakePrometheus 按查询语句返回预设的 /api/v1/query 响应，并记录收到的查询
+type fakePrometheus struct {
+	mu        sync.Mutex
+	responses map[string]string
+	queries   []string
+}
+
+func (f *fakePrometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := r.URL.Query().Get("query")
+	f.queries = append(f.queries, q)
+	if r.URL.Path != "/api/v1/query" {
+		http.NotFound(w, r)
+		return
+	}
+	body, ok := f.responses[q]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"unknown query"}`)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+func vector(values ...string) string {
+	series := ""
+	for i, v := range values {
+		if i > 0 {
+			series += ","
+		}
+		series += fmt.Sprintf(`{"metric":{},"value":[1700000000.123,%q]}`, v)
+	}
+	return `{"status":"success","data":{"resultType":"vector","result":[` + series + `]}}`
+}
+
+var _ = Describe("PrometheusClient", func() {
+	ctx := context.Background()
+	var prom *fakePrometheus
+	var c *PrometheusClient
+
+	BeforeEach(func() {
+		prom = &fakePrometheus{responses: map[string]string{}}
+		srv := httptest.NewServer(prom)
+		DeferCleanup(srv.Close)
+		c = &PrometheusClient{Address: srv.URL + "/"}
+	})
+
+	It("reads a single-series vector and a scalar", func() {
+		prom.responses["up"] = vector("0.25")
+		prom.responses["scalar(up)"] = `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"3"]}}`
+
+		v, ok, err := c.Query(ctx, "up")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal(0.25))
+
+		v, ok, err = c.Query(ctx, "scalar(up)")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal(3.0))
+	})
+
+	It("reports no data for an empty vector or NaN", func() {
+		prom.responses["absent"] = vector()
+		prom.responses["nan"] = vector("NaN")
+		for _, q := range []string{"absent", "nan"} {
+			_, ok, err := c.Query(ctx, q)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse(), q)
+		}
+	})
+
+	It("fails on query errors and ambiguous results", func() {
+		prom.responses["by_pod"] = vector("1", "2")
+		_, _, err := c.Query(ctx, "by_pod")
+		Expect(err).To(MatchError(ContainSubstring("returned 2 series")))
+
+		_, _, err = c.Query(ctx, "rate(")
+		Expect(err).To(MatchError(ContainSubstring("bad_data: unknown query")))
+	})
+})
@@ -25,6 +25,9 @@ func (e *ReadyEngine) Evaluate(ctx context.Context, s Spec, labels map[string]st
 	if v, ok := labels["namespace"]; ok && v != "" {
 		namespace = v
 	}
+	if v, ok := labels["statefulset"]; ok && v != "" && namespace != "" {
+		return e.evaluateStatefulSet(ctx, v, namespace)
+	}
 
 	if depName == "" || namespace == "" {
 		lg.Info("ReadyEngine missing inputs", "deployment", depName, "namespace", namespace)
@@ -49,3 +52,33 @@ func (e *ReadyEngine) Evaluate(ctx context.Context, s Spec, labels map[string]st
 	lg.Info("ReadyEngine evaluated", "deployment", depName, "namespace", namespace, "ready", ready, "desired", desired, "passed", passed)
 	return Result{Passed: passed, Reason: reason}, nil
 }
+
+// evaluateStatefulSet partition 之上的 Pod 都已升级到新版本且全部就绪时通过
+func (e *ReadyEngine) evaluateStatefulSet(ctx context.Context, name, namespace string) (Result, error) {
+	lg := log.FromContext(ctx)
+	var sts appsv1.StatefulSet
+	if err := e.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &sts); err != nil {
+		lg.Info("ReadyEngine get failed", "statefulset", name, "namespace", namespace, "err", err.Error())
+		return Result{Passed: false, Reason: err.Error()}, nil
+	}
+	desired := int32(1)
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+	canary := desired
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		canary = desired - *ru.Partition
+	}
+	updated := sts.Status.UpdatedReplicas
+	if sts.Status.UpdateRevision == sts.Status.CurrentRevision {
+		// 没有新版本时所有 Pod 都是最新的
+		updated = sts.Status.Replicas
+	}
+	passed := sts.Status.ObservedGeneration >= sts.Generation && updated >= canary && sts.Status.ReadyReplicas == desired && desired > 0
+	reason := "waiting for updated pods to become ready"
+	if passed {
+		reason = "statefulset ready"
+	}
+	lg.Info("ReadyEngine evaluated", "statefulset", name, "namespace", namespace, "updated", updated, "canary", canary, "ready", sts.Status.ReadyReplicas, "desired", desired, "passed", passed)
+	return Result{Passed: passed, Reason: reason}, nil
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// MinSLOWindow SLO 查询窗口的下限：窗口太短时 increase/rate 取不到足够的样本，结果为 Inconclusive
+const MinSLOWindow = time.Minute
+
+// SLOEngine 先用 Base 检查 canary 是否就绪，再检查 Spec.Metrics 的阈值，最后计算 Spec.SLOs 在步骤窗口内的燃烧率，
+// 任一不满足即不通过；某个指标没有数据、窗口不足 MinSLOWindow 或某个 SLO 没有流量时结果为 Inconclusive
+type SLOEngine struct {
+	Base Engine
+	// 为空时带指标或 SLO 的分析返回错误
+	Prometheus *PrometheusClient
+}
+
+func (e *SLOEngine) Evaluate(ctx context.Context, s Spec, labels map[string]string) (Result, error) {
+	lg := log.FromContext(ctx)
+	res := Result{Passed: true}
+	if e.Base != nil {
+		r, err := e.Base.Evaluate(ctx, s, labels)
+		if err != nil || !r.Passed {
+			return r, err
+		}
+		res = r
+	}
+	if len(s.Metrics) == 0 && len(s.SLOs) == 0 {
+		return res, nil
+	}
+	if e.Prometheus == nil {
+		return Result{}, fmt.Errorf("metric and SLO analysis needs a Prometheus address (--prometheus-address)")
+	}
+
+	window := s.Window.Round(time.Second)
+	reasons := []string{}
+	if res.Reason != "" {
+		reasons = append(reasons, res.Reason)
+	}
+	inconclusive := false
+	for _, m := range s.Metrics {
+		vars := map[string]string{"window": fmt.Sprintf("%ds", int64(window/time.Second))}
+		for k, v := range s.Vars {
+			vars[k] = v
+		}
+		v, ok, err := e.Prometheus.Query(ctx, renderQuery(m.Query, vars))
+		if err != nil {
+			return Result{}, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		if !ok {
+			inconclusive = true
+			reasons = append(reasons, fmt.Sprintf("metric %s: no data", m.Name))
+			continue
+		}
+		lg.Info("SLOEngine evaluated", "metric", m.Name, "value", v, "compare", m.Compare, "threshold", m.Threshold)
+		if !compare(v, m.Compare, m.Threshold) {
+			return Result{Passed: false, Reason: fmt.Sprintf("metric %s = %g, expected %s %g", m.Name, v, compareSymbol(m.Compare), m.Threshold)}, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("metric %s = %g", m.Name, v))
+	}
+	if len(s.SLOs) == 0 {
+		return Result{Passed: !inconclusive, Inconclusive: inconclusive, Reason: strings.Join(reasons, "; ")}, nil
+	}
+
+	if window < MinSLOWindow {
+		return Result{Inconclusive: true, Reason: fmt.Sprintf("SLO window %s is shorter than %s", window, MinSLOWindow)}, nil
+	}
+	for _, slo := range s.SLOs {
+		vars := map[string]string{
+			"window":    fmt.Sprintf("%ds", int64(window/time.Second)),
+			"threshold": strconv.FormatFloat(slo.Threshold.Seconds(), 'f', -1, 64),
+		}
+		for k, v := range s.Vars {
+			vars[k] = v
+		}
+		bad, total, err := e.query(ctx, slo, vars)
+		if err != nil {
+			return Result{}, fmt.Errorf("SLO %s: %w", slo.Name, err)
+		}
+		if total <= 0 {
+			inconclusive = true
+			reasons = append(reasons, fmt.Sprintf("SLO %s: no traffic in the last %s", slo.Name, window))
+			continue
+		}
+		burn := BurnRate(bad, total, slo.Objective)
+		lg.Info("SLOEngine evaluated", "slo", slo.Name, "bad", bad, "total", total, "burnRate", burn, "maxBurnRate", slo.MaxBurnRate)
+		if burn > slo.MaxBurnRate {
+			return Result{Passed: false, Reason: fmt.Sprintf("SLO %s burn rate %.2f exceeds %g over the last %s (%g of %g requests bad, objective %g%%)",
+				slo.Name, burn, slo.MaxBurnRate, window, bad, total, slo.Objective*100)}, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("SLO %s burn rate %.2f", slo.Name, burn))
+	}
+	return Result{Passed: !inconclusive, Inconclusive: inconclusive, Reason: strings.Join(reasons, "; ")}, nil
+}
+
+// query 取窗口内的坏事件数与请求总数；查询没有结果时视为 0
+func (e *SLOEngine) query(ctx context.Context, slo SLO, vars map[string]string) (bad, total float64, err error) {
+	total, _, err = e.Prometheus.Query(ctx, renderQuery(slo.TotalQuery, vars))
+	if err != nil || total <= 0 {
+		return 0, total, err
+	}
+	bad, _, err = e.Prometheus.Query(ctx, renderQuery(slo.ErrorQuery, vars))
+	return max(bad, 0), total, err
+}
+
+// compare 按 MetricCheck.Compare 比较：LT 要求 v < threshold，GT 要求 v > threshold
+func compare(v float64, op string, threshold float64) bool {
+	if op == "GT" {
+		return v > threshold
+	}
+	return v < threshold
+}
+
+func compareSymbol(op string) string {
+	if op == "GT" {
+		return ">"
+	}
+	return "<"
+}
+
+// BurnRate 错误预算的消耗速度：坏事件比例 / (1 - objective)；1 表示恰好在 SLO 周期末耗尽预算
+func BurnRate(bad, total, objective float64) float64 {
+	return bad / total / (1 - objective)
+}
+
+// renderQuery 把 {{key}} 占位替换为 vars 中的值
+func renderQuery(q string, vars map[string]string) string {
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(q)
+}
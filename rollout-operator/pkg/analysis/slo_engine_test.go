@@ -0,0 +1,171 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// stubEngine 固定返回给定结果
+type stubEngine struct{ res Result }
+
+func (s stubEngine) Evaluate(context.Context, Spec, map[string]string) (Result, error) {
+	return s.res, nil
+}
+
+var _ = Describe("SLOEngine", func() {
+	ctx := context.Background()
+	var prom *fakePrometheus
+	var e *SLOEngine
+
+	const (
+		errorQuery = `sum(increase(http_requests_total{service="{{canaryService}}",code=~"5.."}[{{window}}]))`
+		totalQuery = `sum(increase(http_requests_total{service="{{canaryService}}"}[{{window}}]))`
+		slowQuery  = `sum(increase(http_request_duration_seconds_count{service="{{canaryService}}"}[{{window}}])) - sum(increase(http_request_duration_seconds_bucket{service="{{canaryService}}",le="{{threshold}}"}[{{window}}]))`
+	)
+	availability := SLO{Name: "availability", Objective: 0.999, ErrorQuery: errorQuery, TotalQuery: totalQuery, MaxBurnRate: 2}
+	spec := func(window time.Duration, slos ...SLO) Spec {
+		return Spec{Window: window, SLOs: slos, Vars: map[string]string{"canaryService": "demo-canary"}}
+	}
+
+	BeforeEach(func() {
+		prom = &fakePrometheus{responses: map[string]string{}}
+		srv := httptest.NewServer(prom)
+		DeferCleanup(srv.Close)
+		e = &SLOEngine{Base: stubEngine{Result{Passed: true, Reason: "deployment ready"}}, Prometheus: &PrometheusClient{Address: srv.URL}}
+	})
+
+	It("computes the burn rate relative to the error budget", func() {
+		Expect(BurnRate(1, 1000, 0.999)).To(BeNumerically("~", 1, 1e-9))
+		Expect(BurnRate(5, 100, 0.99)).To(BeNumerically("~", 5, 1e-9))
+	})
+
+	It("passes when the canary burns its budget slowly enough", func() {
+		prom.responses[`sum(increase(http_requests_total{service="demo-canary"}[150s]))`] = vector("10000")
+		prom.responses[`sum(increase(http_requests_total{service="demo-canary",code=~"5.."}[150s]))`] = vector("15")
+
+		res, err := e.Evaluate(ctx, spec(150*time.Second, availability), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Passed).To(BeTrue())
+		Expect(res.Reason).To(Equal("deployment ready; SLO availability burn rate 1.50"))
+	})
+
+	It("fails the step when the burn rate exceeds the limit", func() {
+		prom.responses[`sum(increase(http_requests_total{service="demo-canary"}[60s]))`] = vector("1000")
+		prom.responses[`sum(increase(http_requests_total{service="demo-canary",code=~"5.."}[60s]))`] = vector("5")
+
+		res, err := e.Evaluate(ctx, spec(time.Minute, availability), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Passed).To(BeFalse())
+		Expect(res.Inconclusive).To(BeFalse())
+		Expect(res.Reason).To(HavePrefix("SLO availability burn rate 5.00 exceeds 2 over the last 1m0s"))
+	})
+
+	It("is inconclusive while the step window is shorter than the minimum", func() {
+		res, err := e.Evaluate(ctx, spec(10*time.Second, availability), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(Result{Inconclusive: true, Reason: "SLO window 10s is shorter than 1m0s"}))
+		Expect(prom.queries).To(BeEmpty())
+	})
+
+	It("fills the latency threshold into the query", func() {
+		latency := SLO{Name: "p99", Objective: 0.99, ErrorQuery: slowQuery, TotalQuery: totalQuery, Threshold: 300 * time.Millisecond, MaxBurnRate: 1}
+		prom.responses[`sum(increase(http_requests_total{service="demo-canary"}[120s]))`] = vector("200")
+		prom.responses[`sum(increase(http_request_duration_seconds_count{service="demo-canary"}[120s])) - sum(increase(http_request_duration_seconds_bucket{service="demo-canary",le="0.3"}[120s]))`] = vector("3")
+
+		res, err := e.Evaluate(ctx, spec(2*time.Minute, latency), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Passed).To(BeFalse())
+		Expect(res.Reason).To(ContainSubstring("SLO p99 burn rate 1.50 exceeds 1"))
+	})
+
+	It("is inconclusive without traffic and skips the error query", func() {
+		prom.responses[`sum(increase(http_requests_total{service="demo-canary"}[60s]))`] = vector()
+
+		res, err := e.Evaluate(ctx, spec(time.Minute, availability), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Passed).To(BeFalse())
+		Expect(res.Inconclusive).To(BeTrue())
+		Expect(res.Reason).To(ContainSubstring("SLO availability: no traffic in the last 1m0s"))
+		Expect(prom.queries).To(HaveLen(1))
+	})
+
+	It("does not query Prometheus before the canary is ready", func() {
+		e.Base = stubEngine{Result{Passed: false, Reason: "waiting for readiness"}}
+		res, err := e.Evaluate(ctx, spec(time.Minute, availability), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(Result{Passed: false, Reason: "waiting for readiness"}))
+		Expect(prom.queries).To(BeEmpty())
+	})
+
+	It("checks metric thresholds before the SLOs", func() {
+		errorRate := Metric{Name: "error-rate", Query: `sum(rate(errors{service="{{canaryService}}"}[{{window}}]))`, Threshold: 0.01, Compare: "LT"}
+		prom.responses[`sum(rate(errors{service="demo-canary"}[10s]))`] = vector("0.002")
+
+		s := spec(10 * time.Second)
+		s.Metrics = []Metric{errorRate}
+		res, err := e.Evaluate(ctx, s, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(Result{Passed: true, Reason: "deployment ready; metric error-rate = 0.002"}))
+
+		prom.responses[`sum(rate(errors{service="demo-canary"}[10s]))`] = vector("0.05")
+		s.SLOs = []SLO{availability}
+		res, err = e.Evaluate(ctx, s, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(Result{Passed: false, Reason: "metric error-rate = 0.05, expected < 0.01"}))
+	})
+
+	It("compares GT metrics and is inconclusive without data", func() {
+		throughput := Metric{Name: "throughput", Query: "sum(rate(requests[1m]))", Threshold: 10, Compare: "GT"}
+		prom.responses["sum(rate(requests[1m]))"] = vector("5")
+
+		s := spec(time.Minute)
+		s.Metrics = []Metric{throughput}
+		res, err := e.Evaluate(ctx, s, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Passed).To(BeFalse())
+		Expect(res.Reason).To(Equal("metric throughput = 5, expected > 10"))
+
+		prom.responses["sum(rate(requests[1m]))"] = vector()
+		res, err = e.Evaluate(ctx, s, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Inconclusive).To(BeTrue())
+		Expect(res.Reason).To(ContainSubstring("metric throughput: no data"))
+
+		e.Prometheus = nil
+		_, err = e.Evaluate(ctx, s, nil)
+		Expect(err).To(MatchError(ContainSubstring("--prometheus-address")))
+	})
+
+	It("returns an error instead of failing the step when Prometheus is unavailable", func() {
+		_, err := e.Evaluate(ctx, spec(time.Minute, availability), nil)
+		Expect(err).To(MatchError(ContainSubstring("SLO availability")))
+
+		e.Prometheus = nil
+		_, err = e.Evaluate(ctx, spec(time.Minute, availability), nil)
+		Expect(err).To(MatchError(ContainSubstring("--prometheus-address")))
+
+		res, err := e.Evaluate(ctx, spec(time.Minute), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Passed).To(BeTrue())
+	})
+})
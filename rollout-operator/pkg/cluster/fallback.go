@@ -0,0 +1,38 @@
+package cluster
+
+import (
+	"context"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FallbackClient 用于只缓存部分对象的 manager（--cache-managed-only）：
+// 指定类型的对象在缓存中 Get 不到时，再直接从 API server 读一次。
+// 用户预先创建、不带 managed-by 标签的工作负载与流量资源因此仍能读到，
+// 否则 Get 返回 NotFound、Create 又返回 AlreadyExists，调和会一直重试。
+// 这些对象不会被打上标签，每次都直接读取，它们的变化也不会触发调和
+type FallbackClient struct {
+	client.Client
+	// 直接读 API server，一般为 mgr.GetAPIReader()
+	Reader client.Reader
+	types  map[reflect.Type]bool
+}
+
+// NewFallbackClient objs 为缓存按标签过滤的对象类型
+func NewFallbackClient(c client.Client, reader client.Reader, objs ...client.Object) *FallbackClient {
+	types := make(map[reflect.Type]bool, len(objs))
+	for _, obj := range objs {
+		types[reflect.TypeOf(obj)] = true
+	}
+	return &FallbackClient{Client: c, Reader: reader, types: types}
+}
+
+func (c *FallbackClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := c.Client.Get(ctx, key, obj, opts...)
+	if apierrors.IsNotFound(err) && c.types[reflect.TypeOf(obj)] {
+		return c.Reader.Get(ctx, key, obj, opts...)
+	}
+	return err
+}
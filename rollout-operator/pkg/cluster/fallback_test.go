@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("FallbackClient", func() {
+	ctx := context.Background()
+	var cached, apiServer client.Client
+	var c *FallbackClient
+
+	BeforeEach(func() {
+		managed := map[string]string{"app.kubernetes.io/managed-by": "rollout-operator"}
+		objs := []client.Object{
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "demo-canary", Namespace: "default", Labels: managed}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}},
+		}
+		// 缓存只有带标签的对象，API server 上有全部对象
+		cached = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs[0]).Build()
+		apiServer = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+		c = NewFallbackClient(cached, apiServer, &appsv1.Deployment{})
+	})
+
+	It("reads an unlabeled object that the cache filters out from the API server", func() {
+		var dep appsv1.Deployment
+		Expect(c.Get(ctx, client.ObjectKey{Name: "demo", Namespace: "default"}, &dep)).To(Succeed())
+		Expect(dep.Name).To(Equal("demo"))
+		Expect(dep.Labels).To(BeEmpty())
+	})
+
+	It("serves cached objects from the cache", func() {
+		var dep appsv1.Deployment
+		Expect(c.Get(ctx, client.ObjectKey{Name: "demo-canary", Namespace: "default"}, &dep)).To(Succeed())
+		Expect(apiServer.Delete(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "demo-canary", Namespace: "default"}})).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKey{Name: "demo-canary", Namespace: "default"}, &dep)).To(Succeed())
+	})
+
+	It("still returns NotFound for objects that do not exist", func() {
+		err := c.Get(ctx, client.ObjectKey{Name: "missing", Namespace: "default"}, &appsv1.Deployment{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("does not fall back for types the cache does not filter", func() {
+		err := c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "default"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Registry hub 模式下按名称保存成员集群的客户端
+type Registry struct {
+	clients map[string]client.Client
+}
+
+func NewRegistry() *Registry {
+	return &Registry{clients: map[string]client.Client{}}
+}
+
+// Add 注册一个成员集群，同名时覆盖
+func (r *Registry) Add(name string, c client.Client) {
+	r.clients[name] = c
+}
+
+// Get 返回成员集群的客户端
+func (r *Registry) Get(name string) (client.Client, bool) {
+	c, ok := r.clients[name]
+	return c, ok
+}
+
+// Names 按字母序返回所有成员集群名称
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadDir 从目录加载 kubeconfig，文件名（去掉扩展名）即集群名称；
+// 以 . 开头的文件会被跳过，便于直接挂载包含多个 key 的 Secret
+func LoadDir(dir string, scheme *runtime.Scheme) (*Registry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	reg := NewRegistry()
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		cfg, err := clientcmd.BuildConfigFromFlags("", filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		reg.Add(name, c)
+	}
+	if len(reg.clients) == 0 {
+		return nil, fmt.Errorf("no kubeconfig found in %s", dir)
+	}
+	return reg, nil
+}
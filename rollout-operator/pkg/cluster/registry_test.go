@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: member
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: member
+  context:
+    cluster: member
+    user: member
+current-context: member
+users:
+- name: member
+  user:
+    token: test
+`
+
+var _ = Describe("LoadDir", func() {
+	write := func(dir, name, content string) {
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)).To(Succeed())
+	}
+
+	It("registers one cluster per kubeconfig file named after it", func() {
+		dir := GinkgoT().TempDir()
+		write(dir, "staging.yaml", kubeconfig)
+		write(dir, "eu-west", kubeconfig)
+		write(dir, ".hidden", "not a kubeconfig")
+		Expect(os.Mkdir(filepath.Join(dir, "..data"), 0o700)).To(Succeed())
+
+		reg, err := LoadDir(dir, scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reg.Names()).To(Equal([]string{"eu-west", "staging"}))
+		_, ok := reg.Get("staging")
+		Expect(ok).To(BeTrue())
+		_, ok = reg.Get("prod")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("names the cluster whose kubeconfig is invalid", func() {
+		dir := GinkgoT().TempDir()
+		write(dir, "broken.yaml", "clusters: [")
+		_, err := LoadDir(dir, scheme.Scheme)
+		Expect(err).To(MatchError(ContainSubstring("cluster broken")))
+	})
+
+	It("rejects a directory without kubeconfigs", func() {
+		_, err := LoadDir(GinkgoT().TempDir(), scheme.Scheme)
+		Expect(err).To(MatchError(ContainSubstring("no kubeconfig found")))
+	})
+})
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// 分析结果标签取值
+const (
+	ResultPassed       = "passed"
+	ResultFailed       = "failed"
+	ResultInconclusive = "inconclusive"
+	ResultError        = "error"
+)
+
+var (
+	// RolloutPhase 每个 Rollout 当前所处阶段，当前阶段为 1，其余为 0
+	RolloutPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rollout_phase",
+		Help: "Current phase of the rollout (1 for the active phase, 0 otherwise).",
+	}, []string{"namespace", "name", "phase"})
+
+	// CanaryWeight 当前下发到流量层的金丝雀权重
+	CanaryWeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rollout_canary_weight",
+		Help: "Canary traffic weight currently applied by the traffic provider.",
+	}, []string{"namespace", "name"})
+
+	// StepIndex 当前步骤下标
+	StepIndex = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rollout_step_index",
+		Help: "Index of the canary step the rollout is currently on.",
+	}, []string{"namespace", "name"})
+
+	// AnalysisEvaluations 分析评估次数，按结果区分
+	AnalysisEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rollout_analysis_evaluations_total",
+		Help: "Number of analysis evaluations, partitioned by result.",
+	}, []string{"namespace", "name", "result"})
+
+	// Rollbacks 回滚次数
+	Rollbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rollout_rollbacks_total",
+		Help: "Number of times a rollout was rolled back to stable.",
+	}, []string{"namespace", "name"})
+
+	// TrafficDrifts 流量层权重被外部修改、由控制器恢复的次数
+	TrafficDrifts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rollout_traffic_drifts_total",
+		Help: "Number of times the applied canary weight drifted from the expected weight and was restored.",
+	}, []string{"namespace", "name"})
+
+	// StepDuration 单个步骤从下发权重到结束（下一步开始或晋级）所经历的时间，包括分析与 hold
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rollout_step_duration_seconds",
+		Help:    "Time spent on a canary step, from setting its weight until the next step started or the rollout was promoted.",
+		Buckets: []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		RolloutPhase,
+		CanaryWeight,
+		StepIndex,
+		AnalysisEvaluations,
+		Rollbacks,
+		TrafficDrifts,
+		StepDuration,
+	)
+}
+
+// SetPhase 将当前阶段置 1，phases 中其余阶段置 0
+func SetPhase(namespace, name, phase string, phases []string) {
+	for _, p := range phases {
+		v := 0.0
+		if p == phase {
+			v = 1
+		}
+		RolloutPhase.WithLabelValues(namespace, name, p).Set(v)
+	}
+}
+
+// SetStep 记录当前步骤下标与权重
+func SetStep(namespace, name string, index, weight int32) {
+	StepIndex.WithLabelValues(namespace, name).Set(float64(index))
+	CanaryWeight.WithLabelValues(namespace, name).Set(float64(weight))
+}
+
+// SetWeight 只更新金丝雀权重（晋级后为 100，回滚后为 0）
+func SetWeight(namespace, name string, weight int32) {
+	CanaryWeight.WithLabelValues(namespace, name).Set(float64(weight))
+}
+
+// ObserveAnalysis 记录一次分析评估结果
+func ObserveAnalysis(namespace, name, result string) {
+	AnalysisEvaluations.WithLabelValues(namespace, name, result).Inc()
+}
+
+// ObserveRollback 记录一次回滚
+func ObserveRollback(namespace, name string) {
+	Rollbacks.WithLabelValues(namespace, name).Inc()
+}
+
+// ObserveDrift 记录一次流量漂移
+func ObserveDrift(namespace, name string) {
+	TrafficDrifts.WithLabelValues(namespace, name).Inc()
+}
+
+// ObserveStepDuration 记录步骤从下发权重到结束的耗时，开始时间取自 Rollout 状态
+func ObserveStepDuration(namespace, name string, d time.Duration) {
+	StepDuration.WithLabelValues(namespace, name).Observe(d.Seconds())
+}
+
+// Forget 删除 Rollout 被删除后遗留的时间序列
+func Forget(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	RolloutPhase.DeletePartialMatch(labels)
+	CanaryWeight.DeletePartialMatch(labels)
+	StepIndex.DeletePartialMatch(labels)
+	AnalysisEvaluations.DeletePartialMatch(labels)
+	Rollbacks.DeletePartialMatch(labels)
+	TrafficDrifts.DeletePartialMatch(labels)
+	StepDuration.DeletePartialMatch(labels)
+}
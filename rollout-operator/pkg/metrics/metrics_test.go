@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var _ = Describe("Metrics", func() {
+	const ns, name = "default", "demo"
+
+	AfterEach(func() {
+		Forget(ns, name)
+	})
+
+	It("registers every collector with the controller-runtime registry", func() {
+		SetPhase(ns, name, "Progressing", []string{"Progressing", "Succeeded"})
+		SetStep(ns, name, 1, 20)
+		ObserveAnalysis(ns, name, ResultPassed)
+		ObserveRollback(ns, name)
+		ObserveDrift(ns, name)
+		ObserveStepDuration(ns, name, time.Minute)
+
+		for _, metric := range []string{
+			"rollout_phase",
+			"rollout_canary_weight",
+			"rollout_step_index",
+			"rollout_analysis_evaluations_total",
+			"rollout_rollbacks_total",
+			"rollout_traffic_drifts_total",
+			"rollout_step_duration_seconds",
+		} {
+			n, err := testutil.GatherAndCount(ctrlmetrics.Registry, metric)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(BeNumerically(">", 0), metric)
+		}
+	})
+
+	It("sets only the current phase to 1", func() {
+		phases := []string{"Progressing", "Analyzing", "Succeeded"}
+		SetPhase(ns, name, "Analyzing", phases)
+		Expect(testutil.ToFloat64(RolloutPhase.WithLabelValues(ns, name, "Progressing"))).To(BeZero())
+		Expect(testutil.ToFloat64(RolloutPhase.WithLabelValues(ns, name, "Analyzing"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(RolloutPhase.WithLabelValues(ns, name, "Succeeded"))).To(BeZero())
+	})
+
+	It("labels the step, weight and analysis results", func() {
+		SetStep(ns, name, 2, 50)
+		Expect(testutil.ToFloat64(StepIndex.WithLabelValues(ns, name))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(CanaryWeight.WithLabelValues(ns, name))).To(Equal(50.0))
+		SetWeight(ns, name, 100)
+		Expect(testutil.ToFloat64(CanaryWeight.WithLabelValues(ns, name))).To(Equal(100.0))
+
+		ObserveAnalysis(ns, name, ResultPassed)
+		ObserveAnalysis(ns, name, ResultPassed)
+		ObserveAnalysis(ns, name, ResultInconclusive)
+		Expect(testutil.ToFloat64(AnalysisEvaluations.WithLabelValues(ns, name, ResultPassed))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(AnalysisEvaluations.WithLabelValues(ns, name, ResultInconclusive))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(AnalysisEvaluations.WithLabelValues(ns, name, ResultFailed))).To(BeZero())
+	})
+
+	It("records step durations in seconds", func() {
+		ObserveStepDuration(ns, name, 90*time.Second)
+		Expect(testutil.CollectAndCompare(StepDuration, strings.NewReader(`
+# HELP rollout_step_duration_seconds Time spent on a canary step, from setting its weight until the next step started or the rollout was promoted.
+# TYPE rollout_step_duration_seconds histogram
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="5"} 0
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="15"} 0
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="30"} 0
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="60"} 0
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="120"} 1
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="300"} 1
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="600"} 1
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="1200"} 1
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="1800"} 1
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="3600"} 1
+rollout_step_duration_seconds_bucket{name="demo",namespace="default",le="+Inf"} 1
+rollout_step_duration_seconds_sum{name="demo",namespace="default"} 90
+rollout_step_duration_seconds_count{name="demo",namespace="default"} 1
+`))).To(Succeed())
+	})
+
+	It("forgets every series of a deleted rollout", func() {
+		SetPhase(ns, name, "Progressing", []string{"Progressing"})
+		SetStep(ns, name, 0, 20)
+		ObserveAnalysis(ns, name, ResultFailed)
+		ObserveRollback(ns, name)
+		ObserveDrift(ns, name)
+		ObserveStepDuration(ns, name, time.Second)
+		SetStep("other", name, 0, 20)
+		DeferCleanup(Forget, "other", name)
+
+		Forget(ns, name)
+		Expect(testutil.CollectAndCount(RolloutPhase)).To(BeZero())
+		Expect(testutil.CollectAndCount(AnalysisEvaluations)).To(BeZero())
+		Expect(testutil.CollectAndCount(Rollbacks)).To(BeZero())
+		Expect(testutil.CollectAndCount(TrafficDrifts)).To(BeZero())
+		Expect(testutil.CollectAndCount(StepDuration)).To(BeZero())
+		Expect(testutil.CollectAndCount(StepIndex)).To(Equal(1))
+		Expect(testutil.CollectAndCount(CanaryWeight)).To(Equal(1))
+	})
+})
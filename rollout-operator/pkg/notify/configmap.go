@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigMapKey 集群级通知 ConfigMap 中存放目标列表的 key
+const ConfigMapKey = "notifiers.yaml"
+
+// FromConfigMap 解析集群级 ConfigMap 中的通知目标列表，例如：
+//
+//	notifiers.yaml: |
+//	  - type: Slack
+//	    url: https://hooks.slack.com/services/xxx
+//	    phases: [RolledBack, Failed]
+func FromConfigMap(cm *corev1.ConfigMap) ([]Config, error) {
+	raw, ok := cm.Data[ConfigMapKey]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	var cfgs []Config
+	if err := yaml.Unmarshal([]byte(raw), &cfgs); err != nil {
+		return nil, fmt.Errorf("parse %s/%s key %s: %w", cm.Namespace, cm.Name, ConfigMapKey, err)
+	}
+	return cfgs, nil
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Dispatcher 把事件发送到匹配的通知目标，负责重试与按目标限流
+type Dispatcher struct {
+	Client *http.Client
+	// MaxRetries 首次发送失败后的最大重试次数
+	MaxRetries int
+	// Backoff 首次重试前的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// Rate/Burst 每个 URL 的令牌桶参数
+	Rate  rate.Limit
+	Burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewDispatcher 返回带默认重试与限流参数的 Dispatcher
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		MaxRetries: 3,
+		Backoff:    time.Second,
+		Rate:       rate.Every(time.Second),
+		Burst:      5,
+	}
+}
+
+// Dispatch 同步发送事件到所有匹配的目标，返回聚合后的错误
+func (d *Dispatcher) Dispatch(ctx context.Context, cfgs []Config, ev Event) error {
+	lg := log.FromContext(ctx)
+	var errs []error
+	for _, cfg := range cfgs {
+		if !cfg.Matches(ev.Phase) {
+			continue
+		}
+		n, err := New(cfg, d.Client)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := d.limiter(cfg.URL).Wait(ctx); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := d.send(ctx, n, ev); err != nil {
+			lg.Info("Failed to send notification", "type", cfg.Type, "phase", ev.Phase, "err", err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		lg.Info("Notification sent", "type", cfg.Type, "phase", ev.Phase)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (d *Dispatcher) send(ctx context.Context, n Notifier, ev Event) error {
+	backoff := d.Backoff
+	var err error
+	for attempt := 0; attempt <= d.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = n.Notify(ctx, ev); err == nil {
+			return nil
+		}
+		var se *StatusError
+		if errors.As(err, &se) && !se.Retryable() {
+			return err
+		}
+	}
+	return err
+}
+
+func (d *Dispatcher) limiter(key string) *rate.Limiter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.limiters == nil {
+		d.limiters = map[string]*rate.Limiter{}
+	}
+	l, ok := d.limiters[key]
+	if !ok {
+		limit, burst := d.Rate, d.Burst
+		if limit == 0 {
+			limit = rate.Inf
+		}
+		if burst <= 0 {
+			burst = 1
+		}
+		l = rate.NewLimiter(limit, burst)
+		d.limiters[key] = l
+	}
+	return l
+}
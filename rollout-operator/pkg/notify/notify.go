@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// 支持的通知类型
+const (
+	TypeWebhook  = "Webhook"
+	TypeSlack    = "Slack"
+	TypeTemplate = "Template"
+)
+
+// Event 描述一次 Rollout 阶段变化；Message 为回滚、失败或暂停的原因，取自 Aborted 或 AnalysisFailed 条件
+type Event struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	OldPhase  string    `json:"oldPhase"`
+	Phase     string    `json:"phase"`
+	StepIndex int32     `json:"stepIndex"`
+	Weight    int32     `json:"weight"`
+	Message   string    `json:"message,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Summary 返回一行可读的事件描述
+func (e Event) Summary() string {
+	s := fmt.Sprintf("Rollout %s/%s: %s -> %s (step %d, canary weight %d%%)",
+		e.Namespace, e.Name, e.OldPhase, e.Phase, e.StepIndex, e.Weight)
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+// Config 单个通知目标的配置
+type Config struct {
+	Type    string            `json:"type"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template 仅 Template 类型使用，以 Event 为数据渲染请求体
+	Template string `json:"template,omitempty"`
+	// Phases 为空时使用 DefaultPhases
+	Phases []string `json:"phases,omitempty"`
+}
+
+// DefaultPhases 未配置 Phases 时通知的阶段：发布停下或结束；Progressing 与 Analyzing 之间的切换不通知
+var DefaultPhases = []string{"Paused", "Succeeded", "Failed", "RolledBack"}
+
+// Matches 判断事件阶段是否需要发送到该目标
+func (c Config) Matches(phase string) bool {
+	phases := c.Phases
+	if len(phases) == 0 {
+		phases = DefaultPhases
+	}
+	for _, p := range phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// New 根据配置构造对应的 Notifier
+func New(cfg Config, hc *http.Client) (Notifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("notifier %q: url is required", cfg.Type)
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	switch cfg.Type {
+	case TypeWebhook, "":
+		return &WebhookNotifier{URL: cfg.URL, Headers: cfg.Headers, Client: hc}, nil
+	case TypeSlack:
+		return &SlackNotifier{URL: cfg.URL, Client: hc}, nil
+	case TypeTemplate:
+		tpl, err := template.New("notification").Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parse notification template: %w", err)
+		}
+		return &TemplateNotifier{URL: cfg.URL, Headers: cfg.Headers, Template: tpl, Client: hc}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", cfg.Type)
+	}
+}
+
+// WebhookNotifier 以 JSON 形式 POST 整个 Event
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return post(ctx, n.Client, n.URL, withDefaultContentType(n.Headers), body)
+}
+
+// SlackNotifier 使用 Slack incoming webhook 格式 {"text": "..."}
+type SlackNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(map[string]string{"text": ev.Summary()})
+	if err != nil {
+		return err
+	}
+	return post(ctx, n.Client, n.URL, withDefaultContentType(nil), body)
+}
+
+// TemplateNotifier 用 text/template 渲染请求体，适配任意 HTTP 接收端
+type TemplateNotifier struct {
+	URL      string
+	Headers  map[string]string
+	Template *template.Template
+	Client   *http.Client
+}
+
+func (n *TemplateNotifier) Notify(ctx context.Context, ev Event) error {
+	var buf bytes.Buffer
+	if err := n.Template.Execute(&buf, ev); err != nil {
+		return fmt.Errorf("render notification template: %w", err)
+	}
+	return post(ctx, n.Client, n.URL, withDefaultContentType(n.Headers), buf.Bytes())
+}
+
+// StatusError 接收端返回了非 2xx 状态码
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("notification endpoint returned %d: %s", e.Code, e.Body)
+}
+
+// Retryable 5xx 与 429 可重试，其余 4xx 重试也不会成功
+func (e *StatusError) Retryable() bool {
+	return e.Code >= 500 || e.Code == http.StatusTooManyRequests
+}
+
+func post(ctx context.Context, hc *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{Code: resp.StatusCode, Body: string(msg)}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func withDefaultContentType(headers map[string]string) map[string]string {
+	out := map[string]string{"Content-Type": "application/json"}
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// recorder 本地 HTTP 替身，按顺序返回预设状态码并记录请求
+type recorder struct {
+	mu       sync.Mutex
+	codes    []int
+	bodies   []string
+	headers  []http.Header
+	requests int
+}
+
+func (rc *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	b, _ := io.ReadAll(req.Body)
+	rc.bodies = append(rc.bodies, string(b))
+	rc.headers = append(rc.headers, req.Header.Clone())
+	code := http.StatusOK
+	if rc.requests < len(rc.codes) {
+		code = rc.codes[rc.requests]
+	}
+	rc.requests++
+	w.WriteHeader(code)
+}
+
+var _ = Describe("Dispatcher", func() {
+	var (
+		ctx = context.Background()
+		rec *recorder
+		srv *httptest.Server
+		d   *Dispatcher
+		ev  Event
+	)
+
+	BeforeEach(func() {
+		rec = &recorder{}
+		srv = httptest.NewServer(rec)
+		d = &Dispatcher{Client: srv.Client(), MaxRetries: 2, Backoff: time.Millisecond}
+		ev = Event{Namespace: "default", Name: "demo", OldPhase: "Analyzing", Phase: "RolledBack", StepIndex: 1, Weight: 0}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("posts the event as JSON for webhook targets", func() {
+		Expect(d.Dispatch(ctx, []Config{{Type: TypeWebhook, URL: srv.URL, Headers: map[string]string{"X-Token": "t"}}}, ev)).To(Succeed())
+		Expect(rec.bodies).To(HaveLen(1))
+		var got Event
+		Expect(json.Unmarshal([]byte(rec.bodies[0]), &got)).To(Succeed())
+		Expect(got.Phase).To(Equal("RolledBack"))
+		Expect(got.Name).To(Equal("demo"))
+		Expect(rec.headers[0].Get("Content-Type")).To(Equal("application/json"))
+		Expect(rec.headers[0].Get("X-Token")).To(Equal("t"))
+	})
+
+	It("uses the Slack incoming webhook payload", func() {
+		Expect(d.Dispatch(ctx, []Config{{Type: TypeSlack, URL: srv.URL}}, ev)).To(Succeed())
+		var got map[string]string
+		Expect(json.Unmarshal([]byte(rec.bodies[0]), &got)).To(Succeed())
+		Expect(got).To(HaveKeyWithValue("text", ContainSubstring("default/demo: Analyzing -> RolledBack")))
+	})
+
+	It("renders the body from a template", func() {
+		cfg := Config{Type: TypeTemplate, URL: srv.URL, Template: `{{.Name}} is {{.Phase}}`,
+			Headers: map[string]string{"Content-Type": "text/plain"}}
+		Expect(d.Dispatch(ctx, []Config{cfg}, ev)).To(Succeed())
+		Expect(rec.bodies).To(Equal([]string{"demo is RolledBack"}))
+		Expect(rec.headers[0].Get("Content-Type")).To(Equal("text/plain"))
+	})
+
+	It("skips targets not subscribed to the phase", func() {
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL, Phases: []string{"Succeeded"}}}, ev)).To(Succeed())
+		Expect(rec.requests).To(BeZero())
+	})
+
+	It("only notifies stops and completions by default", func() {
+		ev.OldPhase, ev.Phase = "Progressing", "Analyzing"
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL}}, ev)).To(Succeed())
+		Expect(rec.requests).To(BeZero())
+
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL, Phases: []string{"Analyzing"}}}, ev)).To(Succeed())
+		Expect(rec.requests).To(Equal(1))
+	})
+
+	It("retries server errors until success", func() {
+		rec.codes = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL}}, ev)).To(Succeed())
+		Expect(rec.requests).To(Equal(3))
+	})
+
+	It("gives up after MaxRetries", func() {
+		rec.codes = []int{500, 500, 500, 500}
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL}}, ev)).NotTo(Succeed())
+		Expect(rec.requests).To(Equal(3))
+	})
+
+	It("does not retry client errors", func() {
+		rec.codes = []int{http.StatusBadRequest}
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL}}, ev)).NotTo(Succeed())
+		Expect(rec.requests).To(Equal(1))
+	})
+
+	It("rate limits each target", func() {
+		d.Rate = rate.Every(time.Hour)
+		d.Burst = 1
+		Expect(d.Dispatch(ctx, []Config{{URL: srv.URL}}, ev)).To(Succeed())
+
+		shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		Expect(d.Dispatch(shortCtx, []Config{{URL: srv.URL}}, ev)).NotTo(Succeed())
+		Expect(rec.requests).To(Equal(1))
+	})
+
+	It("rejects unknown notifier types", func() {
+		Expect(d.Dispatch(ctx, []Config{{Type: "Pager", URL: srv.URL}}, ev)).NotTo(Succeed())
+		Expect(rec.requests).To(BeZero())
+	})
+})
+
+var _ = Describe("FromConfigMap", func() {
+	It("parses the notifier list", func() {
+		cm := &corev1.ConfigMap{Data: map[string]string{ConfigMapKey: `
+- type: Slack
+  url: http://hooks.local/a
+  phases: [RolledBack, Failed]
+- url: http://hooks.local/b
+`}}
+		cfgs, err := FromConfigMap(cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfgs).To(HaveLen(2))
+		Expect(cfgs[0].Type).To(Equal(TypeSlack))
+		Expect(cfgs[0].Matches("Failed")).To(BeTrue())
+		Expect(cfgs[0].Matches("Succeeded")).To(BeFalse())
+		Expect(cfgs[1].Matches("Succeeded")).To(BeTrue())
+	})
+
+	It("returns nothing when the key is missing", func() {
+		cfgs, err := FromConfigMap(&corev1.ConfigMap{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfgs).To(BeEmpty())
+	})
+})
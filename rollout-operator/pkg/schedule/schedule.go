@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout 封禁日期的格式
+const DateLayout = "2006-01-02"
+
+// maxSearchSteps NextAllowed 最多跳转的次数，防止窗口与封禁日期相互覆盖时无限查找
+const maxSearchSteps = 1000
+
+// Window 从 Start 每次触发开始、持续 Duration 的允许发布时段
+type Window struct {
+	Start    *Cron
+	Duration time.Duration
+}
+
+// Blackout 禁止发布的日期区间（按日，含首尾）
+type Blackout struct {
+	From, To time.Time
+}
+
+// ParseBlackout 解析 "2025-12-24" 或 "2025-12-24/2025-12-26"
+func ParseBlackout(s string) (Blackout, error) {
+	from, to, isRange := strings.Cut(s, "/")
+	f, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return Blackout{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", from)
+	}
+	b := Blackout{From: f, To: f}
+	if isRange {
+		if b.To, err = time.Parse(DateLayout, to); err != nil {
+			return Blackout{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", to)
+		}
+		if b.To.Before(b.From) {
+			return Blackout{}, fmt.Errorf("blackout %q ends before it starts", s)
+		}
+	}
+	return b, nil
+}
+
+// contains 判断某个本地日期是否落在区间内
+func (b Blackout) contains(t time.Time) bool {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return !day.Before(b.From) && !day.After(b.To)
+}
+
+// Reason 不允许发布的原因
+type Reason string
+
+const (
+	// ReasonOutsideWindow 当前不在任何允许时段内
+	ReasonOutsideWindow Reason = "OutsideWindow"
+	// ReasonBlackout 当天是封禁日期
+	ReasonBlackout Reason = "Blackout"
+)
+
+// Schedule 发布时段与封禁日期；Windows 为空表示除封禁日期外全天允许
+type Schedule struct {
+	Location  *time.Location
+	Windows   []Window
+	Blackouts []Blackout
+}
+
+// Allowed 判断 t 时刻是否允许推进发布，不允许时返回原因
+func (s *Schedule) Allowed(t time.Time) (bool, Reason) {
+	t = t.In(s.location())
+	for _, b := range s.Blackouts {
+		if b.contains(t) {
+			return false, ReasonBlackout
+		}
+	}
+	if len(s.Windows) == 0 {
+		return true, ""
+	}
+	for _, w := range s.Windows {
+		// 最近一次在 (t-Duration, t] 内开始的窗口仍未结束
+		if start := w.Start.Next(t.Add(-w.Duration)); !start.IsZero() && !start.After(t) {
+			return true, ""
+		}
+	}
+	return false, ReasonOutsideWindow
+}
+
+// NextAllowed 返回不早于 t 的第一个允许发布的时刻；找不到时 ok 为 false
+func (s *Schedule) NextAllowed(t time.Time) (next time.Time, ok bool) {
+	loc := s.location()
+	t = t.In(loc)
+	for i := 0; i < maxSearchSteps; i++ {
+		allowed, reason := s.Allowed(t)
+		if allowed {
+			return t, true
+		}
+		if reason == ReasonBlackout {
+			// 跳到第二天零点，零点时可能正处于前一天开始的窗口中
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		var earliest time.Time
+		for _, w := range s.Windows {
+			if start := w.Start.Next(t); !start.IsZero() && (earliest.IsZero() || start.Before(earliest)) {
+				earliest = start
+			}
+		}
+		if earliest.IsZero() {
+			return time.Time{}, false
+		}
+		t = earliest
+	}
+	return time.Time{}, false
+}
+
+func (s *Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func mustCron(expr string) *Cron {
+	c, err := ParseCron(expr)
+	Expect(err).NotTo(HaveOccurred())
+	return c
+}
+
+func at(value string, loc *time.Location) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	Expect(err).NotTo(HaveOccurred())
+	return t
+}
+
+var _ = Describe("Cron", func() {
+	DescribeTable("rejects malformed expressions",
+		func(expr string) {
+			_, err := ParseCron(expr)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("too few fields", "0 9 * *"),
+		Entry("out of range", "60 * * * *"),
+		Entry("inverted range", "0 17-9 * * *"),
+		Entry("zero step", "*/0 * * * *"),
+		Entry("not a number", "0 nine * * *"),
+	)
+
+	DescribeTable("finds the next matching minute",
+		func(expr, from, want string) {
+			Expect(mustCron(expr).Next(at(from, time.UTC))).To(Equal(at(want, time.UTC)))
+		},
+		Entry("strictly after the given time", "0 9 * * *", "2025-03-03 09:00", "2025-03-04 09:00"),
+		Entry("weekdays only", "0 9 * * 1-5", "2025-03-07 10:00", "2025-03-10 09:00"),
+		Entry("sunday as 7", "30 2 * * 7", "2025-03-03 00:00", "2025-03-09 02:30"),
+		Entry("steps with an offset", "5/20 * * * *", "2025-03-03 10:26", "2025-03-03 10:45"),
+		Entry("lists", "0 9,14 * * *", "2025-03-03 10:00", "2025-03-03 14:00"),
+		Entry("day-of-month or day-of-week", "0 0 1 * 1", "2025-03-04 00:00", "2025-03-10 00:00"),
+		Entry("month rollover", "0 0 1 1 *", "2025-03-03 00:00", "2026-01-01 00:00"),
+	)
+
+	It("never fires for impossible dates", func() {
+		Expect(mustCron("0 0 30 2 *").Next(at("2025-01-01 00:00", time.UTC)).IsZero()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Schedule", func() {
+	var shanghai *time.Location
+
+	BeforeEach(func() {
+		var err error
+		shanghai, err = time.LoadLocation("Asia/Shanghai")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// 工作日 10:00-16:00（上海时间）
+	businessHours := func() *Schedule {
+		return &Schedule{
+			Location: shanghai,
+			Windows:  []Window{{Start: mustCron("0 10 * * 1-5"), Duration: 6 * time.Hour}},
+		}
+	}
+
+	It("allows changes inside a window in its time zone", func() {
+		s := businessHours()
+		ok, _ := s.Allowed(at("2025-03-03 11:00", shanghai))
+		Expect(ok).To(BeTrue())
+		// 03:00 UTC 即上海 11:00
+		ok, _ = s.Allowed(at("2025-03-03 03:00", time.UTC))
+		Expect(ok).To(BeTrue())
+	})
+
+	It("treats the end of a window as exclusive", func() {
+		ok, reason := businessHours().Allowed(at("2025-03-03 16:00", shanghai))
+		Expect(ok).To(BeFalse())
+		Expect(reason).To(Equal(ReasonOutsideWindow))
+	})
+
+	It("finds the next window after a weekend", func() {
+		next, ok := businessHours().NextAllowed(at("2025-03-07 17:00", shanghai))
+		Expect(ok).To(BeTrue())
+		Expect(next).To(BeTemporally("==", at("2025-03-10 10:00", shanghai)))
+	})
+
+	It("blocks blackout days even inside a window", func() {
+		s := businessHours()
+		b, err := ParseBlackout("2025-03-10/2025-03-11")
+		Expect(err).NotTo(HaveOccurred())
+		s.Blackouts = []Blackout{b}
+
+		ok, reason := s.Allowed(at("2025-03-10 11:00", shanghai))
+		Expect(ok).To(BeFalse())
+		Expect(reason).To(Equal(ReasonBlackout))
+
+		next, ok := s.NextAllowed(at("2025-03-10 11:00", shanghai))
+		Expect(ok).To(BeTrue())
+		Expect(next).To(BeTemporally("==", at("2025-03-12 10:00", shanghai)))
+	})
+
+	It("resumes at midnight when a window spans the end of a blackout", func() {
+		b, err := ParseBlackout("2025-03-03")
+		Expect(err).NotTo(HaveOccurred())
+		s := &Schedule{
+			Windows:   []Window{{Start: mustCron("0 22 * * *"), Duration: 4 * time.Hour}},
+			Blackouts: []Blackout{b},
+		}
+		next, ok := s.NextAllowed(at("2025-03-03 22:30", time.UTC))
+		Expect(ok).To(BeTrue())
+		Expect(next).To(Equal(at("2025-03-04 00:00", time.UTC)))
+	})
+
+	It("allows any time outside blackouts without windows", func() {
+		b, err := ParseBlackout("2025-12-25")
+		Expect(err).NotTo(HaveOccurred())
+		s := &Schedule{Blackouts: []Blackout{b}}
+		ok, _ := s.Allowed(at("2025-12-24 23:59", time.UTC))
+		Expect(ok).To(BeTrue())
+		ok, _ = s.Allowed(at("2025-12-25 12:00", time.UTC))
+		Expect(ok).To(BeFalse())
+	})
+
+	DescribeTable("rejects malformed blackouts",
+		func(value string) {
+			_, err := ParseBlackout(value)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("bad date", "2025-13-01"),
+		Entry("bad format", "25/12/2025"),
+		Entry("inverted range", "2025-12-26/2025-12-24"),
+	)
+})
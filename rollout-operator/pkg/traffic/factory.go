@@ -0,0 +1,205 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Factory 按 Rollout 所在的 namespace 与 spec.traffic 中的设置返回流量层实现
+type Factory interface {
+	For(ctx context.Context, namespace string, opts Options) (Provider, error)
+}
+
+// spec.traffic.provider 的取值，ByProvider 据此选择 Factory
+const (
+	ProviderNginxIngress = "NginxIngress"
+	ProviderReplicaRatio = "ReplicaRatio"
+	ProviderSMI          = "SMI"
+)
+
+// Options 单个 Rollout 的流量设置
+type Options struct {
+	// Provider 为空时按 NginxIngress 处理
+	Provider string
+	// StickySession 为空时不做会话保持
+	StickySession *StickySession
+	// ReplicaRatio 只有 ReplicaRatio provider 使用
+	ReplicaRatio *ReplicaRatioOptions
+	// Client 不为空时 provider 用它读写流量资源，代替 factory 自己的 client；
+	// 控制器计算发布计划时传入只记录写操作的 client
+	Client client.Client
+}
+
+// clientOr 返回 opts.Client，为空时返回 fallback
+func (o Options) clientOr(fallback client.Client) client.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return fallback
+}
+
+// ReplicaRatioOptions 按副本数分流时需要的工作负载信息
+type ReplicaRatioOptions struct {
+	StableDeployment string
+	CanaryDeployment string
+	// Replicas stable 与 canary 共享的 Pod 总数
+	Replicas int32
+}
+
+// StickySession 基于 cookie 的会话保持
+type StickySession struct {
+	CookieName string
+	// MaxAgeSeconds 为 0 时 cookie 在浏览器关闭后失效
+	MaxAgeSeconds int32
+}
+
+// Static 所有 namespace 共用同一个 Provider，用于测试或只有一个 namespace 的部署；忽略 Options.Client
+type Static struct {
+	Provider Provider
+}
+
+func (s Static) For(context.Context, string, Options) (Provider, error) {
+	return s.Provider, nil
+}
+
+// ByProvider 按 Options.Provider 选择对应的 Factory
+type ByProvider map[string]Factory
+
+func (b ByProvider) For(ctx context.Context, namespace string, opts Options) (Provider, error) {
+	name := opts.Provider
+	if name == "" {
+		name = ProviderNginxIngress
+	}
+	f, ok := b[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported traffic provider %q", name)
+	}
+	return f.For(ctx, namespace, opts)
+}
+
+// ConfigMapKey 流量层配置 ConfigMap 中存放配置的 key
+const ConfigMapKey = "traffic.yaml"
+
+// NamespaceConfig 单个 namespace 的 provider 配置
+type NamespaceConfig struct {
+	// IngressClassName 创建 ingress 使用的 class，默认 nginx
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// Annotations 附加到 provider 创建的 ingress 上，如证书签发、WAF 等注解
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Config 流量层配置：default 作用于所有 namespace，namespaces 中的同名字段覆盖 default
+type Config struct {
+	Default    NamespaceConfig            `json:"default,omitempty"`
+	Namespaces map[string]NamespaceConfig `json:"namespaces,omitempty"`
+}
+
+// For 合并 default 与指定 namespace 的配置
+func (c *Config) For(namespace string) NamespaceConfig {
+	out := NamespaceConfig{IngressClassName: c.Default.IngressClassName}
+	if len(c.Default.Annotations) > 0 {
+		out.Annotations = make(map[string]string, len(c.Default.Annotations))
+		for k, v := range c.Default.Annotations {
+			out.Annotations[k] = v
+		}
+	}
+	ns, ok := c.Namespaces[namespace]
+	if !ok {
+		return out
+	}
+	if ns.IngressClassName != "" {
+		out.IngressClassName = ns.IngressClassName
+	}
+	for k, v := range ns.Annotations {
+		if out.Annotations == nil {
+			out.Annotations = map[string]string{}
+		}
+		out.Annotations[k] = v
+	}
+	return out
+}
+
+// ConfigFromConfigMap 解析流量层配置 ConfigMap，例如：
+//
+//	traffic.yaml: |
+//	  default:
+//	    ingressClassName: nginx
+//	  namespaces:
+//	    payments:
+//	      ingressClassName: nginx-internal
+//	      annotations:
+//	        nginx.ingress.kubernetes.io/ssl-redirect: "true"
+func ConfigFromConfigMap(cm *corev1.ConfigMap) (*Config, error) {
+	cfg := &Config{}
+	raw, ok := cm.Data[ConfigMapKey]
+	if !ok || raw == "" {
+		return cfg, nil
+	}
+	if err := yaml.Unmarshal([]byte(raw), cfg); err != nil {
+		return nil, fmt.Errorf("parse %s/%s key %s: %w", cm.Namespace, cm.Name, ConfigMapKey, err)
+	}
+	return cfg, nil
+}
+
+// NginxFactory 为每个 namespace 构造 NginxProvider，ingress 与 Rollout 位于同一 namespace
+type NginxFactory struct {
+	Client client.Client
+	// ConfigMap 按 namespace 覆盖 provider 配置；Name 为空时全部使用默认值
+	ConfigMap types.NamespacedName
+}
+
+func (f *NginxFactory) For(ctx context.Context, namespace string, opts Options) (Provider, error) {
+	p := &NginxProvider{Client: opts.clientOr(f.Client), Namespace: namespace, StickySession: opts.StickySession}
+	if f.ConfigMap.Name == "" {
+		return p, nil
+	}
+	var cm corev1.ConfigMap
+	if err := f.Client.Get(ctx, f.ConfigMap, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return p, nil
+		}
+		return nil, err
+	}
+	cfg, err := ConfigFromConfigMap(&cm)
+	if err != nil {
+		return nil, err
+	}
+	nc := cfg.For(namespace)
+	p.IngressClassName = nc.IngressClassName
+	p.Annotations = nc.Annotations
+	return p, nil
+}
+
+// ReplicaRatioFactory 为每个 Rollout 构造 ReplicaRatioProvider，共享 Service 与 Rollout 位于同一 namespace
+type ReplicaRatioFactory struct {
+	Client client.Client
+}
+
+func (f *ReplicaRatioFactory) For(_ context.Context, namespace string, opts Options) (Provider, error) {
+	rr := opts.ReplicaRatio
+	if rr == nil {
+		return nil, fmt.Errorf("%s provider requires replica ratio options", ProviderReplicaRatio)
+	}
+	return &ReplicaRatioProvider{
+		Client:           opts.clientOr(f.Client),
+		Namespace:        namespace,
+		StableDeployment: rr.StableDeployment,
+		CanaryDeployment: rr.CanaryDeployment,
+		Replicas:         rr.Replicas,
+	}, nil
+}
+
+// SMIFactory 为每个 namespace 构造 SMIProvider，TrafficSplit 与 Rollout 位于同一 namespace
+type SMIFactory struct {
+	Client client.Client
+}
+
+func (f *SMIFactory) For(_ context.Context, namespace string, opts Options) (Provider, error) {
+	return &SMIProvider{Client: opts.clientOr(f.Client), Namespace: namespace}, nil
+}
@@ -12,9 +12,33 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	canaryAnnotation       = "nginx.ingress.kubernetes.io/canary"
+	canaryWeightAnnotation = "nginx.ingress.kubernetes.io/canary-weight"
+
+	affinityAnnotation               = "nginx.ingress.kubernetes.io/affinity"
+	affinityCanaryBehaviorAnnotation = "nginx.ingress.kubernetes.io/affinity-canary-behavior"
+	sessionCookieNameAnnotation      = "nginx.ingress.kubernetes.io/session-cookie-name"
+	sessionCookieMaxAgeAnnotation    = "nginx.ingress.kubernetes.io/session-cookie-max-age"
+)
+
+// stickyAnnotationKeys 会话保持相关的注解，关闭会话保持时从 ingress 上移除
+var stickyAnnotationKeys = []string{
+	affinityAnnotation,
+	affinityCanaryBehaviorAnnotation,
+	sessionCookieNameAnnotation,
+	sessionCookieMaxAgeAnnotation,
+}
+
 type NginxProvider struct {
 	Client    client.Client
 	Namespace string
+	// IngressClassName 为空时使用 nginx
+	IngressClassName string
+	// Annotations 附加到创建的 ingress 上
+	Annotations map[string]string
+	// StickySession 不为空时 stable/canary ingress 都开启 cookie 会话保持
+	StickySession *StickySession
 }
 
 // SetWeight 设置金丝雀流量权重
@@ -59,6 +83,36 @@ func (p *NginxProvider) Reset(ctx context.Context, host, stable, canary string)
 	return p.deleteCanaryIngress(ctx, host)
 }
 
+// CanaryWeight 读取 canary ingress 上的 canary-weight 注解
+func (p *NginxProvider) CanaryWeight(ctx context.Context, host string) (int32, error) {
+	var ingress networkingv1.Ingress
+	err := p.Client.Get(ctx, client.ObjectKey{Name: p.getCanaryIngressName(host), Namespace: p.Namespace}, &ingress)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if ingress.Annotations[canaryAnnotation] != "true" {
+		return 0, nil
+	}
+	w, err := strconv.Atoi(ingress.Annotations[canaryWeightAnnotation])
+	if err != nil {
+		return 0, fmt.Errorf("invalid canary weight on ingress %s: %w", ingress.Name, err)
+	}
+	return int32(w), nil
+}
+
+// Cleanup 删除 stable/canary 两个 ingress，Rollout 删除时调用
+func (p *NginxProvider) Cleanup(ctx context.Context, host string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Deleting nginx ingresses", "host", host)
+
+	if err := p.deleteCanaryIngress(ctx, host); err != nil {
+		return fmt.Errorf("failed to delete canary ingress: %w", err)
+	}
+	return p.deleteIngress(ctx, p.getStableIngressName(host))
+}
+
 // ensureStableIngress 确保主 ingress 存在并指向 stable service
 func (p *NginxProvider) ensureStableIngress(ctx context.Context, host, stableService string) error {
 	ingressName := p.getStableIngressName(host)
@@ -74,9 +128,11 @@ func (p *NginxProvider) ensureStableIngress(ctx context.Context, host, stableSer
 		return err
 	}
 
-	// 检查是否需要更新 service
-	if p.needsServiceUpdate(&ingress, stableService) {
+	// 检查是否需要更新 service、补齐管理标签或同步 namespace 配置
+	configChanged := p.applyConfig(&ingress, false)
+	if p.needsServiceUpdate(&ingress, stableService) || ingress.Labels[ManagedByLabel] != ManagedByValue || configChanged {
 		p.updateIngressService(&ingress, stableService)
+		setManagedBy(&ingress)
 		return p.Client.Update(ctx, &ingress)
 	}
 
@@ -99,8 +155,10 @@ func (p *NginxProvider) updateCanaryIngress(ctx context.Context, host, stable, c
 	}
 
 	// 更新现有的 canary ingress
+	p.applyConfig(&ingress, true)
 	p.updateCanaryAnnotations(&ingress, weight)
 	p.updateIngressService(&ingress, canary)
+	setManagedBy(&ingress)
 	return p.Client.Update(ctx, &ingress)
 }
 
@@ -114,13 +172,16 @@ func (p *NginxProvider) promoteToStable(ctx context.Context, host, stable, canar
 	}
 
 	p.updateIngressService(&ingress, canary)
+	setManagedBy(&ingress)
 	return p.Client.Update(ctx, &ingress)
 }
 
 // deleteCanaryIngress 删除 canary ingress
 func (p *NginxProvider) deleteCanaryIngress(ctx context.Context, host string) error {
-	ingressName := p.getCanaryIngressName(host)
+	return p.deleteIngress(ctx, p.getCanaryIngressName(host))
+}
 
+func (p *NginxProvider) deleteIngress(ctx context.Context, ingressName string) error {
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ingressName,
@@ -152,12 +213,14 @@ func (p *NginxProvider) createStableIngressSpec(name, host, service string) *net
 			Name:      name,
 			Namespace: p.Namespace,
 			Labels: map[string]string{
-				"app":   "rollout-stable",
-				"track": "stable",
+				"app":          "rollout-stable",
+				"track":        "stable",
+				ManagedByLabel: ManagedByValue,
 			},
+			Annotations: p.withAnnotations(p.stickyAnnotations(false)),
 		},
 		Spec: networkingv1.IngressSpec{
-			IngressClassName: stringPtr("nginx"),
+			IngressClassName: stringPtr(p.ingressClassName()),
 			Rules: []networkingv1.IngressRule{
 				{
 					Host: host,
@@ -193,16 +256,17 @@ func (p *NginxProvider) createCanaryIngressSpec(name, host, service string, weig
 			Name:      name,
 			Namespace: p.Namespace,
 			Labels: map[string]string{
-				"app":   "rollout-canary",
-				"track": "canary",
-			},
-			Annotations: map[string]string{
-				"nginx.ingress.kubernetes.io/canary":        "true",
-				"nginx.ingress.kubernetes.io/canary-weight": strconv.Itoa(int(weight)),
+				"app":          "rollout-canary",
+				"track":        "canary",
+				ManagedByLabel: ManagedByValue,
 			},
+			Annotations: p.withAnnotations(mergeAnnotations(p.stickyAnnotations(true), map[string]string{
+				canaryAnnotation:       "true",
+				canaryWeightAnnotation: strconv.Itoa(int(weight)),
+			})),
 		},
 		Spec: networkingv1.IngressSpec{
-			IngressClassName: stringPtr("nginx"),
+			IngressClassName: stringPtr(p.ingressClassName()),
 			Rules: []networkingv1.IngressRule{
 				{
 					Host: host,
@@ -257,8 +321,103 @@ func (p *NginxProvider) updateCanaryAnnotations(ingress *networkingv1.Ingress, w
 	if ingress.Annotations == nil {
 		ingress.Annotations = make(map[string]string)
 	}
-	ingress.Annotations["nginx.ingress.kubernetes.io/canary"] = "true"
-	ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(int(weight))
+	ingress.Annotations[canaryAnnotation] = "true"
+	ingress.Annotations[canaryWeightAnnotation] = strconv.Itoa(int(weight))
+}
+
+func setManagedBy(ingress *networkingv1.Ingress) {
+	if ingress.Labels == nil {
+		ingress.Labels = make(map[string]string)
+	}
+	ingress.Labels[ManagedByLabel] = ManagedByValue
+}
+
+func (p *NginxProvider) ingressClassName() string {
+	if p.IngressClassName == "" {
+		return "nginx"
+	}
+	return p.IngressClassName
+}
+
+// applyConfig 让已有 ingress 跟上当前的 class、附加注解与会话保持配置，返回是否有修改
+func (p *NginxProvider) applyConfig(ingress *networkingv1.Ingress, canary bool) bool {
+	changed := false
+	if class := p.ingressClassName(); ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName != class {
+		ingress.Spec.IngressClassName = stringPtr(class)
+		changed = true
+	}
+	for k, v := range p.Annotations {
+		if ingress.Annotations[k] != v {
+			if ingress.Annotations == nil {
+				ingress.Annotations = make(map[string]string)
+			}
+			ingress.Annotations[k] = v
+			changed = true
+		}
+	}
+	sticky := p.stickyAnnotations(canary)
+	for _, k := range stickyAnnotationKeys {
+		v, want := sticky[k]
+		_, configured := p.Annotations[k]
+		current, present := ingress.Annotations[k]
+		switch {
+		case want && current != v:
+			if ingress.Annotations == nil {
+				ingress.Annotations = make(map[string]string)
+			}
+			ingress.Annotations[k] = v
+			changed = true
+		case !want && !configured && present:
+			delete(ingress.Annotations, k)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// stickyAnnotations 会话保持需要的注解。ingress-nginx 的 canary ingress 默认就会沿用 affinity 注解，
+// 这里仍显式声明 affinity-canary-behavior=sticky：带着 canary cookie 的请求始终留在 canary，不再按权重重新分配
+func (p *NginxProvider) stickyAnnotations(canary bool) map[string]string {
+	if p.StickySession == nil {
+		return nil
+	}
+	out := map[string]string{
+		affinityAnnotation:          "cookie",
+		sessionCookieNameAnnotation: p.StickySession.CookieName,
+	}
+	if p.StickySession.MaxAgeSeconds > 0 {
+		out[sessionCookieMaxAgeAnnotation] = strconv.Itoa(int(p.StickySession.MaxAgeSeconds))
+	}
+	if canary {
+		out[affinityCanaryBehaviorAnnotation] = "sticky"
+	}
+	return out
+}
+
+// withAnnotations 在 provider 配置的注解之上叠加 ingress 自身的注解
+func (p *NginxProvider) withAnnotations(own map[string]string) map[string]string {
+	if len(p.Annotations) == 0 {
+		return own
+	}
+	out := make(map[string]string, len(p.Annotations)+len(own))
+	for k, v := range p.Annotations {
+		out[k] = v
+	}
+	for k, v := range own {
+		out[k] = v
+	}
+	return out
+}
+
+// mergeAnnotations 合并多组注解，后面的覆盖前面的
+func mergeAnnotations(groups ...map[string]string) map[string]string {
+	out := map[string]string{}
+	for _, g := range groups {
+		for k, v := range g {
+			out[k] = v
+		}
+	}
+	return out
 }
 
 func stringPtr(s string) *string {
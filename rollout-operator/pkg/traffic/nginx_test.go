@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("NginxProvider", func() {
+	ctx := context.Background()
+	const host = "demo.example.com"
+
+	getIngress := func(c client.Client, name string) *networkingv1.Ingress {
+		ing := &networkingv1.Ingress{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, ing)).To(Succeed())
+		return ing
+	}
+
+	Context("with a sticky session", func() {
+		var c client.Client
+		var p *NginxProvider
+
+		BeforeEach(func() {
+			c = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			p = &NginxProvider{
+				Client:        c,
+				Namespace:     "default",
+				StickySession: &StickySession{CookieName: "canary-session", MaxAgeSeconds: 3600},
+			}
+		})
+
+		It("sets cookie affinity on both ingresses and keeps canary sessions on canary", func() {
+			Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(Succeed())
+
+			stable := getIngress(c, host+"-stable")
+			Expect(stable.Annotations).To(HaveKeyWithValue(affinityAnnotation, "cookie"))
+			Expect(stable.Annotations).To(HaveKeyWithValue(sessionCookieNameAnnotation, "canary-session"))
+			Expect(stable.Annotations).To(HaveKeyWithValue(sessionCookieMaxAgeAnnotation, "3600"))
+			Expect(stable.Annotations).NotTo(HaveKey(affinityCanaryBehaviorAnnotation))
+			Expect(stable.Annotations).NotTo(HaveKey(canaryAnnotation))
+
+			canary := getIngress(c, host+"-canary")
+			Expect(canary.Annotations).To(HaveKeyWithValue(canaryAnnotation, "true"))
+			Expect(canary.Annotations).To(HaveKeyWithValue(canaryWeightAnnotation, "10"))
+			Expect(canary.Annotations).To(HaveKeyWithValue(affinityAnnotation, "cookie"))
+			Expect(canary.Annotations).To(HaveKeyWithValue(affinityCanaryBehaviorAnnotation, "sticky"))
+			Expect(canary.Annotations).To(HaveKeyWithValue(sessionCookieNameAnnotation, "canary-session"))
+		})
+
+		It("keeps the affinity annotations as the weight increases", func() {
+			Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(Succeed())
+			Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 50)).To(Succeed())
+
+			canary := getIngress(c, host+"-canary")
+			Expect(canary.Annotations).To(HaveKeyWithValue(canaryWeightAnnotation, "50"))
+			Expect(canary.Annotations).To(HaveKeyWithValue(affinityCanaryBehaviorAnnotation, "sticky"))
+			Expect(canary.Annotations).To(HaveKeyWithValue(sessionCookieNameAnnotation, "canary-session"))
+		})
+
+		It("removes the affinity annotations once the sticky session is turned off", func() {
+			Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(Succeed())
+
+			plain := &NginxProvider{
+				Client:      c,
+				Namespace:   "default",
+				Annotations: map[string]string{affinityAnnotation: "cookie"},
+			}
+			Expect(plain.SetWeight(ctx, host, "demo-stable", "demo-canary", 30)).To(Succeed())
+
+			for _, name := range []string{host + "-stable", host + "-canary"} {
+				ing := getIngress(c, name)
+				Expect(ing.Annotations).NotTo(HaveKey(sessionCookieNameAnnotation), name)
+				Expect(ing.Annotations).NotTo(HaveKey(sessionCookieMaxAgeAnnotation), name)
+				Expect(ing.Annotations).NotTo(HaveKey(affinityCanaryBehaviorAnnotation), name)
+				// 由 namespace 配置附加的注解保留
+				Expect(ing.Annotations).To(HaveKeyWithValue(affinityAnnotation, "cookie"), name)
+			}
+		})
+	})
+
+	It("does not add affinity annotations without a sticky session", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		p := &NginxProvider{Client: c, Namespace: "default"}
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(Succeed())
+
+		for _, name := range []string{host + "-stable", host + "-canary"} {
+			ing := getIngress(c, name)
+			for _, k := range stickyAnnotationKeys {
+				Expect(ing.Annotations).NotTo(HaveKey(k), name)
+			}
+		}
+	})
+})
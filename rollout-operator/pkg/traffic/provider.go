@@ -2,8 +2,18 @@ package traffic
 
 import "context"
 
+// provider 创建的流量资源都带有该标签，控制器据此只关注自己管理的对象
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "rollout-operator"
+)
+
 type Provider interface {
 	SetWeight(ctx context.Context, host, stableSvc, canarySvc string, weight int32) error
 	Promote(ctx context.Context, host, stableSvc, canarySvc string) error
 	Reset(ctx context.Context, host, stableSvc, canarySvc string) error
+	// CanaryWeight 读取流量层当前实际生效的金丝雀权重；没有金丝雀资源时为 0
+	CanaryWeight(ctx context.Context, host string) (int32, error)
+	// Cleanup 删除 provider 为该 host 创建的全部流量资源
+	Cleanup(ctx context.Context, host string) error
 }
@@ -0,0 +1,237 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// replicaRatioWeightAnnotation 共享 Service 上记录最近一次设置的权重；副本数是取整后的结果，无法反推出原始权重
+const replicaRatioWeightAnnotation = "delivery.example.com/canary-weight"
+
+// trackLabel deploymentWorkload 的 stable/canary Service 选择器中区分两侧的标签
+const trackLabel = "track"
+
+// ReplicaRatioProvider 不依赖 ingress 或服务网格：名为 host 的共享 Service 同时选中 stable 与 canary 的 Pod，
+// 权重通过两个 Deployment 的副本数近似实现。限制：
+//   - 权重粒度为 100/Replicas，权重大于 0 时 canary 至少 1 个 Pod、小于 100 时 stable 至少 1 个 Pod
+//   - kube-proxy 按连接而不是按请求分配，长连接客户端会一直停留在同一侧
+//   - 每次调整权重都要启停 Pod，Pod 就绪前实际比例与目标不一致
+//   - 副本数由 provider 管理，不能再对这两个 Deployment 使用 HPA
+type ReplicaRatioProvider struct {
+	Client    client.Client
+	Namespace string
+	// StableDeployment/CanaryDeployment 分别承载 stable/canary Pod 的 Deployment
+	StableDeployment string
+	CanaryDeployment string
+	// Replicas stable 与 canary 共享的 Pod 总数
+	Replicas int32
+}
+
+// ReplicaSplit 把权重换算成 stable/canary 副本数：按比例四舍五入，
+// 权重大于 0 时 canary 至少 1 个，权重小于 100 时 stable 至少 1 个（总数不足 2 时 canary 优先）
+func ReplicaSplit(total, weight int32) (stable, canary int32) {
+	if total <= 0 {
+		return 0, 0
+	}
+	switch {
+	case weight <= 0:
+		return total, 0
+	case weight >= 100:
+		return 0, total
+	}
+	canary = (total*weight + 50) / 100
+	if canary < 1 {
+		canary = 1
+	}
+	if canary >= total && total > 1 {
+		canary = total - 1
+	}
+	return total - canary, canary
+}
+
+// SetWeight 确保共享 Service 存在，并按权重调整两侧副本数
+func (p *ReplicaRatioProvider) SetWeight(ctx context.Context, host, stable, canary string, weight int32) error {
+	lg := log.FromContext(ctx)
+	stableReplicas, canaryReplicas := ReplicaSplit(p.Replicas, weight)
+	lg.Info("Setting replica ratio", "service", host, "weight", weight, "stableReplicas", stableReplicas, "canaryReplicas", canaryReplicas)
+	return p.apply(ctx, host, stable, weight)
+}
+
+// Promote 全部 Pod 交给 canary，stable 缩到 0
+func (p *ReplicaRatioProvider) Promote(ctx context.Context, host, stable, canary string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Promoting canary replicas", "service", host, "stable", stable, "canary", canary)
+	return p.apply(ctx, host, stable, 100)
+}
+
+// Reset 全部 Pod 交给 stable，canary 缩到 0
+func (p *ReplicaRatioProvider) Reset(ctx context.Context, host, stable, canary string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Resetting replicas to stable", "service", host, "stable", stable, "canary", canary)
+	return p.apply(ctx, host, stable, 0)
+}
+
+// CanaryWeight 两侧副本数与共享 Service 上记录的权重一致时返回该权重，否则按实际副本比例估算
+func (p *ReplicaRatioProvider) CanaryWeight(ctx context.Context, host string) (int32, error) {
+	var svc corev1.Service
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, &svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	stableReplicas, err := p.replicas(ctx, p.StableDeployment)
+	if err != nil {
+		return 0, err
+	}
+	canaryReplicas, err := p.replicas(ctx, p.CanaryDeployment)
+	if err != nil {
+		return 0, err
+	}
+	if raw, ok := svc.Annotations[replicaRatioWeightAnnotation]; ok {
+		w, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("parse %s on service %s: %w", replicaRatioWeightAnnotation, host, err)
+		}
+		if s, c := ReplicaSplit(p.Replicas, int32(w)); s == stableReplicas && c == canaryReplicas {
+			return int32(w), nil
+		}
+	}
+	if stableReplicas+canaryReplicas == 0 {
+		return 0, nil
+	}
+	return (canaryReplicas*100 + (stableReplicas+canaryReplicas)/2) / (stableReplicas + canaryReplicas), nil
+}
+
+// Cleanup 删除共享 Service；Deployment 归 Rollout 所有，不在这里删除
+func (p *ReplicaRatioProvider) Cleanup(ctx context.Context, host string) error {
+	lg := log.FromContext(ctx)
+	var svc corev1.Service
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, &svc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if svc.Labels[ManagedByLabel] != ManagedByValue {
+		lg.Info("Service not managed by rollout-operator, leaving it", "service", host)
+		return nil
+	}
+	lg.Info("Deleting shared service", "service", host)
+	return client.IgnoreNotFound(p.Client.Delete(ctx, &svc))
+}
+
+// apply 先调整副本数，再把权重记到共享 Service 上，中途失败时下次调和会发现不一致并重做
+func (p *ReplicaRatioProvider) apply(ctx context.Context, host, stable string, weight int32) error {
+	stableReplicas, canaryReplicas := ReplicaSplit(p.Replicas, weight)
+	if err := p.scale(ctx, p.StableDeployment, stableReplicas); err != nil {
+		return fmt.Errorf("failed to scale stable deployment: %w", err)
+	}
+	if err := p.scale(ctx, p.CanaryDeployment, canaryReplicas); err != nil {
+		return fmt.Errorf("failed to scale canary deployment: %w", err)
+	}
+	if err := p.ensureSharedService(ctx, host, stable, weight); err != nil {
+		return fmt.Errorf("failed to ensure shared service: %w", err)
+	}
+	return nil
+}
+
+func (p *ReplicaRatioProvider) replicas(ctx context.Context, name string) (int32, error) {
+	var dep appsv1.Deployment
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: p.Namespace}, &dep); err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if dep.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return *dep.Spec.Replicas, nil
+}
+
+func (p *ReplicaRatioProvider) scale(ctx context.Context, name string, replicas int32) error {
+	var dep appsv1.Deployment
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: p.Namespace}, &dep); err != nil {
+		return err
+	}
+	if dep.Spec.Replicas != nil && *dep.Spec.Replicas == replicas {
+		return nil
+	}
+	patch := client.MergeFrom(dep.DeepCopy())
+	dep.Spec.Replicas = &replicas
+	return p.Client.Patch(ctx, &dep, patch)
+}
+
+// ensureSharedService 以 stable Service 为模板创建共享 Service，并记录当前权重
+func (p *ReplicaRatioProvider) ensureSharedService(ctx context.Context, host, stableService string, weight int32) error {
+	var stable corev1.Service
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: stableService, Namespace: p.Namespace}, &stable); err != nil {
+		return err
+	}
+	want := sharedService(&stable, host)
+	w := strconv.Itoa(int(weight))
+
+	var svc corev1.Service
+	err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, &svc)
+	if apierrors.IsNotFound(err) {
+		want.Annotations = map[string]string{replicaRatioWeightAnnotation: w}
+		return p.Client.Create(ctx, want)
+	} else if err != nil {
+		return err
+	}
+	if svc.Labels[ManagedByLabel] != ManagedByValue {
+		return fmt.Errorf("service %s/%s exists and is not managed by %s", p.Namespace, host, ManagedByValue)
+	}
+
+	patch := client.MergeFrom(svc.DeepCopy())
+	changed := false
+	if svc.Annotations[replicaRatioWeightAnnotation] != w {
+		if svc.Annotations == nil {
+			svc.Annotations = map[string]string{}
+		}
+		svc.Annotations[replicaRatioWeightAnnotation] = w
+		changed = true
+	}
+	if !equality.Semantic.DeepEqual(svc.Spec.Selector, want.Spec.Selector) {
+		svc.Spec.Selector = want.Spec.Selector
+		changed = true
+	}
+	if !equality.Semantic.DeepDerivative(want.Spec.Ports, svc.Spec.Ports) {
+		svc.Spec.Ports = want.Spec.Ports
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return p.Client.Patch(ctx, &svc, patch)
+}
+
+// sharedService 以 stable Service 为模板构造同时选中 stable/canary Pod 的 Service：
+// 端口相同，选择器去掉区分两侧的 track（Deployment）与 controller-revision-hash（StatefulSet）
+func sharedService(stable *corev1.Service, name string) *corev1.Service {
+	selector := make(map[string]string, len(stable.Spec.Selector))
+	for k, v := range stable.Spec.Selector {
+		if k != trackLabel && k != appsv1.ControllerRevisionHashLabelKey {
+			selector[k] = v
+		}
+	}
+	ports := make([]corev1.ServicePort, 0, len(stable.Spec.Ports))
+	for _, port := range stable.Spec.Ports {
+		ports = append(ports, corev1.ServicePort{Name: port.Name, Protocol: port.Protocol, Port: port.Port, TargetPort: port.TargetPort})
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: stable.Namespace,
+			Labels:    map[string]string{ManagedByLabel: ManagedByValue},
+		},
+		Spec: corev1.ServiceSpec{Selector: selector, Ports: ports},
+	}
+}
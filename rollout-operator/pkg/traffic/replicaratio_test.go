@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = DescribeTable("ReplicaSplit",
+	func(total, weight, wantStable, wantCanary int32) {
+		stable, canary := ReplicaSplit(total, weight)
+		Expect(stable).To(Equal(wantStable), "stable replicas")
+		Expect(canary).To(Equal(wantCanary), "canary replicas")
+	},
+	// 默认 10 个 Pod 下常见的步骤权重
+	Entry("10 pods, weight 0", int32(10), int32(0), int32(10), int32(0)),
+	Entry("10 pods, weight 10", int32(10), int32(10), int32(9), int32(1)),
+	Entry("10 pods, weight 25 rounds up", int32(10), int32(25), int32(7), int32(3)),
+	Entry("10 pods, weight 50", int32(10), int32(50), int32(5), int32(5)),
+	Entry("10 pods, weight 100", int32(10), int32(100), int32(0), int32(10)),
+	Entry("10 pods, weight 1 keeps one canary pod", int32(10), int32(1), int32(9), int32(1)),
+	Entry("10 pods, weight 99 keeps one stable pod", int32(10), int32(99), int32(1), int32(9)),
+	Entry("4 pods, weight 30", int32(4), int32(30), int32(3), int32(1)),
+	Entry("20 pods, weight 5", int32(20), int32(5), int32(19), int32(1)),
+	Entry("3 pods, weight 50", int32(3), int32(50), int32(1), int32(2)),
+	Entry("2 pods, weight 90", int32(2), int32(90), int32(1), int32(1)),
+	Entry("no pods", int32(0), int32(50), int32(0), int32(0)),
+)
+
+var _ = Describe("ReplicaRatioProvider", func() {
+	ctx := context.Background()
+	const host = "demo"
+
+	var c client.Client
+	var p *ReplicaRatioProvider
+
+	deployment := func(name string) *appsv1.Deployment {
+		replicas := int32(2)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+	replicasOf := func(name string) int32 {
+		dep := &appsv1.Deployment{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, dep)).To(Succeed())
+		return *dep.Spec.Replicas
+	}
+
+	BeforeEach(func() {
+		stableSvc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo-stable", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "demo", "track": "stable"},
+				Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(stableSvc, deployment("demo-stable"), deployment("demo-canary")).Build()
+		p = &ReplicaRatioProvider{
+			Client:           c,
+			Namespace:        "default",
+			StableDeployment: "demo-stable",
+			CanaryDeployment: "demo-canary",
+			Replicas:         10,
+		}
+	})
+
+	It("scales both deployments and points the shared service at both tracks", func() {
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 30)).To(Succeed())
+		Expect(replicasOf("demo-stable")).To(Equal(int32(7)))
+		Expect(replicasOf("demo-canary")).To(Equal(int32(3)))
+
+		svc := &corev1.Service{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: host}, svc)).To(Succeed())
+		Expect(svc.Spec.Selector).To(Equal(map[string]string{"app": "demo"}))
+		Expect(svc.Spec.Ports).To(HaveLen(1))
+		Expect(svc.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(8080)))
+		Expect(svc.Labels).To(HaveKeyWithValue(ManagedByLabel, ManagedByValue))
+
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(30)))
+	})
+
+	It("reports the requested weight even when replicas are rounded", func() {
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 5)).To(Succeed())
+		Expect(replicasOf("demo-canary")).To(Equal(int32(1)))
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(5)))
+	})
+
+	It("reports the actual ratio after the deployments are scaled by hand", func() {
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(Succeed())
+		dep := &appsv1.Deployment{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "demo-canary"}, dep)).To(Succeed())
+		replicas := int32(9)
+		dep.Spec.Replicas = &replicas
+		Expect(c.Update(ctx, dep)).To(Succeed())
+
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(50)))
+	})
+
+	It("moves all pods to one side on promote and reset", func() {
+		Expect(p.Promote(ctx, host, "demo-stable", "demo-canary")).To(Succeed())
+		Expect(replicasOf("demo-stable")).To(Equal(int32(0)))
+		Expect(replicasOf("demo-canary")).To(Equal(int32(10)))
+
+		Expect(p.Reset(ctx, host, "demo-stable", "demo-canary")).To(Succeed())
+		Expect(replicasOf("demo-stable")).To(Equal(int32(10)))
+		Expect(replicasOf("demo-canary")).To(Equal(int32(0)))
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(0)))
+	})
+
+	It("refuses to take over a service it did not create and only cleans up its own", func() {
+		Expect(c.Create(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: host, Namespace: "default"}})).To(Succeed())
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(MatchError(ContainSubstring("not managed by")))
+
+		Expect(p.Cleanup(ctx, host)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: host}, &corev1.Service{})).To(Succeed())
+	})
+})
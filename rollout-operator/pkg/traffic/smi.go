@@ -0,0 +1,190 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TrafficSplitGVK Linkerd（linkerd-smi）与 OSM 都支持的 TrafficSplit 版本，权重为整数
+var TrafficSplitGVK = schema.GroupVersionKind{Group: "split.smi-spec.io", Version: "v1alpha2", Kind: "TrafficSplit"}
+
+// smiCanaryServiceAnnotation TrafficSplit 上记录哪个 backend 是 canary，CanaryWeight 只拿到 host
+const smiCanaryServiceAnnotation = "delivery.example.com/canary-service"
+
+// SMIProvider 通过与 host 同名的 TrafficSplit 在 stable/canary Service 之间分配流量。
+// host 是客户端访问的根 Service：不存在时按 stable Service 创建一个同时选中两侧 Pod 的 Service，已存在时原样使用。
+// TrafficSplit 用 unstructured 读写，集群里没有安装 SMI CRD 时只在使用该 provider 的 Rollout 上报错
+type SMIProvider struct {
+	Client    client.Client
+	Namespace string
+}
+
+// SetWeight 确保根 Service 存在，并把 TrafficSplit 的 backend 权重设为 stable=100-weight、canary=weight
+func (p *SMIProvider) SetWeight(ctx context.Context, host, stable, canary string, weight int32) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Setting SMI traffic split weight", "service", host, "stable", stable, "canary", canary, "weight", weight)
+	return p.apply(ctx, host, stable, canary, weight)
+}
+
+// Promote 流量全部切到 canary；保留 TrafficSplit，否则根 Service 会把流量分到两侧 Pod
+func (p *SMIProvider) Promote(ctx context.Context, host, stable, canary string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Promoting SMI traffic split to canary", "service", host, "stable", stable, "canary", canary)
+	return p.apply(ctx, host, stable, canary, 100)
+}
+
+// Reset 流量全部切回 stable
+func (p *SMIProvider) Reset(ctx context.Context, host, stable, canary string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Resetting SMI traffic split to stable", "service", host, "stable", stable, "canary", canary)
+	return p.apply(ctx, host, stable, canary, 0)
+}
+
+// CanaryWeight 按 canary backend 在所有 backend 权重之和中的占比换算成百分比；没有 TrafficSplit 时为 0
+func (p *SMIProvider) CanaryWeight(ctx context.Context, host string) (int32, error) {
+	split := newTrafficSplit()
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, split); err != nil {
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	canary := split.GetAnnotations()[smiCanaryServiceAnnotation]
+	backends, _, err := unstructured.NestedSlice(split.Object, "spec", "backends")
+	if err != nil {
+		return 0, fmt.Errorf("read backends of traffic split %s: %w", host, err)
+	}
+	var total, canaryWeight int64
+	for _, b := range backends {
+		backend, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		w := backendWeight(backend["weight"])
+		total += w
+		if backend["service"] == canary {
+			canaryWeight += w
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return int32((canaryWeight*100 + total/2) / total), nil
+}
+
+// Cleanup 删除 TrafficSplit，以及由 provider 创建的根 Service
+func (p *SMIProvider) Cleanup(ctx context.Context, host string) error {
+	lg := log.FromContext(ctx)
+	lg.Info("Deleting SMI traffic split", "service", host)
+
+	split := newTrafficSplit()
+	split.SetName(host)
+	split.SetNamespace(p.Namespace)
+	if err := p.Client.Delete(ctx, split); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete traffic split: %w", err)
+	}
+	var svc corev1.Service
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, &svc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if svc.Labels[ManagedByLabel] != ManagedByValue {
+		return nil
+	}
+	return client.IgnoreNotFound(p.Client.Delete(ctx, &svc))
+}
+
+func (p *SMIProvider) apply(ctx context.Context, host, stable, canary string, weight int32) error {
+	if err := p.ensureRootService(ctx, host, stable); err != nil {
+		return fmt.Errorf("failed to ensure root service: %w", err)
+	}
+	if err := p.ensureTrafficSplit(ctx, host, stable, canary, weight); err != nil {
+		return fmt.Errorf("failed to ensure traffic split: %w", err)
+	}
+	return nil
+}
+
+// ensureRootService 根 Service 不存在时以 stable Service 为模板创建
+func (p *SMIProvider) ensureRootService(ctx context.Context, host, stableService string) error {
+	var svc corev1.Service
+	err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, &svc)
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+	var stable corev1.Service
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: stableService, Namespace: p.Namespace}, &stable); err != nil {
+		return err
+	}
+	return p.Client.Create(ctx, sharedService(&stable, host))
+}
+
+func (p *SMIProvider) ensureTrafficSplit(ctx context.Context, host, stable, canary string, weight int32) error {
+	backends := []interface{}{
+		map[string]interface{}{"service": stable, "weight": int64(100 - weight)},
+		map[string]interface{}{"service": canary, "weight": int64(weight)},
+	}
+
+	split := newTrafficSplit()
+	err := p.Client.Get(ctx, client.ObjectKey{Name: host, Namespace: p.Namespace}, split)
+	if apierrors.IsNotFound(err) {
+		split = newTrafficSplit()
+		split.SetName(host)
+		split.SetNamespace(p.Namespace)
+		split.SetLabels(map[string]string{ManagedByLabel: ManagedByValue})
+		split.SetAnnotations(map[string]string{smiCanaryServiceAnnotation: canary})
+		if err := unstructured.SetNestedField(split.Object, host, "spec", "service"); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedSlice(split.Object, backends, "spec", "backends"); err != nil {
+			return err
+		}
+		return p.Client.Create(ctx, split)
+	} else if err != nil {
+		return err
+	}
+	if split.GetLabels()[ManagedByLabel] != ManagedByValue {
+		return fmt.Errorf("traffic split %s/%s exists and is not managed by %s", p.Namespace, host, ManagedByValue)
+	}
+
+	orig := split.DeepCopy()
+	annotations := split.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[smiCanaryServiceAnnotation] = canary
+	split.SetAnnotations(annotations)
+	if err := unstructured.SetNestedField(split.Object, host, "spec", "service"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedSlice(split.Object, backends, "spec", "backends"); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(orig.Object, split.Object) {
+		return nil
+	}
+	return p.Client.Patch(ctx, split, client.MergeFrom(orig))
+}
+
+func newTrafficSplit() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(TrafficSplitGVK)
+	return u
+}
+
+// backendWeight 从 API server 读回的整数可能被解码成 int64 或 float64
+func backendWeight(v interface{}) int64 {
+	switch w := v.(type) {
+	case int64:
+		return w
+	case float64:
+		return int64(w)
+	}
+	return 0
+}
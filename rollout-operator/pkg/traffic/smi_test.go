@@ -0,0 +1,105 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("SMIProvider", func() {
+	ctx := context.Background()
+	const host = "demo"
+
+	var c client.Client
+	var p *SMIProvider
+
+	backends := func() []interface{} {
+		split := newTrafficSplit()
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: host}, split)).To(Succeed())
+		service, _, _ := unstructured.NestedString(split.Object, "spec", "service")
+		Expect(service).To(Equal(host))
+		b, _, err := unstructured.NestedSlice(split.Object, "spec", "backends")
+		Expect(err).NotTo(HaveOccurred())
+		return b
+	}
+	backend := func(service string, weight int64) map[string]interface{} {
+		return map[string]interface{}{"service": service, "weight": weight}
+	}
+
+	BeforeEach(func() {
+		stableSvc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo-stable", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "demo", "track": "stable"},
+				Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(stableSvc).Build()
+		p = &SMIProvider{Client: c, Namespace: "default"}
+	})
+
+	It("creates the root service and a traffic split between stable and canary", func() {
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 20)).To(Succeed())
+		Expect(backends()).To(Equal([]interface{}{backend("demo-stable", 80), backend("demo-canary", 20)}))
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(20)))
+
+		root := &corev1.Service{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: host}, root)).To(Succeed())
+		Expect(root.Spec.Selector).To(Equal(map[string]string{"app": "demo"}))
+
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 50)).To(Succeed())
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(50)))
+	})
+
+	It("sends everything to one side on promote and reset", func() {
+		Expect(p.Promote(ctx, host, "demo-stable", "demo-canary")).To(Succeed())
+		Expect(backends()).To(Equal([]interface{}{backend("demo-stable", 0), backend("demo-canary", 100)}))
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(100)))
+
+		Expect(p.Reset(ctx, host, "demo-stable", "demo-canary")).To(Succeed())
+		Expect(backends()).To(Equal([]interface{}{backend("demo-stable", 100), backend("demo-canary", 0)}))
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(0)))
+	})
+
+	It("keeps an existing root service and removes only what it created", func() {
+		Expect(c.Create(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: host, Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "demo-apex"}},
+		})).To(Succeed())
+		Expect(p.SetWeight(ctx, host, "demo-stable", "demo-canary", 10)).To(Succeed())
+
+		Expect(p.Cleanup(ctx, host)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: host}, newTrafficSplit())).NotTo(Succeed())
+		root := &corev1.Service{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: host}, root)).To(Succeed())
+		Expect(root.Spec.Selector).To(Equal(map[string]string{"app": "demo-apex"}))
+	})
+
+	It("reports no canary weight without a traffic split", func() {
+		Expect(p.CanaryWeight(ctx, host)).To(Equal(int32(0)))
+	})
+})